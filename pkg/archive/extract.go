@@ -0,0 +1,259 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractLimits bounds what extracting an archive may write, guarding against
+// decompression bombs. A limit of zero is no limit.
+type ExtractLimits struct {
+	// MaxBytes is the total size of the files extracted.
+	MaxBytes int64
+	// MaxEntries is the number of files, directories and links extracted.
+	MaxEntries int
+}
+
+// DefaultExtractLimits are generous enough for the largest buildpackages.
+var DefaultExtractLimits = ExtractLimits{
+	MaxBytes:   20 << 30,
+	MaxEntries: 1000000,
+}
+
+const maxSymlinkTargetLength = 4096
+
+// extractor writes the entries of an archive below root. Entries that would
+// end up outside of root, directly or through a symlink or hardlink, are
+// rejected.
+type extractor struct {
+	root    string
+	limits  ExtractLimits
+	bytes   int64
+	entries int
+}
+
+func newExtractor(dir string, limits ExtractLimits) (*extractor, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err = filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &extractor{root: root, limits: limits}, nil
+}
+
+func (e *extractor) mkdir(name string, mode os.FileMode) error {
+	path, err := e.entryPath(name)
+	if err != nil {
+		return err
+	}
+
+	// directories stay writable so the entries inside them can be extracted
+	return e.mkdirAll(path, mode.Perm()|0700)
+}
+
+func (e *extractor) writeFile(name string, mode os.FileMode, r io.Reader) error {
+	path, err := e.entryPath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := e.prepare(path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if e.limits.MaxBytes == 0 {
+		if _, err := io.Copy(file, r); err != nil {
+			return err
+		}
+		return file.Close()
+	}
+
+	remaining := e.limits.MaxBytes - e.bytes
+	n, err := io.Copy(file, io.LimitReader(r, remaining+1))
+	e.bytes += n
+	if err != nil {
+		return err
+	} else if n > remaining {
+		return fmt.Errorf("archive contents exceed %d bytes", e.limits.MaxBytes)
+	}
+	return file.Close()
+}
+
+func (e *extractor) symlink(name, target string) error {
+	path, err := e.entryPath(name)
+	if err != nil {
+		return err
+	}
+
+	// replacing a symlink could move what other symlinks resolve to
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s: already exists", name)
+	}
+
+	if err := e.prepare(path); err != nil {
+		return err
+	}
+
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+
+	if filepath.IsAbs(target) || !e.targetWithin(dir, target) {
+		return fmt.Errorf("%s: symlink target %s is outside of the archive", name, target)
+	}
+	return os.Symlink(target, path)
+}
+
+// targetWithin follows a symlink target from dir one component at a time,
+// resolving the symlinks extracted so far, and reports whether it stays within
+// root. Cleaning the target lexically is not enough, as s/.. is not . when s
+// is a symlink. A .. after a component that does not exist yet is rejected,
+// since a later entry could make that component a symlink.
+func (e *extractor) targetWithin(dir, target string) bool {
+	current := dir
+	missing := false
+	for _, component := range strings.Split(filepath.ToSlash(target), "/") {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			if missing {
+				return false
+			}
+			current = filepath.Dir(current)
+		default:
+			current = filepath.Join(current, component)
+			if missing {
+				break
+			}
+
+			fi, err := os.Lstat(current)
+			if err != nil {
+				missing = true
+			} else if fi.Mode()&os.ModeSymlink != 0 {
+				resolved, err := filepath.EvalSymlinks(current)
+				if err != nil {
+					missing = true
+				} else {
+					current = resolved
+				}
+			}
+		}
+
+		if !e.within(current) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *extractor) hardlink(name, target string) error {
+	path, err := e.entryPath(name)
+	if err != nil {
+		return err
+	}
+
+	targetPath, err := e.entryPath(target)
+	if err != nil {
+		return err
+	}
+
+	if err := e.checkParents(targetPath); err != nil {
+		return err
+	}
+
+	fi, err := os.Lstat(targetPath)
+	if err != nil || !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s: hardlink target %s is not a file in the archive", name, target)
+	}
+
+	if err := e.prepare(path); err != nil {
+		return err
+	}
+	return os.Link(targetPath, path)
+}
+
+// countEntry enforces the entry limit. It is called for every entry, including
+// the ones that are skipped.
+func (e *extractor) countEntry() error {
+	e.entries++
+	if e.limits.MaxEntries != 0 && e.entries > e.limits.MaxEntries {
+		return fmt.Errorf("archive has more than %d entries", e.limits.MaxEntries)
+	}
+	return nil
+}
+
+// entryPath returns where the entry is extracted to. Leading slashes are
+// ignored, so /a and a are both extracted to root/a.
+func (e *extractor) entryPath(name string) (string, error) {
+	path := filepath.Join(e.root, filepath.FromSlash(name))
+	if !e.within(path) || path == e.root {
+		return "", fmt.Errorf("%s: illegal file path", name)
+	}
+	return path, nil
+}
+
+func (e *extractor) within(path string) bool {
+	return path == e.root || strings.HasPrefix(path, e.root+string(os.PathSeparator))
+}
+
+// prepare creates the parent directories of path and removes a symlink that
+// an earlier entry left at path, so that writing to path cannot follow it.
+func (e *extractor) prepare(path string) error {
+	if err := e.mkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(path)
+	}
+	return nil
+}
+
+func (e *extractor) mkdirAll(path string, mode os.FileMode) error {
+	if err := e.checkParents(path); err != nil {
+		return err
+	}
+	return os.MkdirAll(path, mode)
+}
+
+// checkParents resolves the deepest existing ancestor of path, which may be a
+// symlink extracted earlier, and fails when it points outside of root.
+func (e *extractor) checkParents(path string) error {
+	existing := path
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return err
+	}
+
+	if !e.within(resolved) {
+		rel, _ := filepath.Rel(e.root, path)
+		return fmt.Errorf("%s: illegal file path", filepath.ToSlash(rel))
+	}
+	return nil
+}
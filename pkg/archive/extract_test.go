@@ -0,0 +1,290 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/archive"
+)
+
+func TestExtract(t *testing.T) {
+	spec.Run(t, "Test archive extraction", testExtract)
+}
+
+type testEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	contents string
+}
+
+func testExtract(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tempDir string
+		dir     string
+	)
+
+	it.Before(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "extract-test")
+		require.NoError(t, err)
+
+		dir = filepath.Join(tempDir, "root")
+		require.NoError(t, os.Mkdir(dir, 0755))
+	})
+
+	it.After(func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	})
+
+	readTar := func(limits archive.ExtractLimits, entries ...testEntry) error {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		for _, entry := range entries {
+			require.NoError(t, tw.WriteHeader(&tar.Header{
+				Name:     entry.name,
+				Typeflag: entry.typeflag,
+				Linkname: entry.linkname,
+				Mode:     0644,
+				Size:     int64(len(entry.contents)),
+			}))
+			_, err := tw.Write([]byte(entry.contents))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+
+		return archive.ReadTarWithLimits(buf, dir, limits)
+	}
+
+	extractZip := func(limits archive.ExtractLimits, entries ...testEntry) error {
+		zipPath := filepath.Join(tempDir, "test.zip")
+		file, err := os.Create(zipPath)
+		require.NoError(t, err)
+
+		zw := zip.NewWriter(file)
+		for _, entry := range entries {
+			header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+			header.SetMode(0644)
+			if entry.typeflag == tar.TypeSymlink {
+				header.SetMode(os.ModeSymlink | 0777)
+				entry.contents = entry.linkname
+			}
+
+			w, err := zw.CreateHeader(header)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(entry.contents))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		require.NoError(t, file.Close())
+
+		return archive.ExtractZipWithLimits(zipPath, dir, limits)
+	}
+
+	requireNotExist := func(path string) {
+		_, err := os.Lstat(path)
+		require.True(t, os.IsNotExist(err), "expected %s not to exist", path)
+	}
+
+	when("#ReadTarWithLimits", func() {
+		it("restores directories, files, symlinks and hardlinks", func() {
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "app/", typeflag: tar.TypeDir},
+				testEntry{name: "app/a.txt", typeflag: tar.TypeReg, contents: "a"},
+				testEntry{name: "app/link", typeflag: tar.TypeSymlink, linkname: "a.txt"},
+				testEntry{name: "app/hard", typeflag: tar.TypeLink, linkname: "app/a.txt"},
+				testEntry{name: "nested/b.txt", typeflag: tar.TypeReg, contents: "b"},
+			)
+			require.NoError(t, err)
+
+			target, err := os.Readlink(filepath.Join(dir, "app", "link"))
+			require.NoError(t, err)
+			require.Equal(t, "a.txt", target)
+
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "app", "hard"))
+			require.NoError(t, err)
+			require.Equal(t, "a", string(contents))
+
+			contents, err = ioutil.ReadFile(filepath.Join(dir, "nested", "b.txt"))
+			require.NoError(t, err)
+			require.Equal(t, "b", string(contents))
+		})
+
+		it("rejects entries that escape the directory", func() {
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "../evil.txt", typeflag: tar.TypeReg, contents: "evil"},
+			)
+			require.EqualError(t, err, "../evil.txt: illegal file path")
+			requireNotExist(filepath.Join(tempDir, "evil.txt"))
+		})
+
+		it("rejects symlinks that point outside of the directory", func() {
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "etc", typeflag: tar.TypeSymlink, linkname: "/etc"},
+			)
+			require.EqualError(t, err, "etc: symlink target /etc is outside of the archive")
+
+			err = readTar(archive.DefaultExtractLimits,
+				testEntry{name: "up", typeflag: tar.TypeSymlink, linkname: "../.."},
+			)
+			require.EqualError(t, err, "up: symlink target ../.. is outside of the archive")
+			requireNotExist(filepath.Join(dir, "up"))
+		})
+
+		it("rejects symlinks that escape through an earlier symlink", func() {
+			// sub/self/up is created in the directory itself, where .. is outside
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "sub/self", typeflag: tar.TypeSymlink, linkname: ".."},
+				testEntry{name: "sub/self/up", typeflag: tar.TypeSymlink, linkname: ".."},
+			)
+			require.EqualError(t, err, "sub/self/up: symlink target .. is outside of the archive")
+			requireNotExist(filepath.Join(dir, "up"))
+		})
+
+		it("rejects symlinks that go up through an earlier symlink", func() {
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "s", typeflag: tar.TypeSymlink, linkname: "."},
+				testEntry{name: "t", typeflag: tar.TypeSymlink, linkname: "s/.."},
+			)
+			require.EqualError(t, err, "t: symlink target s/.. is outside of the archive")
+			requireNotExist(filepath.Join(dir, "t"))
+		})
+
+		it("rejects symlinks that go up through an entry that does not exist yet", func() {
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "t", typeflag: tar.TypeSymlink, linkname: "s/.."},
+				testEntry{name: "s", typeflag: tar.TypeSymlink, linkname: "."},
+			)
+			require.EqualError(t, err, "t: symlink target s/.. is outside of the archive")
+			requireNotExist(filepath.Join(dir, "t"))
+		})
+
+		it("rejects replacing a symlink other symlinks resolve through", func() {
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "a/b/", typeflag: tar.TypeDir},
+				testEntry{name: "s", typeflag: tar.TypeSymlink, linkname: "a/b"},
+				testEntry{name: "t", typeflag: tar.TypeSymlink, linkname: "s/../.."},
+				testEntry{name: "s", typeflag: tar.TypeSymlink, linkname: "."},
+			)
+			require.EqualError(t, err, "s: already exists")
+
+			target, err := os.Readlink(filepath.Join(dir, "s"))
+			require.NoError(t, err)
+			require.Equal(t, "a/b", target)
+		})
+
+		it("does not write through symlinks that point outside of the directory", func() {
+			require.NoError(t, os.Symlink(tempDir, filepath.Join(dir, "out")))
+
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "out/evil.txt", typeflag: tar.TypeReg, contents: "evil"},
+			)
+			require.EqualError(t, err, "out: illegal file path")
+			requireNotExist(filepath.Join(tempDir, "evil.txt"))
+		})
+
+		it("replaces a symlink rather than writing to its target", func() {
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "a.txt", typeflag: tar.TypeReg, contents: "a"},
+				testEntry{name: "link", typeflag: tar.TypeSymlink, linkname: "a.txt"},
+				testEntry{name: "link", typeflag: tar.TypeReg, contents: "link"},
+			)
+			require.NoError(t, err)
+
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "a.txt"))
+			require.NoError(t, err)
+			require.Equal(t, "a", string(contents))
+		})
+
+		it("rejects hardlinks to files outside of the archive", func() {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "secret"), []byte("secret"), 0600))
+
+			err := readTar(archive.DefaultExtractLimits,
+				testEntry{name: "secret", typeflag: tar.TypeLink, linkname: "../secret"},
+			)
+			require.EqualError(t, err, "../secret: illegal file path")
+
+			err = readTar(archive.DefaultExtractLimits,
+				testEntry{name: "link", typeflag: tar.TypeSymlink, linkname: "."},
+				testEntry{name: "hard", typeflag: tar.TypeLink, linkname: "link"},
+			)
+			require.EqualError(t, err, "hard: hardlink target link is not a file in the archive")
+		})
+
+		it("enforces the entry limit", func() {
+			err := readTar(archive.ExtractLimits{MaxEntries: 2},
+				testEntry{name: "a", typeflag: tar.TypeReg},
+				testEntry{name: "b", typeflag: tar.TypeReg},
+				testEntry{name: "c", typeflag: tar.TypeReg},
+			)
+			require.EqualError(t, err, "archive has more than 2 entries")
+			requireNotExist(filepath.Join(dir, "c"))
+		})
+
+		it("enforces the size limit", func() {
+			err := readTar(archive.ExtractLimits{MaxBytes: 10},
+				testEntry{name: "a", typeflag: tar.TypeReg, contents: "12345"},
+				testEntry{name: "b", typeflag: tar.TypeReg, contents: "123456"},
+			)
+			require.EqualError(t, err, "archive contents exceed 10 bytes")
+		})
+	})
+
+	when("#ExtractZipWithLimits", func() {
+		it("restores files and symlinks", func() {
+			err := extractZip(archive.DefaultExtractLimits,
+				testEntry{name: "app/a.txt", contents: "a"},
+				testEntry{name: "app/link", typeflag: tar.TypeSymlink, linkname: "a.txt"},
+			)
+			require.NoError(t, err)
+
+			target, err := os.Readlink(filepath.Join(dir, "app", "link"))
+			require.NoError(t, err)
+			require.Equal(t, "a.txt", target)
+		})
+
+		it("rejects entries and symlinks that escape the directory", func() {
+			err := extractZip(archive.DefaultExtractLimits,
+				testEntry{name: "../evil.txt", contents: "evil"},
+			)
+			require.EqualError(t, err, "../evil.txt: illegal file path")
+			requireNotExist(filepath.Join(tempDir, "evil.txt"))
+
+			err = extractZip(archive.DefaultExtractLimits,
+				testEntry{name: "etc", typeflag: tar.TypeSymlink, linkname: "/etc"},
+			)
+			require.EqualError(t, err, "etc: symlink target /etc is outside of the archive")
+		})
+
+		it("rejects symlinks that go up through an earlier symlink", func() {
+			err := extractZip(archive.DefaultExtractLimits,
+				testEntry{name: "s", typeflag: tar.TypeSymlink, linkname: "."},
+				testEntry{name: "t", typeflag: tar.TypeSymlink, linkname: "s/.."},
+			)
+			require.EqualError(t, err, "t: symlink target s/.. is outside of the archive")
+			requireNotExist(filepath.Join(dir, "t"))
+		})
+
+		it("stops decompressing once the size limit is reached", func() {
+			err := extractZip(archive.ExtractLimits{MaxBytes: 1024},
+				testEntry{name: "bomb", contents: string(make([]byte, 1<<20))},
+			)
+			require.EqualError(t, err, "archive contents exceed 1024 bytes")
+
+			fi, err := os.Stat(filepath.Join(dir, "bomb"))
+			require.NoError(t, err)
+			require.LessOrEqual(t, fi.Size(), int64(1025))
+		})
+	})
+}
@@ -0,0 +1,190 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	IgnoreFileName    = ".kpignore"
+	GitIgnoreFileName = ".gitignore"
+)
+
+// Ignorer matches paths against patterns in gitignore syntax. Paths are
+// relative to the root of the source and use forward slashes. A nil Ignorer
+// ignores nothing.
+type Ignorer struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	regexp  *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ParseIgnorer reads patterns in gitignore syntax, one per line.
+func ParseIgnorer(r io.Reader) (*Ignorer, error) {
+	ignorer := &Ignorer{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if pattern, ok := parseIgnorePattern(scanner.Text()); ok {
+			ignorer.patterns = append(ignorer.patterns, pattern)
+		}
+	}
+	return ignorer, scanner.Err()
+}
+
+// LoadIgnorer reads the .kpignore file at the root of dir. When there is no
+// .kpignore and useGitignore is set, the .gitignore file is read instead. It
+// returns nil when neither file exists.
+func LoadIgnorer(dir string, useGitignore bool) (*Ignorer, error) {
+	for _, name := range ignoreFileNames(useGitignore) {
+		file, err := os.Open(filepath.Join(dir, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return ParseIgnorer(file)
+	}
+	return nil, nil
+}
+
+func ignoreFileNames(useGitignore bool) []string {
+	if useGitignore {
+		return []string{IgnoreFileName, GitIgnoreFileName}
+	}
+	return []string{IgnoreFileName}
+}
+
+// Ignored reports whether the path is ignored. As with git, a path inside an
+// ignored directory is ignored even if a later pattern negates it.
+func (i *Ignorer) Ignored(relPath string, isDir bool) bool {
+	if i == nil {
+		return false
+	}
+
+	relPath = strings.Trim(relPath, "/")
+	segments := strings.Split(relPath, "/")
+	for n := 1; n < len(segments); n++ {
+		if i.matches(strings.Join(segments[:n], "/"), true) {
+			return true
+		}
+	}
+	return i.matches(relPath, isDir)
+}
+
+func (i *Ignorer) matches(relPath string, isDir bool) bool {
+	ignored := false
+	for _, pattern := range i.patterns {
+		if pattern.dirOnly && !isDir {
+			continue
+		}
+		if pattern.regexp.MatchString(relPath) {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
+
+func parseIgnorePattern(line string) (ignorePattern, bool) {
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	var pattern ignorePattern
+	if strings.HasPrefix(line, "!") {
+		pattern.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	// patterns with a slash other than a trailing one are relative to the
+	// root, others match at any depth
+	prefix := "^(?:.*/)?"
+	if strings.Contains(line, "/") {
+		prefix = "^"
+		line = strings.TrimPrefix(line, "/")
+	}
+
+	expr, err := regexp.Compile(prefix + globToRegexp(line) + "$")
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	pattern.regexp = expr
+	return pattern, true
+}
+
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/") && (i == 0 || glob[i-1] == '/'):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case glob[i:] == "**" && i > 0 && glob[i-1] == '/':
+			sb.WriteString(".*")
+			i++
+		case strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString("[^/]*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(glob[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
+
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return strings.ReplaceAll(line, `\ `, " ")
+}
+
+// cleanEntryName returns the relative path of an archive entry, such as
+// src/main.go for ./src/main.go or /src/main.go.
+func cleanEntryName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
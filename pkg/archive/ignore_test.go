@@ -0,0 +1,173 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/archive"
+)
+
+func TestIgnore(t *testing.T) {
+	spec.Run(t, "Test ignore files", testIgnore)
+}
+
+func testIgnore(t *testing.T, when spec.G, it spec.S) {
+	when("#Ignored", func() {
+		var ignorer *archive.Ignorer
+
+		it.Before(func() {
+			var err error
+			ignorer, err = archive.ParseIgnorer(strings.NewReader(`# comment
+.git
+node_modules/
+/target
+*.log
+!keep.log
+docs/**/*.png
+secrets/**
+\#hash
+`))
+			require.NoError(t, err)
+		})
+
+		for _, c := range []struct {
+			path    string
+			isDir   bool
+			ignored bool
+		}{
+			{".git", true, true},
+			{".git/config", false, true},
+			{"sub/.git", true, true},
+			{"node_modules", true, true},
+			{"node_modules", false, false},
+			{"web/node_modules/lib/index.js", false, true},
+			{"target", true, true},
+			{"target/app.jar", false, true},
+			{"sub/target", true, false},
+			{"app.log", false, true},
+			{"logs/app.log", false, true},
+			{"keep.log", false, false},
+			{"docs/a.png", false, true},
+			{"docs/img/b/a.png", false, true},
+			{"img/a.png", false, false},
+			{"secrets/key.pem", false, true},
+			{"secrets", true, false},
+			{"#hash", false, true},
+			{"main.go", false, false},
+		} {
+			c := c
+			it("matches "+c.path, func() {
+				require.Equal(t, c.ignored, ignorer.Ignored(c.path, c.isDir))
+			})
+		}
+
+		it("does not re-include files inside an ignored directory", func() {
+			ignorer, err := archive.ParseIgnorer(strings.NewReader("build/\n!build/keep.txt\n"))
+			require.NoError(t, err)
+			require.True(t, ignorer.Ignored("build/keep.txt", false))
+		})
+
+		it("ignores nothing when there are no patterns", func() {
+			var ignorer *archive.Ignorer
+			require.False(t, ignorer.Ignored("main.go", false))
+		})
+	})
+
+	when("local source", func() {
+		var dir string
+
+		it.Before(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "ignore-test")
+			require.NoError(t, err)
+
+			for name, contents := range map[string]string{
+				"main.go":                   "package main",
+				"node_modules/lib/index.js": "module.exports = {}",
+				"debug.log":                 "log",
+				".gitignore":                "*.log\n",
+			} {
+				require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+			}
+		})
+
+		it.After(func() {
+			require.NoError(t, os.RemoveAll(dir))
+		})
+
+		it("leaves out the files ignored by .kpignore", func() {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".kpignore"), []byte("node_modules/\n.kpignore\n"), 0644))
+
+			files, err := archive.SourceFiles(dir, true)
+			require.NoError(t, err)
+			require.Equal(t, []string{".gitignore", "debug.log", "main.go"}, files)
+
+			tarFile, err := archive.CreateSourceTar(dir, true)
+			require.NoError(t, err)
+			defer os.Remove(tarFile)
+
+			var names []string
+			require.NoError(t, checkTar(tarFile, func(header *tar.Header) {
+				names = append(names, header.Name)
+			}))
+			require.Equal(t, []string{"/.gitignore", "/debug.log", "/main.go"}, names)
+		})
+
+		it("uses .gitignore only when opted in", func() {
+			files, err := archive.SourceFiles(dir, false)
+			require.NoError(t, err)
+			require.Equal(t, []string{".gitignore", "debug.log", "main.go", "node_modules/lib/index.js"}, files)
+
+			files, err = archive.SourceFiles(dir, true)
+			require.NoError(t, err)
+			require.Equal(t, []string{".gitignore", "main.go", "node_modules/lib/index.js"}, files)
+		})
+	})
+
+	when("zip source", func() {
+		it("leaves out the files ignored by the .kpignore in the zip", func() {
+			file, err := ioutil.TempFile("", "source.zip")
+			require.NoError(t, err)
+			defer os.Remove(file.Name())
+
+			writer := zip.NewWriter(file)
+			for name, contents := range map[string]string{
+				".kpignore":    "target/\n",
+				"src/main.go":  "package main",
+				"target/a.jar": "jar",
+			} {
+				f, err := writer.Create(name)
+				require.NoError(t, err)
+				_, err = f.Write([]byte(contents))
+				require.NoError(t, err)
+			}
+			require.NoError(t, writer.Close())
+
+			files, err := archive.ZipSourceFiles(file.Name(), false)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{".kpignore", "src/main.go"}, files)
+
+			tarFile, err := archive.ZipToTar(file.Name(), false)
+			require.NoError(t, err)
+			defer os.Remove(tarFile)
+
+			var names []string
+			require.NoError(t, checkTar(tarFile, func(header *tar.Header) {
+				names = append(names, header.Name)
+			}))
+			require.ElementsMatch(t, []string{".kpignore", "src/main.go"}, names)
+		})
+	})
+}
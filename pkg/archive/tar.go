@@ -18,6 +18,40 @@ var (
 )
 
 func CreateTar(path string) (string, error) {
+	return createTar(path, nil)
+}
+
+// CreateSourceTar creates a tar of the source code in the directory at path,
+// leaving out the files ignored by its .kpignore, or by its .gitignore when
+// useGitignore is set and there is no .kpignore.
+func CreateSourceTar(path string, useGitignore bool) (string, error) {
+	ignorer, err := LoadIgnorer(path, useGitignore)
+	if err != nil {
+		return "", err
+	}
+
+	return createTar(path, ignorer)
+}
+
+// SourceFiles lists the files CreateSourceTar includes from the directory at
+// path.
+func SourceFiles(path string, useGitignore bool) ([]string, error) {
+	ignorer, err := LoadIgnorer(path, useGitignore)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	err = walkSourceDir(path, ignorer, func(file, relPath string, fi os.FileInfo) error {
+		if !fi.IsDir() {
+			files = append(files, filepath.ToSlash(relPath))
+		}
+		return nil
+	})
+	return files, err
+}
+
+func createTar(path string, ignorer *Ignorer) (string, error) {
 	fh, err := ioutil.TempFile("", "")
 	if err != nil {
 		return "", fmt.Errorf("create file for tar: %s", err)
@@ -27,14 +61,28 @@ func CreateTar(path string) (string, error) {
 	tw := tar.NewWriter(fh)
 	defer tw.Close()
 
-	if err := writeDirToTar(tw, path, "/", 0, 0, -1); err != nil {
+	if err := writeDirToTar(tw, path, "/", 0, 0, -1, ignorer); err != nil {
 		return "", err
 	}
 
 	return fh.Name(), nil
 }
 
+// ReadTar extracts the tar read from reader into dir within the
+// DefaultExtractLimits.
 func ReadTar(reader io.Reader, dir string) error {
+	return ReadTarWithLimits(reader, dir, DefaultExtractLimits)
+}
+
+// ReadTarWithLimits extracts the tar read from reader into dir. Entries that
+// would be written outside of dir, directly or through a link, are rejected.
+// Devices and fifos are skipped.
+func ReadTarWithLimits(reader io.Reader, dir string, limits ExtractLimits) error {
+	e, err := newExtractor(dir, limits)
+	if err != nil {
+		return err
+	}
+
 	tarReader := tar.NewReader(reader)
 	for {
 		header, err := tarReader.Next()
@@ -44,28 +92,32 @@ func ReadTar(reader io.Reader, dir string) error {
 			return err
 		}
 
-		filePath := filepath.Join(dir, header.Name)
+		if err := e.countEntry(); err != nil {
+			return err
+		}
+
+		mode := os.FileMode(header.Mode)
 		switch header.Typeflag {
 		case tar.TypeDir:
-			err := os.MkdirAll(filePath, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			outFile, err := os.Create(filePath)
-			if err != nil {
-				return err
-			}
-			defer outFile.Close()
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				return err
-			}
+			err = e.mkdir(header.Name, mode)
+		case tar.TypeReg, tar.TypeRegA:
+			err = e.writeFile(header.Name, mode, tarReader)
+		case tar.TypeSymlink:
+			err = e.symlink(header.Name, header.Linkname)
+		case tar.TypeLink:
+			err = e.hardlink(header.Name, header.Linkname)
+		}
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func writeDirToTar(tw *tar.Writer, srcDir, basePath string, uid, gid int, mode int64) error {
+// walkSourceDir calls fn, in lexical order, for every file and directory in
+// srcDir that is not ignored, skipping sockets and the contents of ignored
+// directories.
+func walkSourceDir(srcDir string, ignorer *Ignorer, fn func(file, relPath string, fi os.FileInfo) error) error {
 	return filepath.Walk(srcDir, func(file string, fi os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -75,6 +127,26 @@ func writeDirToTar(tw *tar.Writer, srcDir, basePath string, uid, gid int, mode i
 			return nil
 		}
 
+		relPath, err := filepath.Rel(srcDir, file)
+		if err != nil {
+			return err
+		} else if relPath == "." {
+			return nil
+		}
+
+		if ignorer.Ignored(filepath.ToSlash(relPath), fi.IsDir()) {
+			if fi.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(file, relPath, fi)
+	})
+}
+
+func writeDirToTar(tw *tar.Writer, srcDir, basePath string, uid, gid int, mode int64, ignorer *Ignorer) error {
+	return walkSourceDir(srcDir, ignorer, func(file, relPath string, fi os.FileInfo) error {
 		var header *tar.Header
 		if fi.Mode()&os.ModeSymlink != 0 {
 			target, err := os.Readlink(file)
@@ -87,19 +159,13 @@ func writeDirToTar(tw *tar.Writer, srcDir, basePath string, uid, gid int, mode i
 				return err
 			}
 		} else {
+			var err error
 			header, err = tar.FileInfoHeader(fi, fi.Name())
 			if err != nil {
 				return err
 			}
 		}
 
-		relPath, err := filepath.Rel(srcDir, file)
-		if err != nil {
-			return err
-		} else if relPath == "." {
-			return nil
-		}
-
 		header.Name = filepath.ToSlash(filepath.Join(basePath, relPath))
 		finalizeHeader(header, uid, gid, mode)
 
@@ -123,9 +189,14 @@ func writeDirToTar(tw *tar.Writer, srcDir, basePath string, uid, gid int, mode i
 	})
 }
 
+// finalizeHeader normalizes the ownership, mode and times of an entry so that
+// identical trees produce identical tars. A mode of -1 keeps only whether the
+// entry is executable.
 func finalizeHeader(header *tar.Header, uid, gid int, mode int64) {
 	if mode != -1 {
 		header.Mode = mode
+	} else {
+		header.Mode = normalizedMode(header)
 	}
 	header.Uid = uid
 	header.Gid = gid
@@ -133,4 +204,17 @@ func finalizeHeader(header *tar.Header, uid, gid int, mode int64) {
 	header.Gname = ""
 
 	header.ModTime = normalizedTime
+	header.AccessTime = time.Time{}
+	header.ChangeTime = time.Time{}
+}
+
+func normalizedMode(header *tar.Header) int64 {
+	switch {
+	case header.Typeflag == tar.TypeSymlink:
+		return 0777
+	case header.Typeflag == tar.TypeDir, header.Mode&0111 != 0:
+		return 0755
+	default:
+		return 0644
+	}
 }
@@ -0,0 +1,288 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	javaArchiveExtensions = []string{".jar", ".war", ".ear"}
+)
+
+// IsTar reports whether the file at source is a tar, optionally compressed
+// with gzip or zstd.
+func IsTar(source string) bool {
+	r, err := openTar(source)
+	if err != nil {
+		return false
+	}
+	defer r.Close()
+
+	_, err = tar.NewReader(r).Next()
+	return err == nil
+}
+
+// IsJavaArchive reports whether the file at source is a jar, war or ear.
+// Executable jars start with a launch script, so unlike IsZip this checks the
+// extension and reads the zip directory rather than sniffing the first bytes.
+func IsJavaArchive(source string) bool {
+	ext := strings.ToLower(filepath.Ext(source))
+	found := false
+	for _, e := range javaArchiveExtensions {
+		found = found || ext == e
+	}
+	if !found {
+		return false
+	}
+
+	r, err := zip.OpenReader(source)
+	if err != nil {
+		return false
+	}
+	return r.Close() == nil
+}
+
+// NormalizeTar converts a tar of source code, optionally compressed with gzip
+// or zstd, to an uncompressed tar normalized the same way as ZipToTar. Files
+// ignored by the .kpignore at the root of the tar, or by its .gitignore when
+// useGitignore is set and there is no .kpignore, are left out.
+func NormalizeTar(srcTar string, useGitignore bool) (string, error) {
+	spool, err := ioutil.TempFile("", "")
+	if err != nil {
+		return "", fmt.Errorf("create file for tar: %s", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	entries, err := tarSourceEntries(srcTar, useGitignore, spool)
+	if err != nil {
+		return "", err
+	}
+
+	tarFile, err := ioutil.TempFile("", "")
+	if err != nil {
+		return "", fmt.Errorf("create file for tar: %s", err)
+	}
+	defer tarFile.Close()
+
+	tw := tar.NewWriter(tarFile)
+	defer tw.Close()
+
+	for _, entry := range entries {
+		header := entry.header
+		finalizeHeader(header, 0, 0, -1)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return "", err
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			if _, err := io.Copy(tw, io.NewSectionReader(spool, entry.offset, header.Size)); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return tarFile.Name(), nil
+}
+
+// TarSourceFiles lists the files NormalizeTar includes from the tar at srcTar.
+func TarSourceFiles(srcTar string, useGitignore bool) ([]string, error) {
+	entries, err := tarSourceEntries(srcTar, useGitignore, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.header.Typeflag != tar.TypeDir {
+			names = append(names, entry.header.Name)
+		}
+	}
+	return names, nil
+}
+
+type tarEntry struct {
+	header *tar.Header
+	offset int64
+}
+
+// tarSourceEntries reads the entries of the tar at srcTar that are not
+// ignored, sorted by name. The contents of regular files are copied to spool,
+// when it is set, so they can be written out in that order.
+func tarSourceEntries(srcTar string, useGitignore bool, spool io.Writer) ([]tarEntry, error) {
+	r, err := openTar(srcTar)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var (
+		entries      []tarEntry
+		offset       int64
+		ignoreFiles  = map[string][]byte{}
+		regularFiles = map[string]tarEntry{}
+	)
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		name := cleanEntryName(header.Name)
+		if name == "" {
+			continue
+		}
+
+		// only the type, name, size, mode and link target are kept so that
+		// the tar records nothing about the machine that created it
+		entry := tarEntry{
+			header: &tar.Header{Name: name, Mode: header.Mode},
+			offset: offset,
+		}
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			entry.header.Typeflag = tar.TypeReg
+			entry.header.Size = header.Size
+		case tar.TypeDir:
+			entry.header.Typeflag = tar.TypeDir
+		case tar.TypeSymlink:
+			entry.header.Typeflag = tar.TypeSymlink
+			entry.header.Linkname = header.Linkname
+		case tar.TypeLink:
+			// a hardlink becomes a copy of the file it links to, which is
+			// always earlier in the tar
+			target, ok := regularFiles[cleanEntryName(header.Linkname)]
+			if !ok {
+				return nil, fmt.Errorf("%s: hardlink to missing file %s", header.Name, header.Linkname)
+			}
+			entry.header.Typeflag = tar.TypeReg
+			entry.header.Size = target.header.Size
+			entry.offset = target.offset
+			entries = append(entries, entry)
+			continue
+		default:
+			// devices and fifos have no place in source code
+			continue
+		}
+
+		if entry.header.Typeflag == tar.TypeReg {
+			if name == IgnoreFileName || name == GitIgnoreFileName {
+				contents, err := ioutil.ReadAll(tr)
+				if err != nil {
+					return nil, err
+				}
+				ignoreFiles[name] = contents
+
+				if spool != nil {
+					if _, err := spool.Write(contents); err != nil {
+						return nil, err
+					}
+				}
+			} else if spool != nil {
+				if _, err := io.Copy(spool, tr); err != nil {
+					return nil, err
+				}
+			}
+
+			regularFiles[name] = entry
+			offset += header.Size
+		}
+
+		entries = append(entries, entry)
+	}
+
+	ignorer, err := tarIgnorer(ignoreFiles, useGitignore)
+	if err != nil {
+		return nil, err
+	}
+
+	var included []tarEntry
+	for _, entry := range entries {
+		if !ignorer.Ignored(entry.header.Name, entry.header.Typeflag == tar.TypeDir) {
+			included = append(included, entry)
+		}
+	}
+
+	sort.SliceStable(included, func(i, j int) bool {
+		return included[i].header.Name < included[j].header.Name
+	})
+	return included, nil
+}
+
+func tarIgnorer(ignoreFiles map[string][]byte, useGitignore bool) (*Ignorer, error) {
+	for _, name := range ignoreFileNames(useGitignore) {
+		if contents, ok := ignoreFiles[name]; ok {
+			return ParseIgnorer(bytes.NewReader(contents))
+		}
+	}
+	return nil, nil
+}
+
+// openTar opens the tar at path, decompressing it when it starts with the
+// gzip or zstd magic number.
+func openTar(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bufio.NewReader(file)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return readCloser{Reader: gr, closers: []io.Closer{gr, file}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return readCloser{Reader: zr, closers: []io.Closer{zr.IOReadCloser(), file}}, nil
+	default:
+		return readCloser{Reader: br, closers: []io.Closer{file}}, nil
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r readCloser) Close() error {
+	var firstErr error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
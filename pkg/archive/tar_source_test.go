@@ -0,0 +1,208 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package archive_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/archive"
+)
+
+func TestTarSource(t *testing.T) {
+	spec.Run(t, "Test tar sources", testTarSource)
+}
+
+func testTarSource(t *testing.T, when spec.G, it spec.S) {
+	var tarPaths []string
+
+	writeTar := func(compress func(io.Writer) io.WriteCloser) string {
+		file, err := ioutil.TempFile("", "source.tar")
+		require.NoError(t, err)
+		defer file.Close()
+		tarPaths = append(tarPaths, file.Name())
+
+		w := compress(file)
+		defer w.Close()
+
+		tw := tar.NewWriter(w)
+		defer tw.Close()
+
+		for _, entry := range []struct {
+			header   tar.Header
+			contents string
+		}{
+			{tar.Header{Name: "./src/", Typeflag: tar.TypeDir, Mode: 0700, Uid: 1000, Uname: "dev"}, ""},
+			{tar.Header{Name: "./src/main.go", Typeflag: tar.TypeReg, Mode: 0600, Uid: 1000, Uname: "dev"}, "package main"},
+			{tar.Header{Name: "./run.sh", Typeflag: tar.TypeReg, Mode: 0700}, "#!/bin/sh"},
+			{tar.Header{Name: "./.kpignore", Typeflag: tar.TypeReg, Mode: 0644}, "*.log\n"},
+			{tar.Header{Name: "./debug.log", Typeflag: tar.TypeReg, Mode: 0644}, "log"},
+			{tar.Header{Name: "./main.go", Typeflag: tar.TypeSymlink, Linkname: "src/main.go", Mode: 0777}, ""},
+			{tar.Header{Name: "./copy.go", Typeflag: tar.TypeLink, Linkname: "./src/main.go"}, ""},
+			{tar.Header{Name: "./dev", Typeflag: tar.TypeChar}, ""},
+		} {
+			header := entry.header
+			header.Size = int64(len(entry.contents))
+			require.NoError(t, tw.WriteHeader(&header))
+			_, err := tw.Write([]byte(entry.contents))
+			require.NoError(t, err)
+		}
+		return file.Name()
+	}
+
+	uncompressed := func(w io.Writer) io.WriteCloser {
+		return nopWriteCloser{w}
+	}
+
+	gzipped := func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	}
+
+	zstdCompressed := func(w io.Writer) io.WriteCloser {
+		zw, err := zstd.NewWriter(w)
+		require.NoError(t, err)
+		return zw
+	}
+
+	it.After(func() {
+		for _, path := range tarPaths {
+			require.NoError(t, os.Remove(path))
+		}
+		tarPaths = nil
+	})
+
+	when("#IsTar", func() {
+		it("detects uncompressed and compressed tars", func() {
+			require.True(t, archive.IsTar(writeTar(uncompressed)))
+			require.True(t, archive.IsTar(writeTar(gzipped)))
+			require.True(t, archive.IsTar(writeTar(zstdCompressed)))
+		})
+
+		it("does not detect other files", func() {
+			require.False(t, archive.IsTar("testdata/fat-zip-to-tar.zip"))
+			require.False(t, archive.IsTar("does-not-exist"))
+		})
+	})
+
+	when("#NormalizeTar", func() {
+		it("sorts entries and normalizes them like zips", func() {
+			tarFile, err := archive.NormalizeTar(writeTar(gzipped), false)
+			require.NoError(t, err)
+			defer os.Remove(tarFile)
+
+			type entry struct {
+				name     string
+				mode     int64
+				uid      int
+				linkname string
+			}
+			var entries []entry
+			require.NoError(t, checkTar(tarFile, func(header *tar.Header) {
+				require.Equal(t, "", header.Uname)
+				entries = append(entries, entry{header.Name, header.Mode, header.Uid, header.Linkname})
+			}))
+
+			require.Equal(t, []entry{
+				{".kpignore", 0644, 0, ""},
+				{"copy.go", 0644, 0, ""},
+				{"main.go", 0777, 0, "src/main.go"},
+				{"run.sh", 0755, 0, ""},
+				{"src", 0755, 0, ""},
+				{"src/main.go", 0644, 0, ""},
+			}, entries)
+		})
+
+		it("copies the contents of hardlinked files", func() {
+			tarFile, err := archive.NormalizeTar(writeTar(uncompressed), false)
+			require.NoError(t, err)
+			defer os.Remove(tarFile)
+
+			f, err := os.Open(tarFile)
+			require.NoError(t, err)
+			defer f.Close()
+
+			contents := map[string]string{}
+			tr := tar.NewReader(f)
+			for {
+				header, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+
+				buf, err := ioutil.ReadAll(tr)
+				require.NoError(t, err)
+				contents[header.Name] = string(buf)
+			}
+			require.Equal(t, "package main", contents["copy.go"])
+			require.Equal(t, "package main", contents["src/main.go"])
+		})
+
+		it("produces the same tar regardless of compression", func() {
+			var normalized [][]byte
+			for _, compress := range []func(io.Writer) io.WriteCloser{uncompressed, gzipped, zstdCompressed} {
+				tarFile, err := archive.NormalizeTar(writeTar(compress), false)
+				require.NoError(t, err)
+				defer os.Remove(tarFile)
+
+				buf, err := ioutil.ReadFile(tarFile)
+				require.NoError(t, err)
+				normalized = append(normalized, buf)
+			}
+
+			require.True(t, bytes.Equal(normalized[0], normalized[1]))
+			require.True(t, bytes.Equal(normalized[0], normalized[2]))
+		})
+	})
+
+	when("#TarSourceFiles", func() {
+		it("lists the files that are not ignored", func() {
+			files, err := archive.TarSourceFiles(writeTar(zstdCompressed), false)
+			require.NoError(t, err)
+			require.Equal(t, []string{".kpignore", "copy.go", "main.go", "run.sh", "src/main.go"}, files)
+		})
+	})
+
+	when("#IsJavaArchive", func() {
+		it("detects executable jars that start with a launch script", func() {
+			file, err := ioutil.TempFile("", "app-*.jar")
+			require.NoError(t, err)
+			defer os.Remove(file.Name())
+
+			_, err = file.Write([]byte("#!/bin/bash\nexec java -jar \"$0\" \"$@\"\n"))
+			require.NoError(t, err)
+
+			writer := zip.NewWriter(file)
+			_, err = writer.Create("META-INF/MANIFEST.MF")
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+			require.NoError(t, file.Close())
+
+			require.False(t, archive.IsZip(file.Name()))
+			require.True(t, archive.IsJavaArchive(file.Name()))
+		})
+
+		it("does not detect zips without a java extension", func() {
+			require.False(t, archive.IsJavaArchive("testdata/fat-zip-to-tar.zip"))
+		})
+	})
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
@@ -12,7 +12,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 )
 
 func IsZip(source string) bool {
@@ -36,53 +36,63 @@ func IsZip(source string) bool {
 	return filetype == "application/zip"
 }
 
+// ExtractZip extracts the zip at src into dest within the
+// DefaultExtractLimits.
 func ExtractZip(src string, dest string) error {
+	return ExtractZipWithLimits(src, dest, DefaultExtractLimits)
+}
+
+// ExtractZipWithLimits extracts the zip at src into dest. Entries that would be
+// written outside of dest, directly or through a symlink, are rejected.
+func ExtractZipWithLimits(src string, dest string, limits ExtractLimits) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
 		return err
 	}
 	defer r.Close()
 
-	for _, f := range r.File {
-
-		fpath := filepath.Join(dest, f.Name)
-
-		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("%s: illegal file path", fpath)
-		}
+	e, err := newExtractor(dest, limits)
+	if err != nil {
+		return err
+	}
 
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, os.ModePerm)
-			continue
+	for _, f := range r.File {
+		if err := e.countEntry(); err != nil {
+			return err
 		}
 
-		if err = os.MkdirAll(filepath.Dir(fpath), os.ModePerm); err != nil {
+		if err := extractZipEntry(e, f); err != nil {
 			return err
 		}
+	}
+	return nil
+}
 
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
+func extractZipEntry(e *extractor, f *zip.File) error {
+	switch {
+	case f.FileInfo().IsDir():
+		return e.mkdir(f.Name, f.Mode())
+	case f.Mode()&os.ModeSymlink != 0:
+		target, err := getSymlinkTarget(f)
 		if err != nil {
 			return err
 		}
-
+		return e.symlink(f.Name, target)
+	default:
 		rc, err := f.Open()
 		if err != nil {
 			return err
 		}
+		defer rc.Close()
 
-		_, err = io.Copy(outFile, rc)
-
-		outFile.Close()
-		rc.Close()
-
-		if err != nil {
-			return err
-		}
+		return e.writeFile(f.Name, f.Mode(), rc)
 	}
-	return nil
 }
 
-func ZipToTar(srcZip string) (string, error) {
+// ZipToTar converts a zip of source code to a tar, leaving out the files
+// ignored by the .kpignore at the root of the zip, or by its .gitignore when
+// useGitignore is set and there is no .kpignore.
+func ZipToTar(srcZip string, useGitignore bool) (string, error) {
 	tarFile, err := ioutil.TempFile("", "")
 	if err != nil {
 		return "", fmt.Errorf("create file for tar: %s", err)
@@ -98,8 +108,13 @@ func ZipToTar(srcZip string) (string, error) {
 	}
 	defer zipReader.Close()
 
+	files, err := zipSourceFiles(zipReader, useGitignore)
+	if err != nil {
+		return "", err
+	}
+
 	var fileMode int64
-	for _, f := range zipReader.File {
+	for _, f := range files {
 		fileMode = -1
 		if isFatFile(f.FileHeader) {
 			fileMode = 0777
@@ -140,17 +155,80 @@ func ZipToTar(srcZip string) (string, error) {
 	return tarFile.Name(), nil
 }
 
+// ZipSourceFiles lists the files ZipToTar includes from the zip at srcZip.
+func ZipSourceFiles(srcZip string, useGitignore bool) ([]string, error) {
+	zipReader, err := zip.OpenReader(srcZip)
+	if err != nil {
+		return nil, err
+	}
+	defer zipReader.Close()
+
+	files, err := zipSourceFiles(zipReader, useGitignore)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, f := range files {
+		if !f.FileInfo().IsDir() {
+			names = append(names, cleanEntryName(f.Name))
+		}
+	}
+	return names, nil
+}
+
+func zipSourceFiles(zipReader *zip.ReadCloser, useGitignore bool) ([]*zip.File, error) {
+	ignorer, err := zipIgnorer(zipReader, useGitignore)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*zip.File
+	for _, f := range zipReader.File {
+		if !ignorer.Ignored(cleanEntryName(f.Name), f.FileInfo().IsDir()) {
+			files = append(files, f)
+		}
+	}
+
+	// zips built by different tools order their entries differently
+	sort.SliceStable(files, func(i, j int) bool {
+		return cleanEntryName(files[i].Name) < cleanEntryName(files[j].Name)
+	})
+	return files, nil
+}
+
+func zipIgnorer(zipReader *zip.ReadCloser, useGitignore bool) (*Ignorer, error) {
+	for _, name := range ignoreFileNames(useGitignore) {
+		for _, f := range zipReader.File {
+			if cleanEntryName(f.Name) != name {
+				continue
+			}
+
+			r, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+
+			return ParseIgnorer(r)
+		}
+	}
+	return nil, nil
+}
+
 func getSymlinkTarget(f *zip.File) (string, error) {
 	r, err := f.Open()
 	if err != nil {
-		return "", nil
+		return "", err
 	}
 	defer r.Close()
 
 	// contents is the target of the symlink
-	target, err := ioutil.ReadAll(r)
+	target, err := ioutil.ReadAll(io.LimitReader(r, maxSymlinkTargetLength+1))
 	if err != nil {
 		return "", err
+	} else if len(target) > maxSymlinkTargetLength {
+		return "", fmt.Errorf("%s: symlink target is too long", f.Name)
 	}
 
 	return string(target), nil
@@ -92,7 +92,7 @@ func testZip(t *testing.T, when spec.G, it spec.S) {
 
 			createZip(file, t, err)
 
-			tarFile, err := archive.ZipToTar(file.Name())
+			tarFile, err := archive.ZipToTar(file.Name(), false)
 			require.NoError(t, err)
 			defer os.RemoveAll(tarFile)
 
@@ -109,7 +109,7 @@ func testZip(t *testing.T, when spec.G, it spec.S) {
 		it("writes the tar to the dest dir with 0777 when files are compressed in fat (MSDOS) format", func() {
 			zipFile := filepath.Join("testdata", "fat-zip-to-tar.zip")
 
-			tarFile, err := archive.ZipToTar(zipFile)
+			tarFile, err := archive.ZipToTar(zipFile, false)
 			require.NoError(t, err)
 			defer os.RemoveAll(tarFile)
 
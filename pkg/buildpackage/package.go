@@ -0,0 +1,477 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package buildpackage
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	"github.com/pivotal/kpack/pkg/registry/imagehelpers"
+	"github.com/pkg/errors"
+)
+
+const (
+	buildpackLayersLabel = "io.buildpacks.buildpack.layers"
+
+	buildpackDescriptorFile = "buildpack.toml"
+	packageConfigFile       = "package.toml"
+
+	buildpacksDir = "/cnb/buildpacks"
+)
+
+var normalizedTime = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+
+type buildpackDescriptor struct {
+	API       string `toml:"api"`
+	Buildpack struct {
+		ID       string `toml:"id"`
+		Version  string `toml:"version"`
+		Homepage string `toml:"homepage"`
+	} `toml:"buildpack"`
+	Stacks []struct {
+		ID     string   `toml:"id"`
+		Mixins []string `toml:"mixins"`
+	} `toml:"stacks"`
+	Order []struct {
+		Group []struct {
+			ID       string `toml:"id"`
+			Version  string `toml:"version"`
+			Optional bool   `toml:"optional"`
+		} `toml:"group"`
+	} `toml:"order"`
+}
+
+type packageConfig struct {
+	Buildpack struct {
+		URI string `toml:"uri"`
+	} `toml:"buildpack"`
+	Dependencies []struct {
+		URI   string `toml:"uri"`
+		Image string `toml:"image"`
+	} `toml:"dependencies"`
+}
+
+type buildpackageMetadata struct {
+	ID       string                        `json:"id"`
+	Version  string                        `json:"version"`
+	Homepage string                        `json:"homepage,omitempty"`
+	Stacks   []corev1alpha1.BuildpackStack `json:"stacks,omitempty"`
+}
+
+type buildpackLayerInfo struct {
+	API         string                        `json:"api"`
+	Stacks      []corev1alpha1.BuildpackStack `json:"stacks,omitempty"`
+	Order       corev1alpha1.Order            `json:"order,omitempty"`
+	LayerDiffID string                        `json:"layerDiffID"`
+	Homepage    string                        `json:"homepage,omitempty"`
+}
+
+// buildpackLayers is the io.buildpacks.buildpack.layers label, the layer of
+// every buildpack in a buildpackage by id and version.
+type buildpackLayers map[string]map[string]buildpackLayerInfo
+
+func isBuildpackDir(buildPackage string) bool {
+	fi, err := os.Stat(filepath.Join(buildPackage, buildpackDescriptorFile))
+	return err == nil && !fi.IsDir()
+}
+
+// packageBuildpack assembles a buildpackage from the buildpack source in dir,
+// as pack buildpack package would. The buildpackage includes the dependencies
+// listed in the package.toml next to the buildpack.toml, which can be images,
+// .cnb files or other buildpack directories. Local .cnb dependencies are read
+// into tempDir.
+func (u *Uploader) packageBuildpack(keychain authn.Keychain, dir, tempDir string) (v1.Image, error) {
+	var config packageConfig
+	if _, err := toml.DecodeFile(filepath.Join(dir, packageConfigFile), &config); err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrapf(err, "reading %s", packageConfigFile)
+	}
+
+	buildpackDir, err := localURI(dir, config.Buildpack.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	var descriptor buildpackDescriptor
+	if _, err := toml.DecodeFile(filepath.Join(buildpackDir, buildpackDescriptorFile), &descriptor); err != nil {
+		return nil, errors.Wrapf(err, "reading %s", buildpackDescriptorFile)
+	}
+	if descriptor.Buildpack.ID == "" || descriptor.Buildpack.Version == "" {
+		return nil, errors.Errorf("%s must set the buildpack id and version", buildpackDescriptorFile)
+	}
+
+	image := empty.Image
+	layers := buildpackLayers{}
+	for _, dependency := range config.Dependencies {
+		depImage, err := u.readDependency(keychain, dir, tempDir, dependency.URI, dependency.Image)
+		if err != nil {
+			return nil, err
+		}
+
+		image, err = addBuildpackLayers(image, layers, depImage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := layers[descriptor.Buildpack.ID][descriptor.Buildpack.Version]; ok {
+		return nil, errors.Errorf("buildpack %s@%s is also a dependency", descriptor.Buildpack.ID, descriptor.Buildpack.Version)
+	}
+
+	layer, err := buildpackLayer(buildpackDir, descriptor)
+	if err != nil {
+		return nil, err
+	}
+
+	diffID, err := layer.DiffID()
+	if err != nil {
+		return nil, err
+	}
+
+	image, err = mutate.AppendLayers(image, layer)
+	if err != nil {
+		return nil, err
+	}
+
+	info := buildpackLayerInfo{
+		API:         descriptor.API,
+		Order:       descriptor.order(),
+		LayerDiffID: diffID.String(),
+		Homepage:    descriptor.Buildpack.Homepage,
+	}
+	if len(info.Order) == 0 {
+		info.Stacks = descriptor.stacks()
+	} else if err := layers.validateOrder(descriptor.Buildpack.ID, info.Order); err != nil {
+		return nil, err
+	}
+	layers.add(descriptor.Buildpack.ID, descriptor.Buildpack.Version, info)
+
+	return imagehelpers.SetLabels(image, map[string]interface{}{
+		buildpackageMetadataLabel: buildpackageMetadata{
+			ID:       descriptor.Buildpack.ID,
+			Version:  descriptor.Buildpack.Version,
+			Homepage: descriptor.Buildpack.Homepage,
+			Stacks:   layers.stacks(descriptor.Buildpack.ID, descriptor.Buildpack.Version),
+		},
+		buildpackLayersLabel: layers,
+	})
+}
+
+func (u *Uploader) readDependency(keychain authn.Keychain, dir, tempDir, uri, image string) (v1.Image, error) {
+	if image != "" {
+		return u.Fetcher.Fetch(keychain, image)
+	}
+
+	if strings.HasPrefix(uri, "docker://") {
+		return u.Fetcher.Fetch(keychain, strings.TrimPrefix(uri, "docker://"))
+	}
+
+	if uri == "" {
+		return nil, errors.Errorf("%s dependencies must set a uri or image", packageConfigFile)
+	}
+
+	depPath, err := localURI(dir, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	depDir, err := ioutil.TempDir(tempDir, "dependency")
+	if err != nil {
+		return nil, err
+	}
+
+	var depImage v1.Image
+	if isBuildpackDir(depPath) {
+		depImage, err = u.packageBuildpack(keychain, depPath, depDir)
+	} else {
+		depImage, err = readCNB(depPath, depDir)
+	}
+	return depImage, errors.Wrapf(err, "invalid dependency %s", uri)
+}
+
+// localURI resolves a uri in package.toml, which must refer to the local
+// filesystem, relative to dir.
+func localURI(dir, uri string) (string, error) {
+	switch {
+	case uri == "":
+		return dir, nil
+	case strings.HasPrefix(uri, "file://"):
+		uri = strings.TrimPrefix(uri, "file://")
+	case strings.Contains(uri, "://"):
+		return "", errors.Errorf("unsupported uri %s, only local paths and images are supported", uri)
+	}
+
+	if filepath.IsAbs(uri) {
+		return uri, nil
+	}
+	return filepath.Join(dir, filepath.FromSlash(uri)), nil
+}
+
+// addBuildpackLayers appends the layers of the buildpacks in the buildpackage
+// that are not in the image yet.
+func addBuildpackLayers(image v1.Image, layers buildpackLayers, buildpackage v1.Image) (v1.Image, error) {
+	var depLayers buildpackLayers
+	if err := imagehelpers.GetLabel(buildpackage, buildpackLayersLabel, &depLayers); err != nil {
+		return nil, err
+	}
+
+	for _, id := range depLayers.ids() {
+		for _, version := range depLayers.versions(corev1alpha1.BuildpackInfo{Id: id}) {
+			if _, ok := layers[id][version]; ok {
+				continue
+			}
+
+			info := depLayers[id][version]
+			diffID, err := v1.NewHash(info.LayerDiffID)
+			if err != nil {
+				return nil, err
+			}
+
+			layer, err := buildpackage.LayerByDiffID(diffID)
+			if err != nil {
+				return nil, err
+			}
+
+			image, err = mutate.AppendLayers(image, layer)
+			if err != nil {
+				return nil, err
+			}
+			layers.add(id, version, info)
+		}
+	}
+	return image, nil
+}
+
+func (l buildpackLayers) add(id, version string, info buildpackLayerInfo) {
+	if _, ok := l[id]; !ok {
+		l[id] = map[string]buildpackLayerInfo{}
+	}
+	l[id][version] = info
+}
+
+// validateOrder checks that every buildpack in the order is in the
+// buildpackage. Buildpacks without a version match any version.
+func (l buildpackLayers) validateOrder(id string, order corev1alpha1.Order) error {
+	for _, entry := range order {
+		for _, ref := range entry.Group {
+			if len(l.versions(ref.BuildpackInfo)) == 0 {
+				name := ref.Id
+				if ref.Version != "" {
+					name = ref.BuildpackInfo.String()
+				}
+				return errors.Errorf("buildpack %s in the order of %s is not a dependency", name, id)
+			}
+		}
+	}
+	return nil
+}
+
+func (l buildpackLayers) ids() []string {
+	var ids []string
+	for id := range l {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// versions returns the versions of the buildpack in the buildpackage, or all
+// of them when bp has no version.
+func (l buildpackLayers) versions(bp corev1alpha1.BuildpackInfo) []string {
+	var versions []string
+	for version := range l[bp.Id] {
+		if bp.Version == "" || bp.Version == version {
+			versions = append(versions, version)
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// stacks returns the stacks of the buildpack. The stacks of a composite
+// buildpack are the ones all of the buildpacks in its order support, with the
+// mixins any of them require.
+func (l buildpackLayers) stacks(id, version string) []corev1alpha1.BuildpackStack {
+	return l.stacksVisiting(id, version, map[string]bool{})
+}
+
+func (l buildpackLayers) stacksVisiting(id, version string, visiting map[string]bool) []corev1alpha1.BuildpackStack {
+	info := l[id][version]
+	if len(info.Order) == 0 {
+		return info.Stacks
+	}
+
+	key := id + "@" + version
+	if visiting[key] {
+		return nil
+	}
+	visiting[key] = true
+	defer delete(visiting, key)
+
+	var (
+		stacks []corev1alpha1.BuildpackStack
+		first  = true
+	)
+	for _, entry := range info.Order {
+		for _, ref := range entry.Group {
+			for _, depVersion := range l.versions(ref.BuildpackInfo) {
+				depStacks := l.stacksVisiting(ref.Id, depVersion, visiting)
+				if first {
+					stacks, first = depStacks, false
+				} else {
+					stacks = intersectStacks(stacks, depStacks)
+				}
+			}
+		}
+	}
+	return stacks
+}
+
+func intersectStacks(a, b []corev1alpha1.BuildpackStack) []corev1alpha1.BuildpackStack {
+	var stacks []corev1alpha1.BuildpackStack
+	for _, stackA := range a {
+		for _, stackB := range b {
+			if stackA.ID != stackB.ID {
+				continue
+			}
+
+			mixins := append(append([]string{}, stackA.Mixins...), stackB.Mixins...)
+			stacks = append(stacks, corev1alpha1.BuildpackStack{ID: stackA.ID, Mixins: uniqueSorted(mixins)})
+		}
+	}
+	return stacks
+}
+
+func (d buildpackDescriptor) stacks() []corev1alpha1.BuildpackStack {
+	var stacks []corev1alpha1.BuildpackStack
+	for _, stack := range d.Stacks {
+		stacks = append(stacks, corev1alpha1.BuildpackStack{ID: stack.ID, Mixins: stack.Mixins})
+	}
+	return stacks
+}
+
+func (d buildpackDescriptor) order() corev1alpha1.Order {
+	var order corev1alpha1.Order
+	for _, entry := range d.Order {
+		var group []corev1alpha1.BuildpackRef
+		for _, ref := range entry.Group {
+			group = append(group, corev1alpha1.BuildpackRef{
+				BuildpackInfo: corev1alpha1.BuildpackInfo{Id: ref.ID, Version: ref.Version},
+				Optional:      ref.Optional,
+			})
+		}
+		order = append(order, corev1alpha1.OrderEntry{Group: group})
+	}
+	return order
+}
+
+// buildpackLayer creates the layer of the buildpack in dir, placing it in
+// /cnb/buildpacks/<id>/<version> with normalized ownership, modes and times.
+// The files in bin are made executable.
+func buildpackLayer(dir string, descriptor buildpackDescriptor) (v1.Layer, error) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	idDir := path.Join(buildpacksDir, strings.ReplaceAll(descriptor.Buildpack.ID, "/", "_"))
+	baseDir := path.Join(idDir, descriptor.Buildpack.Version)
+	for _, d := range []string{idDir, baseDir} {
+		if err := tw.WriteHeader(&tar.Header{Name: d, Typeflag: tar.TypeDir, Mode: 0755, ModTime: normalizedTime}); err != nil {
+			return nil, err
+		}
+	}
+
+	err := filepath.Walk(dir, func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		} else if relPath == "." {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		header := &tar.Header{Name: path.Join(baseDir, relPath), ModTime: normalizedTime}
+		switch {
+		case fi.IsDir():
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0755
+		case fi.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = target
+			header.Mode = 0777
+		case fi.Mode().IsRegular():
+			header.Typeflag = tar.TypeReg
+			header.Size = fi.Size()
+			header.Mode = 0644
+			if strings.HasPrefix(relPath, "bin/") || fi.Mode()&0111 != 0 {
+				header.Mode = 0755
+			}
+		default:
+			return nil
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			if _, err := io.Copy(tw, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	contents := buf.Bytes()
+	return tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(contents)), nil
+	})
+}
+
+func uniqueSorted(values []string) []string {
+	seen := map[string]bool{}
+	var unique []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
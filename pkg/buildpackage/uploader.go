@@ -10,11 +10,16 @@ import (
 	"github.com/google/go-containerregistry/pkg/authn"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/pivotal/kpack/pkg/registry/imagehelpers"
 	"github.com/pkg/errors"
 
 	"github.com/vmware-tanzu/kpack-cli/pkg/archive"
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 )
 
+const buildpackageMetadataLabel = "io.buildpacks.buildpackage.metadata"
+
 type Relocator interface {
 	Relocate(keychain authn.Keychain, image v1.Image, dest string) (string, error)
 }
@@ -28,7 +33,9 @@ type Uploader struct {
 	Fetcher   Fetcher
 }
 
-func (u *Uploader) UploadBuildpackage(keychain authn.Keychain, buildPackage, repository string) (string, error) {
+// UploadBuildpackage relocates the buildpackage to the repository kpConfig
+// assigns to its buildpack id.
+func (u *Uploader) UploadBuildpackage(keychain authn.Keychain, buildPackage string, kpConfig config.KpConfig) (string, error) {
 	tempDir, err := ioutil.TempDir("", "cnb-upload")
 	if err != nil {
 		return "", err
@@ -40,10 +47,66 @@ func (u *Uploader) UploadBuildpackage(keychain authn.Keychain, buildPackage, rep
 		return "", err
 	}
 
+	repository, err := Repository(image, kpConfig)
+	if err != nil {
+		return "", err
+	}
+
 	return u.Relocator.Relocate(keychain, image, repository)
 }
 
+// Prefetch starts fetching and relocating the remote buildpackages ahead of
+// UploadBuildpackage when the relocator supports it. The Fetcher should cache
+// images, as UploadBuildpackage fetches them again.
+func (u *Uploader) Prefetch(keychain authn.Keychain, buildPackages []string, kpConfig config.KpConfig) error {
+	prefetcher, ok := u.Relocator.(registry.Prefetcher)
+	if !ok {
+		return nil
+	}
+
+	var jobs []registry.RelocationJob
+	for _, bp := range buildPackages {
+		if isLocalCnb(bp) {
+			continue
+		}
+
+		bp := bp
+		jobs = append(jobs, registry.RelocationJob{Fetch: func() (v1.Image, string, error) {
+			image, err := u.Fetcher.Fetch(keychain, bp)
+			if err != nil {
+				return nil, "", err
+			}
+
+			repository, err := Repository(image, kpConfig)
+			return image, repository, err
+		}})
+	}
+
+	return prefetcher.Prefetch(keychain, jobs)
+}
+
+// Repository returns the repository kpConfig assigns to the buildpackage image.
+func Repository(image v1.Image, kpConfig config.KpConfig) (string, error) {
+	return kpConfig.ImageRepository(config.BuildpackageImage, config.RepositoryValues{BuildpackID: buildpackID(image)})
+}
+
+// buildpackID reads the id from the buildpackage metadata label, returning an
+// empty id for images without one.
+func buildpackID(image v1.Image) string {
+	var metadata struct {
+		ID string `json:"id"`
+	}
+	if err := imagehelpers.GetLabel(image, buildpackageMetadataLabel, &metadata); err != nil {
+		return ""
+	}
+	return metadata.ID
+}
+
 func (u *Uploader) read(keychain authn.Keychain, buildPackage, tempDir string) (v1.Image, error) {
+	if isBuildpackDir(buildPackage) {
+		image, err := u.packageBuildpack(keychain, buildPackage, tempDir)
+		return image, errors.Wrapf(err, "invalid local buildpack %s", buildPackage)
+	}
 	if isLocalCnb(buildPackage) {
 		cnb, err := readCNB(buildPackage, tempDir)
 		return cnb, errors.Wrapf(err, "invalid local buildpackage %s", buildPackage)
@@ -77,10 +140,18 @@ func readCNB(buildPackage, tempDir string) (v1.Image, error) {
 		return nil, err
 	}
 
-	image, err := index.Image(manifest.Manifests[0].Digest)
-	if err != nil {
-		return nil, err
+	if len(manifest.Manifests) == 0 {
+		return nil, errors.New("no buildpackage found in archive")
+	}
+
+	desc := manifest.Manifests[0]
+	if desc.MediaType.IsIndex() {
+		child, err := index.ImageIndex(desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		return registry.NewIndexImage(child)
 	}
 
-	return image, nil
+	return index.Image(desc.Digest)
 }
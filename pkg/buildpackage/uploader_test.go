@@ -5,14 +5,23 @@ package buildpackage
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/random"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 	"github.com/pivotal/kpack/pkg/registry/imagehelpers"
 	"github.com/pivotal/kpack/pkg/registry/registryfakes"
 	"github.com/sclevine/spec"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 	"github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
 )
 
@@ -28,11 +37,12 @@ func testBuildpackageUploader(t *testing.T, when spec.G, it spec.S) {
 		Relocator: relocator,
 	}
 	fakeKeychain := &registryfakes.FakeKeychain{}
+	kpConfig := config.NewKpConfig("kpackcr.org/somepath", corev1.ObjectReference{})
 
 	when("UploadBuildpackage", func() {
 		when("cnb file is provided", func() {
 			it("it uploads to registry", func() {
-				image, err := uploader.UploadBuildpackage(fakeKeychain, "testdata/sample-bp.cnb", "kpackcr.org/somepath")
+				image, err := uploader.UploadBuildpackage(fakeKeychain, "testdata/sample-bp.cnb", kpConfig)
 				require.NoError(t, err)
 
 				const expectedFixture = "kpackcr.org/somepath@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"
@@ -41,6 +51,94 @@ func testBuildpackageUploader(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("buildpack directory is provided", func() {
+			it("packages it like pack and uploads it to registry", func() {
+				_, err := uploader.UploadBuildpackage(fakeKeychain, "testdata/sample-bp", kpConfig)
+				require.NoError(t, err)
+				require.Equal(t, 1, relocator.CallCount())
+
+				tempDir, err := ioutil.TempDir("", "cnb")
+				require.NoError(t, err)
+				defer os.RemoveAll(tempDir)
+
+				expected, err := readCNB("testdata/sample-bp.cnb", tempDir)
+				require.NoError(t, err)
+
+				_, packaged, _ := relocator.RelocateCall(0)
+				require.Equal(t, labels(t, expected), labels(t, packaged))
+				require.Equal(t, diffIDs(t, expected), diffIDs(t, packaged))
+			})
+
+			it("includes the dependencies in package.toml", func() {
+				dir, err := ioutil.TempDir("", "meta-bp")
+				require.NoError(t, err)
+				defer os.RemoveAll(dir)
+
+				cnb, err := filepath.Abs("testdata/sample-bp.cnb")
+				require.NoError(t, err)
+
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "buildpack.toml"), []byte(`api = "0.2"
+[buildpack]
+id = "sample/meta"
+version = "1.0.0"
+
+[[order]]
+[[order.group]]
+id = "sample/buildpackage"
+`), 0644))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "package.toml"), []byte(fmt.Sprintf(`[buildpack]
+uri = "."
+
+[[dependencies]]
+uri = %q
+`, cnb)), 0644))
+
+				_, err = uploader.UploadBuildpackage(fakeKeychain, dir, kpConfig)
+				require.NoError(t, err)
+
+				_, packaged, _ := relocator.RelocateCall(0)
+				require.Len(t, diffIDs(t, packaged), 2)
+
+				var metadata buildpackageMetadata
+				require.NoError(t, imagehelpers.GetLabel(packaged, buildpackageMetadataLabel, &metadata))
+				require.Equal(t, buildpackageMetadata{
+					ID:      "sample/meta",
+					Version: "1.0.0",
+					Stacks: []corev1alpha1.BuildpackStack{
+						{ID: "io.buildpacks.stacks.bionic"},
+						{ID: "org.cloudfoundry.stacks.tiny"},
+					},
+				}, metadata)
+
+				var layers buildpackLayers
+				require.NoError(t, imagehelpers.GetLabel(packaged, buildpackLayersLabel, &layers))
+				require.Contains(t, layers, "sample/buildpackage")
+				require.Equal(t, corev1alpha1.Order{{
+					Group: []corev1alpha1.BuildpackRef{{BuildpackInfo: corev1alpha1.BuildpackInfo{Id: "sample/buildpackage"}}},
+				}}, layers["sample/meta"]["1.0.0"].Order)
+			})
+
+			it("fails when a buildpack in the order is not a dependency", func() {
+				dir, err := ioutil.TempDir("", "meta-bp")
+				require.NoError(t, err)
+				defer os.RemoveAll(dir)
+
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "buildpack.toml"), []byte(`api = "0.2"
+[buildpack]
+id = "sample/meta"
+version = "1.0.0"
+
+[[order]]
+[[order.group]]
+id = "sample/missing"
+`), 0644))
+
+				_, err = uploader.UploadBuildpackage(fakeKeychain, dir, kpConfig)
+				require.EqualError(t, err, fmt.Sprintf("invalid local buildpack %s: buildpack sample/missing in the order of sample/meta is not a dependency", dir))
+				require.Equal(t, 0, relocator.CallCount())
+			})
+		})
+
 		when("remote location", func() {
 			it("it uploads to registry", func() {
 				testImage, err := random.Image(10, 10)
@@ -51,7 +149,7 @@ func testBuildpackageUploader(t *testing.T, when spec.G, it spec.S) {
 
 				fetcher.AddImage("some/remote-bp", testImage)
 
-				image, err := uploader.UploadBuildpackage(fakeKeychain, "some/remote-bp", "kpackcr.org/somepath")
+				image, err := uploader.UploadBuildpackage(fakeKeychain, "some/remote-bp", kpConfig)
 				require.NoError(t, err)
 
 				digest, err := testImage.Digest()
@@ -62,5 +160,77 @@ func testBuildpackageUploader(t *testing.T, when spec.G, it spec.S) {
 				require.Equal(t, 1, relocator.CallCount())
 			})
 		})
+
+		when("a buildpackage repository template is configured", func() {
+			it("it uploads to the repository for the buildpack id", func() {
+				testImage, err := random.Image(10, 10)
+				require.NoError(t, err)
+
+				testImage, err = imagehelpers.SetStringLabel(testImage, "io.buildpacks.buildpackage.metadata", `{"id": "sample-buildpack/name"}`)
+				require.NoError(t, err)
+
+				fetcher.AddImage("some/remote-bp", testImage)
+
+				templated := kpConfig.WithRepositoryTemplate(config.BuildpackageImage, "{{.Repo}}/buildpacks/{{.BuildpackID}}")
+				image, err := uploader.UploadBuildpackage(fakeKeychain, "some/remote-bp", templated)
+				require.NoError(t, err)
+
+				digest, err := testImage.Digest()
+				require.NoError(t, err)
+
+				expectedImage := fmt.Sprintf("kpackcr.org/somepath/buildpacks/sample-buildpack/name@%s", digest)
+				require.Equal(t, expectedImage, image)
+			})
+		})
+	})
+
+	when("Prefetch", func() {
+		it("fetches each remote buildpackage once", func() {
+			testImage, err := random.Image(10, 10)
+			require.NoError(t, err)
+			fetcher.AddImage("some/remote-bp", testImage)
+
+			cachingFetcher := registry.NewCachingFetcher(fetcher)
+			prefetching := &Uploader{
+				Fetcher: cachingFetcher,
+				Relocator: registry.NewParallelRelocator(ioutil.Discard, 1, func(io.Writer) registry.Relocator {
+					return relocator
+				}),
+			}
+
+			require.NoError(t, prefetching.Prefetch(fakeKeychain, []string{"some/remote-bp"}, kpConfig))
+			require.Equal(t, 1, fetcher.CallCount())
+			require.Equal(t, 1, relocator.CallCount())
+
+			_, err = prefetching.UploadBuildpackage(fakeKeychain, "some/remote-bp", kpConfig)
+			require.NoError(t, err)
+			require.Equal(t, 1, fetcher.CallCount())
+			require.Equal(t, 1, relocator.CallCount())
+		})
+
+		it("returns the error fetching a buildpackage", func() {
+			prefetching := &Uploader{
+				Fetcher: fetcher,
+				Relocator: registry.NewParallelRelocator(ioutil.Discard, 1, func(io.Writer) registry.Relocator {
+					return relocator
+				}),
+			}
+
+			err := prefetching.Prefetch(fakeKeychain, []string{"some/missing-bp"}, kpConfig)
+			require.EqualError(t, err, `image not found: "some/missing-bp"`)
+			require.Equal(t, 0, relocator.CallCount())
+		})
 	})
 }
+
+func labels(t *testing.T, image v1.Image) map[string]string {
+	config, err := image.ConfigFile()
+	require.NoError(t, err)
+	return config.Config.Labels
+}
+
+func diffIDs(t *testing.T, image v1.Image) []v1.Hash {
+	config, err := image.ConfigFile()
+	require.NoError(t, err)
+	return config.RootFS.DiffIDs
+}
@@ -4,17 +4,20 @@
 package clusterstack
 
 import (
+	"strings"
+
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
 	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 	"github.com/vmware-tanzu/kpack-cli/pkg/stackimage"
 )
 
 type Uploader interface {
-	UploadStackImages(keychain authn.Keychain, buildImageTag, runImageTag, dest string) (string, string, error)
+	UploadStackImages(keychain authn.Keychain, buildImageTag, runImageTag, buildDest, runDest string) (string, string, error)
 	ValidateStackIDs(keychain authn.Keychain, buildImageTag, runImageTag string) (string, error)
 }
 
@@ -25,6 +28,7 @@ type Printer interface {
 
 type Factory struct {
 	Uploader Uploader
+	Fetcher  registry.Fetcher
 	Printer  Printer
 }
 
@@ -34,6 +38,7 @@ func NewFactory(printer Printer, relocator registry.Relocator, fetcher registry.
 			Fetcher:   fetcher,
 			Relocator: relocator,
 		},
+		Fetcher: fetcher,
 		Printer: printer,
 	}
 }
@@ -44,23 +49,14 @@ func (f *Factory) MakeStack(keychain authn.Keychain, name, buildImageTag, runIma
 		return nil, err
 	}
 
-	defaultRepo, err := kpConfig.DefaultRepository()
-	if err != nil {
-		return nil, err
-	}
-
-	if err := f.Printer.PrintStatus("Uploading to '%s'...", defaultRepo); err != nil {
-		return nil, err
-	}
-
-	relocatedBuildImageRef, relocatedRunImageRef, err := f.Uploader.UploadStackImages(keychain, buildImageTag, runImageTag, defaultRepo)
+	relocatedBuildImageRef, relocatedRunImageRef, err := f.uploadStackImages(keychain, name, buildImageTag, runImageTag, kpConfig)
 	if err != nil {
 		return nil, err
 	}
 
 	sa := kpConfig.ServiceAccount()
 
-	return &v1alpha2.ClusterStack{
+	stack := &v1alpha2.ClusterStack{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       v1alpha2.ClusterStackKind,
 			APIVersion: "kpack.io/v1alpha2",
@@ -79,7 +75,9 @@ func (f *Factory) MakeStack(keychain authn.Keychain, name, buildImageTag, runIma
 			},
 			ServiceAccountRef: &sa,
 		},
-	}, nil
+	}
+
+	return stack, k8s.SetUpstreamImages(stack, upstreamImages(relocatedBuildImageRef, buildImageTag, relocatedRunImageRef, runImageTag))
 }
 
 func (f *Factory) UpdateStack(keychain authn.Keychain, stack *v1alpha2.ClusterStack, buildImageTag, runImageTag string, kpConfig config.KpConfig) (*v1alpha2.ClusterStack, error) {
@@ -88,31 +86,112 @@ func (f *Factory) UpdateStack(keychain authn.Keychain, stack *v1alpha2.ClusterSt
 		return nil, err
 	}
 
-	relocatedBuildImageRef, relocatedRunImageRef, err := f.uploadStackImages(keychain, buildImageTag, runImageTag, kpConfig)
+	relocatedBuildImageRef, relocatedRunImageRef, err := f.uploadStackImages(keychain, stack.Name, buildImageTag, runImageTag, kpConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	newStack := updatedStack(stack, relocatedBuildImageRef, relocatedRunImageRef, stackID)
+	return newStack, k8s.SetUpstreamImages(newStack, upstreamImages(relocatedBuildImageRef, buildImageTag, relocatedRunImageRef, runImageTag))
+}
+
+// RefreshStack resolves the upstream tags recorded for the build and run
+// images and relocates the images whose tag has moved to a different image.
+// Images without a recorded upstream tag are left as they are.
+func (f *Factory) RefreshStack(keychain authn.Keychain, stack *v1alpha2.ClusterStack, kpConfig config.KpConfig) (*v1alpha2.ClusterStack, error) {
+	upstream, err := k8s.GetUpstreamImages(stack)
+	if err != nil {
+		return nil, err
+	}
+
+	buildImageTag, buildMoved, err := f.refreshedImage(keychain, upstream, stack.Spec.BuildImage.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	runImageTag, runMoved, err := f.refreshedImage(keychain, upstream, stack.Spec.RunImage.Image)
 	if err != nil {
 		return nil, err
 	}
 
-	return updatedStack(stack, relocatedBuildImageRef, relocatedRunImageRef, stackID), nil
+	if !buildMoved && !runMoved {
+		return stack.DeepCopy(), nil
+	}
+
+	return f.UpdateStack(keychain, stack, buildImageTag, runImageTag, kpConfig)
+}
+
+// refreshedImage returns the reference to relocate image from and whether its
+// upstream tag has moved. Images without a recorded tag are relocated from
+// their current reference, which the relocator skips as it already exists.
+func (f *Factory) refreshedImage(keychain authn.Keychain, upstream map[string]string, image string) (string, bool, error) {
+	tag, ok := upstream[image]
+	if !ok {
+		return image, false, nil
+	}
+
+	img, err := f.Fetcher.Fetch(keychain, tag)
+	if err != nil {
+		return "", false, err
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return "", false, err
+	}
+
+	if strings.HasSuffix(image, "@"+digest.String()) {
+		return tag, false, f.Printer.Printlnf("\t'%s' is up to date", tag)
+	}
+	return tag, true, f.Printer.Printlnf("\t'%s' has moved", tag)
 }
 
-func (f *Factory) uploadStackImages(keychain authn.Keychain, buildImageTag, runImageTag string, kpConfig config.KpConfig) (string, string, error) {
-	defaultRepo, err := kpConfig.DefaultRepository()
+func (f *Factory) uploadStackImages(keychain authn.Keychain, name, buildImageTag, runImageTag string, kpConfig config.KpConfig) (string, string, error) {
+	buildRepo, err := kpConfig.ImageRepository(config.BuildImage, config.RepositoryValues{Name: name})
+	if err != nil {
+		return "", "", err
+	}
+
+	runRepo, err := kpConfig.ImageRepository(config.RunImage, config.RepositoryValues{Name: name})
 	if err != nil {
 		return "", "", err
 	}
 
-	if err := f.Printer.PrintStatus("Uploading to '%s'...", defaultRepo); err != nil {
+	if buildRepo == runRepo {
+		err = f.Printer.PrintStatus("Uploading to '%s'...", buildRepo)
+	} else {
+		err = f.Printer.PrintStatus("Uploading to '%s' and '%s'...", buildRepo, runRepo)
+	}
+	if err != nil {
 		return "", "", err
 	}
 
-	return f.Uploader.UploadStackImages(keychain, buildImageTag, runImageTag, defaultRepo)
+	return f.Uploader.UploadStackImages(keychain, buildImageTag, runImageTag, buildRepo, runRepo)
 }
 
 func (f *Factory) validate(keychain authn.Keychain, buildTag, runTag string) (string, error) {
 	return f.Uploader.ValidateStackIDs(keychain, buildTag, runTag)
 }
 
+// upstreamImages records the tags the stack images were relocated from so that
+// they can be refreshed later. Digests and local files never change and are
+// not recorded. Neither is recorded when the build and run images were
+// relocated to the same image from different tags, as they cannot be told
+// apart.
+func upstreamImages(buildImageRef, buildImageTag, runImageRef, runImageTag string) map[string]string {
+	upstream := map[string]string{}
+	if buildImageRef == runImageRef && buildImageTag != runImageTag {
+		return upstream
+	}
+	if registry.IsRemoteTag(buildImageTag) {
+		upstream[buildImageRef] = buildImageTag
+	}
+	if registry.IsRemoteTag(runImageTag) {
+		upstream[runImageRef] = runImageTag
+	}
+	return upstream
+}
+
 func updatedStack(stack *v1alpha2.ClusterStack, buildImageRef, runImageRef, stackId string) *v1alpha2.ClusterStack {
 	newStack := stack.DeepCopy()
 
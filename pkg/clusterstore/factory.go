@@ -20,7 +20,8 @@ import (
 )
 
 type BuildpackageUploader interface {
-	UploadBuildpackage(keychain authn.Keychain, buildPackage, repository string) (string, error)
+	UploadBuildpackage(keychain authn.Keychain, buildPackage string, kpConfig config.KpConfig) (string, error)
+	Prefetch(keychain authn.Keychain, buildPackages []string, kpConfig config.KpConfig) error
 }
 
 type Printer interface {
@@ -29,15 +30,18 @@ type Printer interface {
 
 type Factory struct {
 	Uploader BuildpackageUploader
+	Fetcher  registry.Fetcher
 	Printer  Printer
 }
 
 func NewFactory(printer Printer, relocator registry.Relocator, fetcher registry.Fetcher) *Factory {
+	fetcher = registry.NewCachingFetcher(fetcher)
 	return &Factory{
 		Uploader: &buildpackage.Uploader{
 			Fetcher:   fetcher,
 			Relocator: relocator,
 		},
+		Fetcher: fetcher,
 		Printer: printer,
 	}
 }
@@ -62,13 +66,17 @@ func (f *Factory) MakeStore(keychain authn.Keychain, name string, kpConfig confi
 		},
 	}
 
-	defaultRepo, err := kpConfig.DefaultRepository()
-	if err != nil {
+	if _, err := kpConfig.DefaultRepository(); err != nil {
+		return nil, err
+	}
+
+	if err := f.Uploader.Prefetch(keychain, buildpackages, kpConfig); err != nil {
 		return nil, err
 	}
 
+	upstream := map[string]string{}
 	for _, bp := range buildpackages {
-		uploadedBp, err := f.Uploader.UploadBuildpackage(keychain, bp, defaultRepo)
+		uploadedBp, err := f.Uploader.UploadBuildpackage(keychain, bp, kpConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -76,6 +84,11 @@ func (f *Factory) MakeStore(keychain authn.Keychain, name string, kpConfig confi
 		newStore.Spec.Sources = append(newStore.Spec.Sources, corev1alpha1.StoreImage{
 			Image: uploadedBp,
 		})
+		recordUpstream(upstream, uploadedBp, bp)
+	}
+
+	if err := k8s.SetUpstreamImages(newStore, upstream); err != nil {
+		return nil, err
 	}
 
 	return newStore, k8s.SetLastAppliedCfg(newStore)
@@ -84,18 +97,27 @@ func (f *Factory) MakeStore(keychain authn.Keychain, name string, kpConfig confi
 func (f *Factory) AddToStore(keychain authn.Keychain, store *v1alpha2.ClusterStore, kpConfig config.KpConfig, buildpackages ...string) (*v1alpha2.ClusterStore, error) {
 	updatedStore := store.DeepCopy()
 
-	defaultRepo, err := kpConfig.DefaultRepository()
+	if _, err := kpConfig.DefaultRepository(); err != nil {
+		return nil, err
+	}
+
+	if err := f.Uploader.Prefetch(keychain, buildpackages, kpConfig); err != nil {
+		return nil, err
+	}
+
+	upstream, err := k8s.GetUpstreamImages(updatedStore)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, bp := range buildpackages {
-		uploadedBp, err := f.Uploader.UploadBuildpackage(keychain, bp, defaultRepo)
+		uploadedBp, err := f.Uploader.UploadBuildpackage(keychain, bp, kpConfig)
 		if err != nil {
 			return nil, err
 		}
 
-		if storeContains(updatedStore, uploadedBp) {
+		if source, ok := storeSource(updatedStore, uploadedBp); ok {
+			recordUpstream(upstream, source, bp)
 			if err = f.Printer.Printlnf("\tBuildpackage already exists in the store"); err != nil {
 				return nil, err
 			}
@@ -105,13 +127,81 @@ func (f *Factory) AddToStore(keychain authn.Keychain, store *v1alpha2.ClusterSto
 		updatedStore.Spec.Sources = append(updatedStore.Spec.Sources, corev1alpha1.StoreImage{
 			Image: uploadedBp,
 		})
+		recordUpstream(upstream, uploadedBp, bp)
 
 		if err = f.Printer.Printlnf("\tAdded Buildpackage"); err != nil {
 			return nil, err
 		}
 	}
 
-	return updatedStore, nil
+	return updatedStore, k8s.SetUpstreamImages(updatedStore, upstream)
+}
+
+// RefreshStore resolves the upstream tag recorded for each source and
+// relocates the buildpackages whose tag has moved to a different image.
+// Sources without a recorded upstream tag are left as they are.
+func (f *Factory) RefreshStore(keychain authn.Keychain, store *v1alpha2.ClusterStore, kpConfig config.KpConfig) (*v1alpha2.ClusterStore, error) {
+	updatedStore := store.DeepCopy()
+
+	upstream, err := k8s.GetUpstreamImages(updatedStore)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(upstream) > 0 {
+		if _, err := kpConfig.DefaultRepository(); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, source := range updatedStore.Spec.Sources {
+		bp, ok := upstream[source.Image]
+		if !ok {
+			continue
+		}
+
+		moved, err := f.moved(keychain, bp, source.Image)
+		if err != nil {
+			return nil, err
+		}
+		if !moved {
+			if err = f.Printer.Printlnf("\t'%s' is up to date", bp); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err = f.Printer.Printlnf("\t'%s' has moved", bp); err != nil {
+			return nil, err
+		}
+
+		uploadedBp, err := f.Uploader.UploadBuildpackage(keychain, bp, kpConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		updatedStore.Spec.Sources[i].Image = uploadedBp
+		delete(upstream, source.Image)
+		upstream[uploadedBp] = bp
+	}
+
+	return updatedStore, k8s.SetUpstreamImages(updatedStore, upstream)
+}
+
+// moved reports whether the upstream reference now points to a different
+// image than the relocated one.
+func (f *Factory) moved(keychain authn.Keychain, upstream, relocated string) (bool, error) {
+	image, err := f.Fetcher.Fetch(keychain, upstream)
+	if err != nil {
+		return false, err
+	}
+
+	digest, err := image.Digest()
+	if err != nil {
+		return false, err
+	}
+
+	return !strings.HasSuffix(relocated, "@"+digest.String()), nil
 }
 
 func (f *Factory) RemoveFromStore(store *v1alpha2.ClusterStore, buildpackages ...string) (*v1alpha2.ClusterStore, error) {
@@ -137,7 +227,21 @@ func (f *Factory) RemoveFromStore(store *v1alpha2.ClusterStore, buildpackages ..
 		}
 	}
 
-	return newStore, nil
+	upstream, err := k8s.GetUpstreamImages(newStore)
+	if err != nil {
+		return nil, err
+	}
+	sources := map[string]struct{}{}
+	for _, source := range newStore.Spec.Sources {
+		sources[source.Image] = struct{}{}
+	}
+	for image := range upstream {
+		if _, ok := sources[image]; !ok {
+			delete(upstream, image)
+		}
+	}
+
+	return newStore, k8s.SetUpstreamImages(newStore, upstream)
 }
 
 func getStoreImage(store *v1alpha2.ClusterStore, buildpackage string) (corev1alpha1.StoreImage, bool) {
@@ -157,7 +261,9 @@ func (f *Factory) validate(buildpackages []string) error {
 	return nil
 }
 
-func storeContains(store *v1alpha2.ClusterStore, buildpackage string) bool {
+// storeSource returns the source of the store with the same digest as the
+// buildpackage.
+func storeSource(store *v1alpha2.ClusterStore, buildpackage string) (string, bool) {
 	digest := strings.Split(buildpackage, "@")[1]
 
 	for _, image := range store.Spec.Sources {
@@ -167,8 +273,16 @@ func storeContains(store *v1alpha2.ClusterStore, buildpackage string) bool {
 		}
 
 		if parts[1] == digest {
-			return true
+			return image.Image, true
 		}
 	}
-	return false
+	return "", false
+}
+
+// recordUpstream records the tag a source was relocated from so that it can be
+// refreshed later. Digests and local files never change and are not recorded.
+func recordUpstream(upstream map[string]string, source, buildpackage string) {
+	if registry.IsRemoteTag(buildpackage) {
+		upstream[source] = buildpackage
+	}
 }
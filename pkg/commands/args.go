@@ -25,3 +25,12 @@ func OptionalArgsWithUsage(n int) cobra.PositionalArgs {
 		return nil
 	}
 }
+
+func RangeArgsWithUsage(min, max int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("accepts between %d and %d arg(s), received %d\n\n%s", min, max, len(args), cmd.UsageString())
+		}
+		return nil
+	}
+}
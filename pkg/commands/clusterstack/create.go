@@ -22,7 +22,9 @@ func NewCreateCommand(clientSetProvider k8s.ClientSetProvider, rup registry.Util
 	var (
 		buildImageRef string
 		runImageRef   string
+		alwaysTag     bool
 		tlsCfg        registry.TLSConfig
+		retryCfg      registry.RetryConfig
 	)
 
 	cmd := &cobra.Command{
@@ -54,7 +56,7 @@ kp clusterstack create my-stack --build-image ../path/to/build.tar --run-image .
 
 			ctx := cmd.Context()
 
-			factory := clusterstack.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, ch.IsUploading()), rup.Fetcher(tlsCfg))
+			factory := clusterstack.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, retryCfg, ch.IsUploading(), alwaysTag), rup.Fetcher(tlsCfg, retryCfg))
 
 			name := args[0]
 			return create(ctx, name, buildImageRef, runImageRef, factory, ch, cs, newWaiter(cs.DynamicClient))
@@ -63,7 +65,9 @@ kp clusterstack create my-stack --build-image ../path/to/build.tar --run-image .
 	cmd.Flags().StringVarP(&buildImageRef, "build-image", "b", "", "build image tag or local tar file path")
 	cmd.Flags().StringVarP(&runImageRef, "run-image", "r", "", "run image tag or local tar file path")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
 	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
 	_ = cmd.MarkFlagRequired("build-image")
 	_ = cmd.MarkFlagRequired("run-image")
 	return cmd
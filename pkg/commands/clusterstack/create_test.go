@@ -76,8 +76,10 @@ func testCreateCommand(imageCommand func(clientSetProvider k8s.ClientSetProvider
 				APIVersion: "kpack.io/v1alpha2",
 			},
 			ObjectMeta: metav1.ObjectMeta{
-				Name:        "stack-name",
-				Annotations: nil,
+				Name: "stack-name",
+				Annotations: map[string]string{
+					"kpack.io/upstream-images": `{"default-registry.io/default-repo@sha256:build-image-digest":"some-registry.io/repo/some-build-image","default-registry.io/default-repo@sha256:run-image-digest":"some-registry.io/repo/some-run-image"}`,
+				},
 			},
 			Spec: v1alpha2.ClusterStackSpec{
 				Id: "stack-id",
@@ -148,6 +150,8 @@ ClusterStack "stack-name" created
 				const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStack
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:build-image-digest":"some-registry.io/repo/some-build-image","default-registry.io/default-repo@sha256:run-image-digest":"some-registry.io/repo/some-run-image"}'
   creationTimestamp: null
   name: stack-name
 spec:
@@ -192,7 +196,10 @@ Uploading to 'default-registry.io/default-repo'...
     "apiVersion": "kpack.io/v1alpha2",
     "metadata": {
         "name": "stack-name",
-        "creationTimestamp": null
+        "creationTimestamp": null,
+        "annotations": {
+            "kpack.io/upstream-images": "{\"default-registry.io/default-repo@sha256:build-image-digest\":\"some-registry.io/repo/some-build-image\",\"default-registry.io/default-repo@sha256:run-image-digest\":\"some-registry.io/repo/some-run-image\"}"
+        }
     },
     "spec": {
         "id": "stack-id",
@@ -266,6 +273,8 @@ ClusterStack "stack-name" created (dry run)
 					const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStack
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:build-image-digest":"some-registry.io/repo/some-build-image","default-registry.io/default-repo@sha256:run-image-digest":"some-registry.io/repo/some-run-image"}'
   creationTimestamp: null
   name: stack-name
 spec:
@@ -330,6 +339,8 @@ ClusterStack "stack-name" created (dry run with image upload)
 					const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStack
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:build-image-digest":"some-registry.io/repo/some-build-image","default-registry.io/default-repo@sha256:run-image-digest":"some-registry.io/repo/some-run-image"}'
   creationTimestamp: null
   name: stack-name
 spec:
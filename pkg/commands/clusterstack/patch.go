@@ -34,7 +34,9 @@ func NewPatchCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilP
 	var (
 		buildImageRef string
 		runImageRef   string
+		alwaysTag     bool
 		tlsCfg        registry.TLSConfig
+		retryCfg      registry.RetryConfig
 	)
 
 	cmd := &cobra.Command{
@@ -70,7 +72,7 @@ kp clusterstack patch my-stack --build-image ../path/to/build.tar --run-image ..
 				return err
 			}
 
-			factory := clusterstack.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, ch.IsUploading()), rup.Fetcher(tlsCfg))
+			factory := clusterstack.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, retryCfg, ch.IsUploading(), alwaysTag), rup.Fetcher(tlsCfg, retryCfg))
 
 			return patch(ctx, authn.DefaultKeychain, stack, buildImageRef, runImageRef, factory, ch, cs, newWaiter(cs.DynamicClient))
 		},
@@ -79,7 +81,9 @@ kp clusterstack patch my-stack --build-image ../path/to/build.tar --run-image ..
 	cmd.Flags().StringVarP(&buildImageRef, "build-image", "b", "", "build image tag or local tar file path")
 	cmd.Flags().StringVarP(&runImageRef, "run-image", "r", "", "run image tag or local tar file path")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
 	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
 	_ = cmd.MarkFlagRequired("build-image")
 	_ = cmd.MarkFlagRequired("run-image")
 	return cmd
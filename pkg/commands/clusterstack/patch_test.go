@@ -119,7 +119,7 @@ func testUpdateCommand(imageCommand func(clientSetProvider k8s.ClientSetProvider
 					"--registry-verify-certs",
 				},
 				ExpectPatches: []string{
-					`{"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:new-build-image-digest"},"runImage":{"image":"default-registry.io/default-repo@sha256:new-run-image-digest"}}}`,
+					`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:new-build-image-digest\":\"some-registry.io/repo/new-build\",\"default-registry.io/default-repo@sha256:new-run-image-digest\":\"some-registry.io/repo/new-run\"}"}},"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:new-build-image-digest"},"runImage":{"image":"default-registry.io/default-repo@sha256:new-run-image-digest"}}}`,
 				},
 				ExpectedOutput: `Updating ClusterStack...
 Uploading to 'default-registry.io/default-repo'...
@@ -161,6 +161,8 @@ ClusterStack "stack-name" updated
 				const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStack
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:new-build-image-digest":"some-registry.io/repo/new-build","default-registry.io/default-repo@sha256:new-run-image-digest":"some-registry.io/repo/new-run"}'
   creationTimestamp: null
   name: stack-name
 spec:
@@ -194,7 +196,7 @@ status:
 						"--output", "yaml",
 					},
 					ExpectPatches: []string{
-						`{"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:new-build-image-digest"},"runImage":{"image":"default-registry.io/default-repo@sha256:new-run-image-digest"}}}`,
+						`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:new-build-image-digest\":\"some-registry.io/repo/new-build\",\"default-registry.io/default-repo@sha256:new-run-image-digest\":\"some-registry.io/repo/new-run\"}"}},"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:new-build-image-digest"},"runImage":{"image":"default-registry.io/default-repo@sha256:new-run-image-digest"}}}`,
 					},
 					ExpectedOutput: resourceYAML,
 					ExpectedErrorOutput: `Updating ClusterStack...
@@ -211,7 +213,10 @@ Uploading to 'default-registry.io/default-repo'...
     "apiVersion": "kpack.io/v1alpha2",
     "metadata": {
         "name": "stack-name",
-        "creationTimestamp": null
+        "creationTimestamp": null,
+        "annotations": {
+            "kpack.io/upstream-images": "{\"default-registry.io/default-repo@sha256:new-build-image-digest\":\"some-registry.io/repo/new-build\",\"default-registry.io/default-repo@sha256:new-run-image-digest\":\"some-registry.io/repo/new-run\"}"
+        }
     },
     "spec": {
         "id": "stack-id",
@@ -252,7 +257,7 @@ Uploading to 'default-registry.io/default-repo'...
 						"--output", "json",
 					},
 					ExpectPatches: []string{
-						`{"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:new-build-image-digest"},"runImage":{"image":"default-registry.io/default-repo@sha256:new-run-image-digest"}}}`,
+						`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:new-build-image-digest\":\"some-registry.io/repo/new-build\",\"default-registry.io/default-repo@sha256:new-run-image-digest\":\"some-registry.io/repo/new-run\"}"}},"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:new-build-image-digest"},"runImage":{"image":"default-registry.io/default-repo@sha256:new-run-image-digest"}}}`,
 					},
 					ExpectedOutput: resourceJSON,
 					ExpectedErrorOutput: `Updating ClusterStack...
@@ -276,11 +281,16 @@ Uploading to 'default-registry.io/default-repo'...
 					},
 				})
 
+				unchangedStack := stack.DeepCopy()
+				unchangedStack.Annotations = map[string]string{
+					"kpack.io/upstream-images": `{"default-registry.io/default-repo@sha256:build-image-digest":"some-registry.io/repo/new-build","default-registry.io/default-repo@sha256:run-image-digest":"some-registry.io/repo/new-run"}`,
+				}
+
 				it("reports no change", func() {
 					testhelpers.CommandTest{
 						Objects: []runtime.Object{
 							config,
-							stack,
+							unchangedStack,
 						},
 						Args: []string{
 							"stack-name",
@@ -301,6 +311,8 @@ ClusterStack "stack-name" updated (no change)
 					const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStack
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:build-image-digest":"some-registry.io/repo/new-build","default-registry.io/default-repo@sha256:run-image-digest":"some-registry.io/repo/new-run"}'
   creationTimestamp: null
   name: stack-name
 spec:
@@ -325,7 +337,7 @@ status:
 					testhelpers.CommandTest{
 						Objects: []runtime.Object{
 							config,
-							stack,
+							unchangedStack,
 						},
 						Args: []string{
 							"stack-name",
@@ -372,6 +384,8 @@ ClusterStack "stack-name" updated (dry run)
 					const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStack
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:new-build-image-digest":"some-registry.io/repo/new-build","default-registry.io/default-repo@sha256:new-run-image-digest":"some-registry.io/repo/new-run"}'
   creationTimestamp: null
   name: stack-name
 spec:
@@ -443,6 +457,8 @@ ClusterStack "stack-name" updated (dry run with image upload)
 					const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStack
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:new-build-image-digest":"some-registry.io/repo/new-build","default-registry.io/default-repo@sha256:new-run-image-digest":"some-registry.io/repo/new-run"}'
   creationTimestamp: null
   name: stack-name
 spec:
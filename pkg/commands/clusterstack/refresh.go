@@ -0,0 +1,108 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package clusterstack
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/clusterstack"
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func NewRefreshCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilProvider, newWaiter func(dynamic.Interface) commands.ResourceWaiter) *cobra.Command {
+	var (
+		alwaysTag bool
+		tlsCfg    registry.TLSConfig
+		retryCfg  registry.RetryConfig
+	)
+
+	cmd := &cobra.Command{
+		Use:   "refresh <name>",
+		Short: "Refresh a cluster stack from its upstream tags",
+		Long: `Re-resolve the upstream tags the build and run images of a specific cluster-scoped stack were uploaded from.
+
+kp records the tag each image was uploaded from in the "kpack.io/upstream-images" annotation.
+When either tag now points to a different image, the images are uploaded to the default repository and the stack is patched.
+Images uploaded from a digest or a local file are left as they are.
+
+The default repository is read from the "default.repository" key in the "kp-config" ConfigMap within "kpack" namespace.`,
+		Example: `kp clusterstack refresh my-stack
+kp clusterstack refresh my-stack --dry-run`,
+		Args:         commands.ExactArgsWithUsage(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := clientSetProvider.GetClientSet("")
+			if err != nil {
+				return err
+			}
+
+			ch, err := commands.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+
+			stack, err := cs.KpackClient.KpackV1alpha2().ClusterStacks().Get(ctx, args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			factory := clusterstack.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, retryCfg, ch.IsUploading(), alwaysTag), rup.Fetcher(tlsCfg, retryCfg))
+
+			return refresh(ctx, authn.DefaultKeychain, stack, factory, ch, cs, newWaiter(cs.DynamicClient))
+		},
+	}
+
+	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
+	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
+	return cmd
+}
+
+func refresh(ctx context.Context, keychain authn.Keychain, stack *v1alpha2.ClusterStack, factory *clusterstack.Factory, ch *commands.CommandHelper, cs k8s.ClientSet, w commands.ResourceWaiter) error {
+	if err := ch.PrintStatus("Refreshing ClusterStack..."); err != nil {
+		return err
+	}
+
+	kpConfig := config.NewKpConfigProvider(cs.K8sClient).GetKpConfig(ctx)
+
+	updatedStack, err := factory.RefreshStack(keychain, stack, kpConfig)
+	if err != nil {
+		return err
+	}
+
+	p, err := k8s.CreatePatch(stack, updatedStack)
+	if err != nil {
+		return err
+	}
+
+	hasUpdates := len(p) > 0
+	if hasUpdates && !ch.IsDryRun() {
+		updatedStack, err = cs.KpackClient.KpackV1alpha2().ClusterStacks().Patch(ctx, updatedStack.Name, types.MergePatchType, p, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+		if err := w.Wait(ctx, updatedStack); err != nil {
+			return err
+		}
+	}
+
+	if err = ch.PrintObj(updatedStack); err != nil {
+		return err
+	}
+
+	return ch.PrintChangeResult(hasUpdates, "ClusterStack %q updated", updatedStack.Name)
+}
@@ -0,0 +1,170 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package clusterstack_test
+
+import (
+	"testing"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	clusterstackcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/clusterstack"
+	commandsfakes "github.com/vmware-tanzu/kpack-cli/pkg/commands/fakes"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+)
+
+func TestRefreshCommand(t *testing.T) {
+	spec.Run(t, "TestRefreshCommand", testRefreshCommand)
+}
+
+func testRefreshCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		fakeFetcher = registryfakes.NewStackImagesFetcher(registryfakes.StackInfo{
+			StackID: "stack-id",
+			BuildImg: registryfakes.ImageInfo{
+				Ref:    "some-registry.io/repo/build",
+				Digest: "build-image-digest",
+			},
+			RunImg: registryfakes.ImageInfo{
+				Ref:    "some-registry.io/repo/run",
+				Digest: "new-run-image-digest",
+			},
+		})
+		fakeRegistryUtilProvider = &registryfakes.UtilProvider{
+			FakeFetcher: fakeFetcher,
+		}
+
+		fakeWaiter = &commandsfakes.FakeWaiter{}
+
+		config = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kp-config",
+				Namespace: "kpack",
+			},
+			Data: map[string]string{
+				"default.repository": "default-registry.io/default-repo",
+			},
+		}
+
+		stack = &v1alpha2.ClusterStack{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "stack-name",
+				Annotations: map[string]string{
+					"kpack.io/upstream-images": `{"default-registry.io/default-repo@sha256:build-image-digest":"some-registry.io/repo/build","default-registry.io/default-repo@sha256:run-image-digest":"some-registry.io/repo/run"}`,
+				},
+			},
+			Spec: v1alpha2.ClusterStackSpec{
+				Id: "stack-id",
+				BuildImage: v1alpha2.ClusterStackSpecImage{
+					Image: "default-registry.io/default-repo@sha256:build-image-digest",
+				},
+				RunImage: v1alpha2.ClusterStackSpecImage{
+					Image: "default-registry.io/default-repo@sha256:run-image-digest",
+				},
+			},
+		}
+	)
+
+	cmdFunc := func(k8sClientSet *k8sfakes.Clientset, kpackClientSet *kpackfakes.Clientset) *cobra.Command {
+		clientSetProvider := testhelpers.GetFakeClusterProvider(k8sClientSet, kpackClientSet)
+		return clusterstackcmds.NewRefreshCommand(clientSetProvider, fakeRegistryUtilProvider, func(dynamic.Interface) commands.ResourceWaiter {
+			return fakeWaiter
+		})
+	}
+
+	it("relocates the stack when an upstream tag has moved", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				stack,
+			},
+			Args: []string{"stack-name"},
+			ExpectPatches: []string{
+				`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:build-image-digest\":\"some-registry.io/repo/build\",\"default-registry.io/default-repo@sha256:new-run-image-digest\":\"some-registry.io/repo/run\"}"}},"spec":{"runImage":{"image":"default-registry.io/default-repo@sha256:new-run-image-digest"}}}`,
+			},
+			ExpectedOutput: `Refreshing ClusterStack...
+	'some-registry.io/repo/build' is up to date
+	'some-registry.io/repo/run' has moved
+Uploading to 'default-registry.io/default-repo'...
+	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
+	Uploading 'default-registry.io/default-repo@sha256:new-run-image-digest'
+ClusterStack "stack-name" updated
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+		require.Len(t, fakeWaiter.WaitCalls, 1)
+	})
+
+	it("reports no change when the upstream tags are up to date", func() {
+		fakeFetcher.AddStackImages(registryfakes.StackInfo{
+			StackID: "stack-id",
+			BuildImg: registryfakes.ImageInfo{
+				Ref:    "some-registry.io/repo/build",
+				Digest: "build-image-digest",
+			},
+			RunImg: registryfakes.ImageInfo{
+				Ref:    "some-registry.io/repo/run",
+				Digest: "run-image-digest",
+			},
+		})
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				stack,
+			},
+			Args: []string{"stack-name"},
+			ExpectedOutput: `Refreshing ClusterStack...
+	'some-registry.io/repo/build' is up to date
+	'some-registry.io/repo/run' is up to date
+ClusterStack "stack-name" updated (no change)
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+		require.Len(t, fakeWaiter.WaitCalls, 0)
+	})
+
+	it("leaves stacks without upstream tags unchanged", func() {
+		unannotated := stack.DeepCopy()
+		unannotated.Annotations = nil
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				unannotated,
+			},
+			Args: []string{"stack-name"},
+			ExpectedOutput: `Refreshing ClusterStack...
+ClusterStack "stack-name" updated (no change)
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("does not patch the stack with --dry-run", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				stack,
+			},
+			Args: []string{"stack-name", "--dry-run"},
+			ExpectedOutput: `Refreshing ClusterStack... (dry run)
+	'some-registry.io/repo/build' is up to date
+	'some-registry.io/repo/run' has moved
+Uploading to 'default-registry.io/default-repo'... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+	Skipping 'default-registry.io/default-repo@sha256:new-run-image-digest'
+ClusterStack "stack-name" updated (dry run)
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+		require.Len(t, fakeWaiter.WaitCalls, 0)
+	})
+}
@@ -20,7 +20,9 @@ func NewSaveCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilPr
 	var (
 		buildImageRef string
 		runImageRef   string
+		alwaysTag     bool
 		tlsCfg        registry.TLSConfig
+		retryCfg      registry.RetryConfig
 	)
 
 	cmd := &cobra.Command{
@@ -53,7 +55,7 @@ kp clusterstack save my-stack --build-image ../path/to/build.tar --run-image ../
 				return err
 			}
 
-			factory := clusterstack.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, ch.IsUploading()), rup.Fetcher(tlsCfg))
+			factory := clusterstack.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, retryCfg, ch.IsUploading(), alwaysTag), rup.Fetcher(tlsCfg, retryCfg))
 
 			name := args[0]
 			cStack, err := cs.KpackClient.KpackV1alpha2().ClusterStacks().Get(ctx, name, metav1.GetOptions{})
@@ -69,7 +71,9 @@ kp clusterstack save my-stack --build-image ../path/to/build.tar --run-image ../
 	cmd.Flags().StringVarP(&buildImageRef, "build-image", "b", "", "build image tag or local tar file path")
 	cmd.Flags().StringVarP(&runImageRef, "run-image", "r", "", "run image tag or local tar file path")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
 	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
 	_ = cmd.MarkFlagRequired("build-image")
 	_ = cmd.MarkFlagRequired("run-image")
 	return cmd
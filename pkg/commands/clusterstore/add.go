@@ -25,7 +25,10 @@ import (
 func NewAddCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilProvider, newWaiter func(dynamic.Interface) commands.ResourceWaiter) *cobra.Command {
 	var (
 		buildpackages []string
+		parallelism   int
+		alwaysTag     bool
 		tlsCfg        registry.TLSConfig
+		retryCfg      registry.RetryConfig
 	)
 
 	cmd := &cobra.Command{
@@ -36,11 +39,15 @@ func NewAddCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilPro
 Buildpackages will be uploaded to the default repository.
 Therefore, you must have credentials to access the registry on your machine.
 
+A buildpackage can also be a directory containing a buildpack.toml. It is packaged into a buildpackage
+along with the dependencies listed in its package.toml, without needing pack or Docker.
+
 The default repository is read from the "default.repository" key in the "kp-config" ConfigMap within "kpack" namespace.
 `,
 		Example: `kp clusterstore add my-store -b my-registry.com/my-buildpackage
 kp clusterstore add my-store -b my-registry.com/my-buildpackage -b my-registry.com/my-other-buildpackage -b my-registry.com/my-third-buildpackage
-kp clusterstore add my-store -b ../path/to/my-local-buildpackage.cnb`,
+kp clusterstore add my-store -b ../path/to/my-local-buildpackage.cnb
+kp clusterstore add my-store -b ../path/to/my-buildpack`,
 		Args:         commands.ExactArgsWithUsage(1),
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -64,8 +71,8 @@ kp clusterstore add my-store -b ../path/to/my-local-buildpackage.cnb`,
 				return err
 			}
 
-			relocator := rup.Relocator(ch.Writer(), tlsCfg, ch.IsUploading())
-			fetcher := rup.Fetcher(tlsCfg)
+			relocator := registry.NewParallelRelocatorFromProvider(rup, ch.Writer(), tlsCfg, retryCfg, ch.IsUploading(), alwaysTag, parallelism)
+			fetcher := rup.Fetcher(tlsCfg, retryCfg)
 			factory := clusterstore.NewFactory(ch, relocator, fetcher)
 
 			return update(ctx, store, buildpackages, factory, ch, cs, newWaiter(cs.DynamicClient))
@@ -74,7 +81,10 @@ kp clusterstore add my-store -b ../path/to/my-local-buildpackage.cnb`,
 
 	cmd.Flags().StringArrayVarP(&buildpackages, "buildpackage", "b", []string{}, "location of the buildpackage")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetParallelismFlag(cmd, &parallelism)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
 	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
 	return cmd
 }
 
@@ -105,7 +105,7 @@ func testAddCommand(clusterStackCommand func(clientSetProvider k8s.ClientSetProv
 					"--registry-verify-certs",
 				},
 				ExpectPatches: []string{
-					`{"spec":{"sources":[{"image":"default-registry.io/default-repo/old-buildpack-id@sha256:old-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:new-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}]}}`,
+					`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:new-buildpack-digest\":\"some-registry.io/repo/new-buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo/old-buildpack-id@sha256:old-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:new-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}]}}`,
 				},
 				ExpectedOutput: `Adding to ClusterStore...
 	Uploading 'default-registry.io/default-repo@sha256:new-buildpack-digest'
@@ -156,6 +156,8 @@ ClusterStore "store-name" updated (no change)
 				const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStore
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:new-buildpack-digest":"some-registry.io/repo/new-buildpack"}'
   creationTimestamp: null
   name: store-name
 spec:
@@ -181,7 +183,7 @@ status: {}
 						"--output", "yaml",
 					},
 					ExpectPatches: []string{
-						`{"spec":{"sources":[{"image":"default-registry.io/default-repo/old-buildpack-id@sha256:old-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:new-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}]}}`,
+						`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:new-buildpack-digest\":\"some-registry.io/repo/new-buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo/old-buildpack-id@sha256:old-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:new-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}]}}`,
 					},
 					ExpectedOutput: resourceYAML,
 					ExpectedErrorOutput: `Adding to ClusterStore...
@@ -199,7 +201,10 @@ status: {}
     "apiVersion": "kpack.io/v1alpha2",
     "metadata": {
         "name": "store-name",
-        "creationTimestamp": null
+        "creationTimestamp": null,
+        "annotations": {
+            "kpack.io/upstream-images": "{\"default-registry.io/default-repo@sha256:new-buildpack-digest\":\"some-registry.io/repo/new-buildpack\"}"
+        }
     },
     "spec": {
         "sources": [
@@ -233,7 +238,7 @@ status: {}
 						"-b", localCNBPath,
 						"--output", "json",
 					},
-					ExpectPatches:  []string{`{"spec":{"sources":[{"image":"default-registry.io/default-repo/old-buildpack-id@sha256:old-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:new-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}]}}`},
+					ExpectPatches:  []string{`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:new-buildpack-digest\":\"some-registry.io/repo/new-buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo/old-buildpack-id@sha256:old-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:new-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}]}}`},
 					ExpectedOutput: resourceJSON,
 					ExpectedErrorOutput: `Adding to ClusterStore...
 	Uploading 'default-registry.io/default-repo@sha256:new-buildpack-digest'
@@ -330,6 +335,8 @@ ClusterStore "store-name" updated (dry run)
 					const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStore
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:new-buildpack-digest":"some-registry.io/repo/new-buildpack"}'
   creationTimestamp: null
   name: store-name
 spec:
@@ -416,6 +423,8 @@ ClusterStore "store-name" updated (dry run with image upload)
 					const resourceYAML = `apiVersion: kpack.io/v1alpha2
 kind: ClusterStore
 metadata:
+  annotations:
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:new-buildpack-digest":"some-registry.io/repo/new-buildpack"}'
   creationTimestamp: null
   name: store-name
 spec:
@@ -21,7 +21,10 @@ import (
 func NewCreateCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilProvider, newWaiter func(dynamic.Interface) commands.ResourceWaiter) *cobra.Command {
 	var (
 		buildpackages []string
+		parallelism   int
+		alwaysTag     bool
 		tlsCfg        registry.TLSConfig
+		retryCfg      registry.RetryConfig
 	)
 
 	cmd := &cobra.Command{
@@ -32,13 +35,17 @@ func NewCreateCommand(clientSetProvider k8s.ClientSetProvider, rup registry.Util
 Buildpackages will be uploaded to the default repository.
 Therefore, you must have credentials to access the registry on your machine.
 
+A buildpackage can also be a directory containing a buildpack.toml. It is packaged into a buildpackage
+along with the dependencies listed in its package.toml, without needing pack or Docker.
+
 This clusterstore will be created only if it does not exist.
 The default repository is read from the "default.repository" key in the "kp-config" ConfigMap within "kpack" namespace.
 The default service account used is read from the "default.serviceaccount" key in the "kp-config" ConfigMap within "kpack" namespace.
 `,
 		Example: `kp clusterstore create my-store -b my-registry.com/my-buildpackage
 kp clusterstore create my-store -b my-registry.com/my-buildpackage -b my-registry.com/my-other-buildpackage
-kp clusterstore create my-store -b ../path/to/my-local-buildpackage.cnb`,
+kp clusterstore create my-store -b ../path/to/my-local-buildpackage.cnb
+kp clusterstore create my-store -b ../path/to/my-buildpack`,
 		Args:         commands.ExactArgsWithUsage(1),
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -54,7 +61,7 @@ kp clusterstore create my-store -b ../path/to/my-local-buildpackage.cnb`,
 
 			ctx := cmd.Context()
 
-			factory := clusterstore.NewFactory(ch, rup.Relocator(ch.Writer(), tlsCfg, ch.IsUploading()), rup.Fetcher(tlsCfg))
+			factory := clusterstore.NewFactory(ch, registry.NewParallelRelocatorFromProvider(rup, ch.Writer(), tlsCfg, retryCfg, ch.IsUploading(), alwaysTag, parallelism), rup.Fetcher(tlsCfg, retryCfg))
 
 			name := args[0]
 			return create(ctx, name, buildpackages, factory, ch, cs, newWaiter(cs.DynamicClient))
@@ -63,7 +70,10 @@ kp clusterstore create my-store -b ../path/to/my-local-buildpackage.cnb`,
 
 	cmd.Flags().StringArrayVarP(&buildpackages, "buildpackage", "b", []string{}, "location of the buildpackage")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetParallelismFlag(cmd, &parallelism)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
 	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
 	return cmd
 }
 
@@ -69,7 +69,8 @@ func testCreateCommand(clusterStackCommand func(clientSetProvider k8s.ClientSetP
 				ObjectMeta: metav1.ObjectMeta{
 					Name: "store-name",
 					Annotations: map[string]string{
-						"kubectl.kubernetes.io/last-applied-configuration": `{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}`,
+						"kpack.io/upstream-images":                         `{"default-registry.io/default-repo@sha256:buildpack-digest":"some-registry.io/repo/buildpack"}`,
+						"kubectl.kubernetes.io/last-applied-configuration": `{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null,"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:buildpack-digest\":\"some-registry.io/repo/buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}`,
 					},
 				},
 				Spec: v1alpha2.ClusterStoreSpec{
@@ -161,7 +162,8 @@ ClusterStore "store-name" created
 kind: ClusterStore
 metadata:
   annotations:
-    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:buildpack-digest":"some-registry.io/repo/buildpack"}'
+    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null,"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:buildpack-digest\":\"some-registry.io/repo/buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
   creationTimestamp: null
   name: store-name
 spec:
@@ -204,7 +206,8 @@ status: {}
         "name": "store-name",
         "creationTimestamp": null,
         "annotations": {
-            "kubectl.kubernetes.io/last-applied-configuration": "{\"kind\":\"ClusterStore\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"store-name\",\"creationTimestamp\":null},\"spec\":{\"sources\":[{\"image\":\"default-registry.io/default-repo@sha256:buildpack-digest\"},{\"image\":\"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf\"}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{}}"
+            "kpack.io/upstream-images": "{\"default-registry.io/default-repo@sha256:buildpack-digest\":\"some-registry.io/repo/buildpack\"}",
+            "kubectl.kubernetes.io/last-applied-configuration": "{\"kind\":\"ClusterStore\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"store-name\",\"creationTimestamp\":null,\"annotations\":{\"kpack.io/upstream-images\":\"{\\\"default-registry.io/default-repo@sha256:buildpack-digest\\\":\\\"some-registry.io/repo/buildpack\\\"}\"}},\"spec\":{\"sources\":[{\"image\":\"default-registry.io/default-repo@sha256:buildpack-digest\"},{\"image\":\"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf\"}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{}}"
         }
     },
     "spec": {
@@ -274,7 +277,8 @@ ClusterStore "store-name" created (dry run)
 kind: ClusterStore
 metadata:
   annotations:
-    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:buildpack-digest":"some-registry.io/repo/buildpack"}'
+    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null,"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:buildpack-digest\":\"some-registry.io/repo/buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
   creationTimestamp: null
   name: store-name
 spec:
@@ -334,7 +338,8 @@ ClusterStore "store-name" created (dry run with image upload)
 kind: ClusterStore
 metadata:
   annotations:
-    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:buildpack-digest":"some-registry.io/repo/buildpack"}'
+    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null,"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:buildpack-digest\":\"some-registry.io/repo/buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:37d646bec2453ab05fe57288ede904dfd12f988dbc964e3e764c41c1bd3b58bf"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
   creationTimestamp: null
   name: store-name
 spec:
@@ -0,0 +1,118 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package clusterstore
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/clusterstore"
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func NewRefreshCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilProvider, newWaiter func(dynamic.Interface) commands.ResourceWaiter) *cobra.Command {
+	var (
+		parallelism int
+		alwaysTag   bool
+		tlsCfg      registry.TLSConfig
+		retryCfg    registry.RetryConfig
+	)
+
+	cmd := &cobra.Command{
+		Use:   "refresh <store>",
+		Short: "Refresh the buildpackages of a cluster store from their upstream tags",
+		Long: `Re-resolve the upstream tags the buildpackages of a specific cluster-scoped buildpack store were uploaded from.
+
+kp records the tag each buildpackage was uploaded from in the "kpack.io/upstream-images" annotation.
+Buildpackages whose tag now points to a different image are uploaded to the default repository and replaced in the store.
+Buildpackages uploaded from a digest or a local file are left as they are.
+
+The default repository is read from the "default.repository" key in the "kp-config" ConfigMap within "kpack" namespace.
+`,
+		Example: `kp clusterstore refresh my-store
+kp clusterstore refresh my-store --dry-run`,
+		Args:         commands.ExactArgsWithUsage(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := clientSetProvider.GetClientSet("")
+			if err != nil {
+				return err
+			}
+
+			ch, err := commands.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			ctx := cmd.Context()
+
+			name := args[0]
+			store, err := cs.KpackClient.KpackV1alpha2().ClusterStores().Get(ctx, name, metav1.GetOptions{})
+			if k8serrors.IsNotFound(err) {
+				return errors.Errorf("ClusterStore '%s' does not exist", name)
+			} else if err != nil {
+				return err
+			}
+
+			relocator := registry.NewParallelRelocatorFromProvider(rup, ch.Writer(), tlsCfg, retryCfg, ch.IsUploading(), alwaysTag, parallelism)
+			fetcher := rup.Fetcher(tlsCfg, retryCfg)
+			factory := clusterstore.NewFactory(ch, relocator, fetcher)
+
+			return refresh(ctx, store, factory, ch, cs, newWaiter(cs.DynamicClient))
+		},
+	}
+
+	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetParallelismFlag(cmd, &parallelism)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
+	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
+	return cmd
+}
+
+func refresh(ctx context.Context, store *v1alpha2.ClusterStore, factory *clusterstore.Factory, ch *commands.CommandHelper, cs k8s.ClientSet, w commands.ResourceWaiter) error {
+	if err := ch.PrintStatus("Refreshing ClusterStore..."); err != nil {
+		return err
+	}
+
+	kpConfig := config.NewKpConfigProvider(cs.K8sClient).GetKpConfig(ctx)
+
+	updatedStore, err := factory.RefreshStore(authn.DefaultKeychain, store, kpConfig)
+	if err != nil {
+		return err
+	}
+
+	patch, err := k8s.CreatePatch(store, updatedStore)
+	if err != nil {
+		return err
+	}
+
+	hasPatch := len(patch) > 0
+	if hasPatch && !ch.IsDryRun() {
+		updatedStore, err = cs.KpackClient.KpackV1alpha2().ClusterStores().Patch(ctx, updatedStore.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+		if err := w.Wait(ctx, updatedStore); err != nil {
+			return err
+		}
+	}
+
+	if err = ch.PrintObj(updatedStore); err != nil {
+		return err
+	}
+
+	return ch.PrintChangeResult(hasPatch, "ClusterStore %q updated", updatedStore.Name)
+}
@@ -0,0 +1,177 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package clusterstore_test
+
+import (
+	"testing"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	storecmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/clusterstore"
+	commandsfakes "github.com/vmware-tanzu/kpack-cli/pkg/commands/fakes"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+)
+
+func TestClusterStoreRefreshCommand(t *testing.T) {
+	spec.Run(t, "TestClusterStoreRefreshCommand", testRefreshCommand)
+}
+
+func testRefreshCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		fakeFetcher = registryfakes.NewBuildpackImagesFetcher(
+			registryfakes.BuildpackImgInfo{
+				Id: "some-buildpack-id",
+				ImageInfo: registryfakes.ImageInfo{
+					Ref:    "some-registry.io/repo/some-buildpack",
+					Digest: "new-buildpack-digest",
+				},
+			},
+			registryfakes.BuildpackImgInfo{
+				Id: "other-buildpack-id",
+				ImageInfo: registryfakes.ImageInfo{
+					Ref:    "some-registry.io/repo/other-buildpack",
+					Digest: "other-buildpack-digest",
+				},
+			},
+		)
+		fakeRegistryUtilProvider = &registryfakes.UtilProvider{
+			FakeFetcher: fakeFetcher,
+		}
+
+		fakeWaiter = &commandsfakes.FakeWaiter{}
+
+		config = &corev1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "kp-config",
+				Namespace: "kpack",
+			},
+			Data: map[string]string{
+				"default.repository": "default-registry.io/default-repo",
+			},
+		}
+
+		existingStore = &v1alpha2.ClusterStore{
+			ObjectMeta: v1.ObjectMeta{
+				Name: "store-name",
+				Annotations: map[string]string{
+					"kpack.io/upstream-images": `{"default-registry.io/default-repo@sha256:old-buildpack-digest":"some-registry.io/repo/some-buildpack","default-registry.io/default-repo@sha256:other-buildpack-digest":"some-registry.io/repo/other-buildpack"}`,
+				},
+			},
+			Spec: v1alpha2.ClusterStoreSpec{
+				Sources: []corev1alpha1.StoreImage{
+					{Image: "default-registry.io/default-repo@sha256:old-buildpack-digest"},
+					{Image: "default-registry.io/default-repo@sha256:other-buildpack-digest"},
+					{Image: "default-registry.io/default-repo@sha256:local-buildpack-digest"},
+				},
+			},
+		}
+	)
+
+	cmdFunc := func(k8sClientSet *k8sfakes.Clientset, kpackClientSet *kpackfakes.Clientset) *cobra.Command {
+		clientSetProvider := testhelpers.GetFakeClusterProvider(k8sClientSet, kpackClientSet)
+		return storecmds.NewRefreshCommand(clientSetProvider, fakeRegistryUtilProvider, func(dynamic.Interface) commands.ResourceWaiter {
+			return fakeWaiter
+		})
+	}
+
+	it("relocates the buildpackages whose upstream tag has moved", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				existingStore,
+			},
+			Args: []string{"store-name"},
+			ExpectPatches: []string{
+				`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:new-buildpack-digest\":\"some-registry.io/repo/some-buildpack\",\"default-registry.io/default-repo@sha256:other-buildpack-digest\":\"some-registry.io/repo/other-buildpack\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:new-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:other-buildpack-digest"},{"image":"default-registry.io/default-repo@sha256:local-buildpack-digest"}]}}`,
+			},
+			ExpectedOutput: `Refreshing ClusterStore...
+	'some-registry.io/repo/some-buildpack' has moved
+	Uploading 'default-registry.io/default-repo@sha256:new-buildpack-digest'
+	'some-registry.io/repo/other-buildpack' is up to date
+ClusterStore "store-name" updated
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+		require.Len(t, fakeWaiter.WaitCalls, 1)
+	})
+
+	it("reports no change when every upstream tag is up to date", func() {
+		fakeFetcher.AddBuildpackImages(registryfakes.BuildpackImgInfo{
+			Id: "some-buildpack-id",
+			ImageInfo: registryfakes.ImageInfo{
+				Ref:    "some-registry.io/repo/some-buildpack",
+				Digest: "old-buildpack-digest",
+			},
+		})
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				existingStore,
+			},
+			Args: []string{"store-name"},
+			ExpectedOutput: `Refreshing ClusterStore...
+	'some-registry.io/repo/some-buildpack' is up to date
+	'some-registry.io/repo/other-buildpack' is up to date
+ClusterStore "store-name" updated (no change)
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+		require.Len(t, fakeWaiter.WaitCalls, 0)
+	})
+
+	it("leaves stores without upstream tags unchanged", func() {
+		store := existingStore.DeepCopy()
+		store.Annotations = nil
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				store,
+			},
+			Args: []string{"store-name"},
+			ExpectedOutput: `Refreshing ClusterStore...
+ClusterStore "store-name" updated (no change)
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("does not patch the store with --dry-run", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+				existingStore,
+			},
+			Args: []string{"store-name", "--dry-run"},
+			ExpectedOutput: `Refreshing ClusterStore... (dry run)
+	'some-registry.io/repo/some-buildpack' has moved
+	Skipping 'default-registry.io/default-repo@sha256:new-buildpack-digest'
+	'some-registry.io/repo/other-buildpack' is up to date
+ClusterStore "store-name" updated (dry run)
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+		require.Len(t, fakeWaiter.WaitCalls, 0)
+	})
+
+	it("fails when the store does not exist", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				config,
+			},
+			Args:                []string{"store-name"},
+			ExpectErr:           true,
+			ExpectedErrorOutput: "Error: ClusterStore 'store-name' does not exist\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+}
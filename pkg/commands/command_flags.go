@@ -13,12 +13,26 @@ import (
 )
 
 const (
-	caCertPathFlag  = "registry-ca-cert-path"
-	verifyCertsFlag = "registry-verify-certs"
+	caCertPathFlag   = "registry-ca-cert-path"
+	verifyCertsFlag  = "registry-verify-certs"
+	tlsConfigFlag    = "registry-tls-config"
+	parallelismFlag  = "parallelism"
+	alwaysTagFlag    = "always-tag"
+	retriesFlag      = "registry-retries"
+	retryDelayFlag   = "registry-retry-max-delay"
+	useGitignoreFlag = "use-gitignore"
 
-	caCertPathFlagUsage  = "add CA certificate for registry API (format: /tmp/ca.crt)"
-	verifyCertsFlagUsage = "set whether to verify server's certificate chain and host name"
-	dryRunUsage          = `perform validation with no side-effects; no objects are sent to the server.
+	defaultParallelism = 4
+
+	caCertPathFlagUsage   = "add CA certificate for registry API (format: /tmp/ca.crt)"
+	verifyCertsFlagUsage  = "set whether to verify server's certificate chain and host name"
+	tlsConfigFlagUsage    = "path to a file with the CA certificate, client certificate and key, and verification mode of individual registries (format: /tmp/registries.yaml)"
+	parallelismFlagUsage  = "number of images to upload concurrently"
+	alwaysTagFlagUsage    = "upload and tag images even if their digest is already present in the default repository"
+	retriesFlagUsage      = "number of attempts for registry requests that fail with a 5xx, 429, or connection error"
+	retryDelayFlagUsage   = "maximum delay between attempts of a registry request"
+	useGitignoreFlagUsage = "leave out the files ignored by the .gitignore of the local path when it has no .kpignore"
+	dryRunUsage           = `perform validation with no side-effects; no objects are sent to the server.
   The --dry-run flag can be used in combination with the --output flag to
   view the Kubernetes resource(s) without sending anything to the server.`
 	dryRunImgUploadUsage = `similar to --dry-run, but with container image uploads allowed.
@@ -35,6 +49,43 @@ var outputUsage = fmt.Sprintf(`print Kubernetes resources in the specified forma
 func SetTLSFlags(cmd *cobra.Command, cfg *registry.TLSConfig) {
 	cmd.Flags().StringVar(&cfg.CaCertPath, caCertPathFlag, "", caCertPathFlagUsage)
 	cmd.Flags().BoolVar(&cfg.VerifyCerts, verifyCertsFlag, true, verifyCertsFlagUsage)
+	cmd.Flags().StringVar(&cfg.ConfigPath, tlsConfigFlag, "", tlsConfigFlagUsage)
+}
+
+// SetRetryFlags registers the registry retry flags and reports retried fetches
+// to the command's status output, which depends on the flags the command is
+// run with. It must be called after the command's PreRunE is set.
+func SetRetryFlags(cmd *cobra.Command, cfg *registry.RetryConfig) {
+	cmd.Flags().IntVar(&cfg.Attempts, retriesFlag, registry.DefaultRetryAttempts, retriesFlagUsage)
+	cmd.Flags().DurationVar(&cfg.MaxDelay, retryDelayFlag, registry.DefaultRetryMaxDelay, retryDelayFlagUsage)
+
+	preRunE := cmd.PreRunE
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if preRunE != nil {
+			if err := preRunE(cmd, args); err != nil {
+				return err
+			}
+		}
+
+		ch, err := NewCommandHelper(cmd)
+		if err != nil {
+			return err
+		}
+		cfg.Writer = ch.Writer()
+		return nil
+	}
+}
+
+func SetParallelismFlag(cmd *cobra.Command, parallelism *int) {
+	cmd.Flags().IntVar(parallelism, parallelismFlag, defaultParallelism, parallelismFlagUsage)
+}
+
+func SetAlwaysTagFlag(cmd *cobra.Command, alwaysTag *bool) {
+	cmd.Flags().BoolVar(alwaysTag, alwaysTagFlag, false, alwaysTagFlagUsage)
+}
+
+func SetUseGitignoreFlag(cmd *cobra.Command, useGitignore *bool) {
+	cmd.Flags().BoolVar(useGitignore, useGitignoreFlag, false, useGitignoreFlagUsage)
 }
 
 func SetDryRunOutputFlags(cmd *cobra.Command) {
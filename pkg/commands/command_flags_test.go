@@ -0,0 +1,70 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package commands_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func TestCommandFlags(t *testing.T) {
+	spec.Run(t, "TestCommandFlags", testCommandFlags)
+}
+
+func testCommandFlags(t *testing.T, when spec.G, it spec.S) {
+	when("SetRetryFlags", func() {
+		var (
+			retryCfg  registry.RetryConfig
+			preRunRan bool
+			cmd       *cobra.Command
+			out       *bytes.Buffer
+			errOut    *bytes.Buffer
+		)
+
+		it.Before(func() {
+			retryCfg = registry.RetryConfig{}
+			preRunRan = false
+
+			cmd = &cobra.Command{
+				PreRunE: func(cmd *cobra.Command, args []string) error {
+					preRunRan = true
+					return nil
+				},
+				RunE: func(cmd *cobra.Command, args []string) error {
+					_, err := retryCfg.Writer.Write([]byte("retrying\n"))
+					return err
+				},
+			}
+			commands.SetDryRunOutputFlags(cmd)
+			commands.SetRetryFlags(cmd, &retryCfg)
+
+			out, errOut = &bytes.Buffer{}, &bytes.Buffer{}
+			cmd.SetOut(out)
+			cmd.SetErr(errOut)
+		})
+
+		it("runs the existing pre run and sets the retry attempts", func() {
+			cmd.SetArgs([]string{"--registry-retries", "2"})
+			require.NoError(t, cmd.Execute())
+
+			require.True(t, preRunRan)
+			require.Equal(t, 2, retryCfg.Attempts)
+		})
+
+		it("writes retries to stderr when the output format is set", func() {
+			cmd.SetArgs([]string{"--output", "yaml"})
+			require.NoError(t, cmd.Execute())
+
+			require.Empty(t, out.String())
+			require.Equal(t, "retrying\n", errOut.String())
+		})
+	})
+}
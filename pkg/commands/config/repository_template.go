@@ -0,0 +1,76 @@
+package config
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+)
+
+func NewRepositoryTemplateCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
+	var reset bool
+
+	cmd := &cobra.Command{
+		Use:   "repository-template <kind> [template]",
+		Short: "Set or Get the repository template for an image kind",
+		Long: `Set or Get the repository template for an image kind
+
+Repository templates decide which repository relocated images are uploaded to.
+The kind must be one of "lifecycle", "build-image", "run-image", or "buildpackage".
+
+Templates use Go template syntax and can reference:
+  {{.Repo}}         the default repository
+  {{.Name}}         the ClusterStack name (build-image and run-image only)
+  {{.BuildpackID}}  the id of the buildpackage (buildpackage only)
+
+By default all images are uploaded to "{{.Repo}}", except that "kp lifecycle patch" uploads the lifecycle to "{{.Repo}}/lifecycle".
+
+This data is stored in the "repository.template.<kind>" keys of the kp-config config map in the kpack namespace.
+`,
+		Example: `kp config repository-template buildpackage
+kp config repository-template buildpackage "{{.Repo}}/buildpacks/{{.BuildpackID}}"
+kp config repository-template run-image "{{.Repo}}/stacks/{{.Name}}/run"
+kp config repository-template buildpackage --reset`,
+		Args:         commands.RangeArgsWithUsage(1, 2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			ch, err := commands.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			kind, err := config.ParseImageKind(args[0])
+			if err != nil {
+				return err
+			}
+
+			cs, err := clientSetProvider.GetClientSet("")
+			if err != nil {
+				return err
+			}
+
+			configHelper := config.NewKpConfigProvider(cs.K8sClient)
+
+			if len(args) == 1 && !reset {
+				return ch.Printlnf("%s", configHelper.GetKpConfig(ctx).RepositoryTemplate(kind))
+			}
+
+			text := ""
+			if len(args) == 2 {
+				text = args[1]
+			}
+
+			if err = configHelper.SetRepositoryTemplate(ctx, kind, text); err != nil {
+				return err
+			}
+
+			return ch.Printlnf("kp-config set")
+		},
+	}
+
+	cmd.Flags().BoolVar(&reset, "reset", false, "restore the default template for the kind")
+	return cmd
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"testing"
+
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRepositoryTemplateCommand(t *testing.T) {
+	spec.Run(t, "TestRepositoryTemplateCommand", testRepositoryTemplateCommand)
+}
+
+func testRepositoryTemplateCommand(t *testing.T, when spec.G, it spec.S) {
+	cmdFunc := func(k8sClientSet *k8sfakes.Clientset, _ *kpackfakes.Clientset) *cobra.Command {
+		return NewRepositoryTemplateCommand(testhelpers.GetFakeClusterProvider(k8sClientSet, nil))
+	}
+
+	kpConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kp-config",
+			Namespace: "kpack",
+		},
+		Data: map[string]string{
+			"default.repository":                "test-repo",
+			"repository.template.buildpackage":  "{{.Repo}}/buildpacks/{{.BuildpackID}}",
+			"default.repository.serviceaccount": "default",
+		},
+	}
+
+	when("running command with only a kind", func() {
+		it("prints the configured template", func() {
+			testhelpers.CommandTest{
+				Objects:        []runtime.Object{kpConfig},
+				Args:           []string{"buildpackage"},
+				ExpectedOutput: "{{.Repo}}/buildpacks/{{.BuildpackID}}\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("prints the default template when none is configured", func() {
+			testhelpers.CommandTest{
+				Objects:        []runtime.Object{kpConfig},
+				Args:           []string{"lifecycle"},
+				ExpectedOutput: "{{.Repo}}\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors for an unknown kind", func() {
+			testhelpers.CommandTest{
+				Objects:             []runtime.Object{kpConfig},
+				Args:                []string{"builder"},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: unknown image kind 'builder', must be one of build-image, buildpackage, lifecycle, run-image\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("setting a template", func() {
+		it("updates the config map", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{kpConfig},
+				Args:    []string{"run-image", "{{.Repo}}/stacks/{{.Name}}/run"},
+				ExpectPatches: []string{
+					`{"data":{"repository.template.run-image":"{{.Repo}}/stacks/{{.Name}}/run"}}`,
+				},
+				ExpectedOutput: "kp-config set\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("removes the template when reset", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{kpConfig},
+				Args:    []string{"buildpackage", "--reset"},
+				ExpectPatches: []string{
+					`{"data":{"repository.template.buildpackage":null}}`,
+				},
+				ExpectedOutput: "kp-config set\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors for a template that does not render a repository", func() {
+			testhelpers.CommandTest{
+				Objects:             []runtime.Object{kpConfig},
+				Args:                []string{"buildpackage", "{{.Repo}}/{{.Stack}}"},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: invalid buildpackage repository template: template: buildpackage:1:12: executing \"buildpackage\" at <.Stack>: can't evaluate field Stack in type config.RepositoryValues\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+}
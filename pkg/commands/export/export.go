@@ -0,0 +1,61 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package export
+
+import (
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+)
+
+func NewExportCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
+	var filename string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a dependency descriptor from the cluster",
+		Long: `Generate a dependency descriptor from the lifecycle, clusterstores, clusterstacks, and clusterbuilders in the cluster.
+
+The "default" clusterstack and clusterbuilder are written as defaultClusterStack and defaultClusterBuilder
+when they mirror another clusterstack or clusterbuilder.
+
+The resulting descriptor can be used with "kp import" to recreate the same dependencies in another cluster.`,
+		Example: `kp export
+kp export -f dependencies.yaml`,
+		Args:         commands.ExactArgsWithUsage(0),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cs, err := clientSetProvider.GetClientSet("")
+			if err != nil {
+				return err
+			}
+
+			exporter := importpkg.NewExporter(cs.K8sClient, cs.KpackClient)
+
+			descriptor, err := exporter.ExportDescriptor(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			data, err := yaml.Marshal(descriptor)
+			if err != nil {
+				return err
+			}
+
+			if filename == "" {
+				_, err = cmd.OutOrStdout().Write(data)
+				return err
+			}
+
+			return ioutil.WriteFile(filename, data, 0644)
+		},
+	}
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "dependency descriptor filename to write (default stdout)")
+	return cmd
+}
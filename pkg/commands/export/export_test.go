@@ -0,0 +1,183 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package export_test
+
+import (
+	"testing"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	exportcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/export"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+)
+
+func TestExportCommand(t *testing.T) {
+	spec.Run(t, "TestExportCommand", testExportCommand)
+}
+
+func testExportCommand(t *testing.T, when spec.G, it spec.S) {
+	lifecycleImageConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "lifecycle-image",
+			Namespace: "kpack",
+		},
+		Data: map[string]string{
+			"image": "default-registry.io/default-repo/lifecycle@sha256:1111111111111111111111111111111111111111111111111111111111111111",
+		},
+	}
+
+	store := &v1alpha2.ClusterStore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "store-name",
+		},
+		Spec: v1alpha2.ClusterStoreSpec{
+			Sources: []corev1alpha1.StoreImage{
+				{Image: "default-registry.io/default-repo@sha256:2222222222222222222222222222222222222222222222222222222222222222"},
+			},
+		},
+	}
+
+	stack := &v1alpha2.ClusterStack{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "stack-name",
+		},
+		Spec: v1alpha2.ClusterStackSpec{
+			Id: "stack-id",
+			BuildImage: v1alpha2.ClusterStackSpecImage{
+				Image: "default-registry.io/default-repo@sha256:3333333333333333333333333333333333333333333333333333333333333333",
+			},
+			RunImage: v1alpha2.ClusterStackSpecImage{
+				Image: "default-registry.io/default-repo@sha256:4444444444444444444444444444444444444444444444444444444444444444",
+			},
+		},
+	}
+
+	defaultStack := stack.DeepCopy()
+	defaultStack.Name = "default"
+
+	builder := &v1alpha2.ClusterBuilder{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "clusterbuilder-name",
+		},
+		Spec: v1alpha2.ClusterBuilderSpec{
+			BuilderSpec: v1alpha2.BuilderSpec{
+				Tag: "default-registry.io/default-repo:clusterbuilder-clusterbuilder-name",
+				Stack: corev1.ObjectReference{
+					Name: "stack-name",
+					Kind: v1alpha2.ClusterStackKind,
+				},
+				Store: corev1.ObjectReference{
+					Name: "store-name",
+					Kind: v1alpha2.ClusterStoreKind,
+				},
+				Order: []corev1alpha1.OrderEntry{
+					{
+						Group: []corev1alpha1.BuildpackRef{
+							{
+								BuildpackInfo: corev1alpha1.BuildpackInfo{
+									Id: "buildpack-id",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	defaultBuilder := builder.DeepCopy()
+	defaultBuilder.Name = "default"
+	defaultBuilder.Spec.Tag = "default-registry.io/default-repo:clusterbuilder-default"
+
+	cmdFunc := func(k8sClientSet *k8sfakes.Clientset, kpackClientSet *kpackfakes.Clientset) *cobra.Command {
+		clientSetProvider := testhelpers.GetFakeClusterProvider(k8sClientSet, kpackClientSet)
+		return exportcmds.NewExportCommand(clientSetProvider)
+	}
+
+	it("writes a descriptor with defaults for mirrored default resources", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				lifecycleImageConfig,
+				store,
+				stack,
+				defaultStack,
+				builder,
+				defaultBuilder,
+			},
+			ExpectedOutput: `apiVersion: kp.kpack.io/v1alpha4
+clusterBuilders:
+- clusterStack: stack-name
+  clusterStore: store-name
+  name: clusterbuilder-name
+  order:
+  - group:
+    - id: buildpack-id
+clusterStacks:
+- buildImage:
+    image: default-registry.io/default-repo@sha256:3333333333333333333333333333333333333333333333333333333333333333
+  name: stack-name
+  runImage:
+    image: default-registry.io/default-repo@sha256:4444444444444444444444444444444444444444444444444444444444444444
+clusterStores:
+- name: store-name
+  sources:
+  - image: default-registry.io/default-repo@sha256:2222222222222222222222222222222222222222222222222222222222222222
+defaultClusterBuilder: clusterbuilder-name
+defaultClusterStack: stack-name
+kind: DependencyDescriptor
+lifecycle:
+  image: default-registry.io/default-repo/lifecycle@sha256:1111111111111111111111111111111111111111111111111111111111111111
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("keeps default resources that do not mirror a named resource", func() {
+		defaultStack := defaultStack.DeepCopy()
+		defaultStack.Spec.RunImage.Image = "default-registry.io/default-repo@sha256:5555555555555555555555555555555555555555555555555555555555555555"
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				lifecycleImageConfig,
+				stack,
+				defaultStack,
+			},
+			ExpectedOutput: `apiVersion: kp.kpack.io/v1alpha4
+clusterBuilders: []
+clusterStacks:
+- buildImage:
+    image: default-registry.io/default-repo@sha256:3333333333333333333333333333333333333333333333333333333333333333
+  name: stack-name
+  runImage:
+    image: default-registry.io/default-repo@sha256:4444444444444444444444444444444444444444444444444444444444444444
+- buildImage:
+    image: default-registry.io/default-repo@sha256:3333333333333333333333333333333333333333333333333333333333333333
+  name: default
+  runImage:
+    image: default-registry.io/default-repo@sha256:5555555555555555555555555555555555555555555555555555555555555555
+clusterStores: []
+kind: DependencyDescriptor
+lifecycle:
+  image: default-registry.io/default-repo/lifecycle@sha256:1111111111111111111111111111111111111111111111111111111111111111
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("fails when the lifecycle configmap does not exist", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{
+				store,
+			},
+			ExpectErr:           true,
+			ExpectedErrorOutput: "Error: configmap \"lifecycle-image\" not found in \"kpack\" namespace\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+}
@@ -20,10 +20,12 @@ import (
 
 func NewPatchCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilProvider, newImageWaiter func(k8s.ClientSet) ImageWaiter) *cobra.Command {
 	var (
-		namespace string
-		subPath   string
-		factory   image.Factory
-		tlsCfg    registry.TLSConfig
+		namespace    string
+		subPath      string
+		factory      image.Factory
+		tlsCfg       registry.TLSConfig
+		retryCfg     registry.RetryConfig
+		useGitignore bool
 	)
 
 	cmd := &cobra.Command{
@@ -42,6 +44,9 @@ The flags for this command determine how the build will retrieve source code:
 
 Local source code will be pushed to the same registry as the existing image resource tag.
 Therefore, you must have credentials to access the registry on your machine.
+Files matching the patterns of a .kpignore file, in gitignore syntax, at the root of the local path are not pushed.
+With "--use-gitignore", the .gitignore file is used when there is no .kpignore.
+With "--dry-run", the files that would be pushed are listed instead.
 
 Environment variables may be provided by using the "--env" flag or deleted by using the "--delete-env" flag.
 For each environment variable, supply the "--env" flag followed by the key value pair.
@@ -79,7 +84,7 @@ kp image patch my-image --tag my-registry.com/my-repo --blob https://my-blob-hos
 				return err
 			}
 
-			factory.SourceUploader = rup.SourceUploader(ch.Writer(), tlsCfg, ch.CanChangeState())
+			factory.SourceUploader = rup.SourceUploader(ch.Writer(), tlsCfg, retryCfg, ch.CanChangeState(), useGitignore)
 			factory.Printer = ch
 
 			if cmd.Flag("sub-path").Changed {
@@ -107,7 +112,8 @@ kp image patch my-image --tag my-registry.com/my-repo --blob https://my-blob-hos
 	cmd.Flags().StringVar(&factory.GitRepo, "git", "", "git repository url")
 	cmd.Flags().StringVar(&factory.GitRevision, "git-revision", "", "git revision such as commit, tag, or branch (default \"main\")")
 	cmd.Flags().StringVar(&factory.Blob, "blob", "", "source code blob url")
-	cmd.Flags().StringVar(&factory.LocalPath, "local-path", "", "path to local source code")
+	cmd.Flags().StringVar(&factory.LocalPath, "local-path", "", "path to local source code: a directory, zip, jar, war or tar (optionally gzip or zstd compressed)")
+	commands.SetUseGitignoreFlag(cmd, &useGitignore)
 	cmd.Flags().StringVar(&subPath, "sub-path", "", "build code at the sub path located within the source code directory")
 	cmd.Flags().StringVar(&factory.Builder, "builder", "", "builder name")
 	cmd.Flags().StringVar(&factory.ClusterBuilder, "cluster-builder", "", "cluster builder name")
@@ -120,6 +126,7 @@ kp image patch my-image --tag my-registry.com/my-repo --blob https://my-blob-hos
 	cmd.Flags().BoolP("wait", "w", false, "wait for image resource patch to be reconciled and tail resulting build logs")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
 	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
 	return cmd
 }
 
@@ -0,0 +1,88 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func NewConvertCommand(rup registry.UtilProvider) *cobra.Command {
+	var (
+		filename    string
+		outputFile  string
+		tlsConfig   registry.TLSConfig
+		retryConfig registry.RetryConfig
+	)
+
+	cmd := &cobra.Command{
+		Use:   "convert -f <filename>",
+		Short: "Convert a dependency descriptor to the current apiVersion",
+		Long: fmt.Sprintf(`Convert a dependency descriptor of any supported apiVersion to %s.
+
+The field order and comments of the descriptor are kept. Every change made by the conversion, and every
+change in how kp import reads the descriptor, is reported.
+
+The converted descriptor is written to stdout, or to --output-file when provided, and the changes are
+reported on stderr.`, importpkg.CurrentAPIVersion),
+		Example: `kp import convert -f dependencies.yaml
+kp import convert -f dependencies.yaml --output-file dependencies.yaml`,
+		Args:         commands.ExactArgsWithUsage(0),
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if filename == "" {
+				return fmt.Errorf("required flag(s) \"filename\" not set\n\n%s", cmd.UsageString())
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			loader := newDescriptorLoader(cmd, rup.Fetcher(tlsConfig, retryConfig), tlsConfig)
+
+			rawDescriptor, err := loader.Load(filename)
+			if err != nil {
+				return err
+			}
+
+			converted, err := importpkg.ConvertDescriptor(rawDescriptor)
+			if err != nil {
+				return err
+			}
+
+			if converted.From == converted.To {
+				if _, err := fmt.Fprintf(cmd.ErrOrStderr(), "Descriptor is already at %s\n", converted.To); err != nil {
+					return err
+				}
+			}
+
+			for _, note := range converted.Notes {
+				if _, err := fmt.Fprintln(cmd.ErrOrStderr(), note); err != nil {
+					return err
+				}
+			}
+
+			if outputFile == "" {
+				_, err := fmt.Fprint(cmd.OutOrStdout(), converted.Descriptor)
+				return err
+			}
+
+			if err := ioutil.WriteFile(outputFile, []byte(converted.Descriptor), 0644); err != nil {
+				return err
+			}
+
+			_, err = fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %s descriptor '%s'\n", converted.To, outputFile)
+			return err
+		},
+	}
+	cmd.Flags().StringVarP(&filename, "filename", "f", "", "dependency descriptor filename, https url, or OCI artifact")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "write the converted descriptor to this file instead of stdout")
+	commands.SetTLSFlags(cmd, &tlsConfig)
+	commands.SetRetryFlags(cmd, &retryConfig)
+	return cmd
+}
@@ -0,0 +1,167 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	importcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/import"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+)
+
+func TestConvertCommand(t *testing.T) {
+	spec.Run(t, "TestConvertCommand", testConvertCommand)
+}
+
+func testConvertCommand(t *testing.T, when spec.G, it spec.S) {
+	fakeUtilProvider := &registryfakes.UtilProvider{
+		FakeFetcher: &registryfakes.Fetcher{},
+	}
+
+	cmdFunc := func(k8sClientSet *k8sfakes.Clientset, kpackClientSet *kpackfakes.Clientset) *cobra.Command {
+		return importcmds.NewConvertCommand(fakeUtilProvider)
+	}
+
+	const converted = `# dependencies for the build platform
+apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+defaultClusterBuilder: clusterbuilder-name
+defaultClusterStack: stack-name # the only stack
+clusterStores:
+- name: store-name
+  sources:
+  - image: some-registry.io/repo/buildpack-image
+clusterStacks:
+- name: stack-name
+  buildImage:
+    image: some-registry.io/repo/build-image
+  runImage:
+    image: some-registry.io/repo/run-image
+clusterBuilders:
+- name: clusterbuilder-name
+  clusterStack: stack-name
+  clusterStore: store-name
+  order:
+  - group:
+    - id: buildpack-id
+`
+
+	const notes = `renamed 'defaultStack' to 'defaultClusterStack': ClusterStack 'default' is still created from stack 'stack-name'
+kept 'defaultClusterBuilder': ClusterBuilder 'default' is still created from clusterBuilder 'clusterbuilder-name'
+renamed 'stores' to 'clusterStores'
+renamed 'stacks' to 'clusterStacks'
+renamed 'stack' and 'store' to 'clusterStack' and 'clusterStore' in clusterBuilders
+`
+
+	it("writes the converted descriptor to stdout and the changes to stderr", func() {
+		testhelpers.CommandTest{
+			Args:                []string{"-f", "./testdata/v1-commented-deps.yaml"},
+			ExpectedOutput:      converted,
+			ExpectedErrorOutput: notes,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("writes the converted descriptor to the output file", func() {
+		dir, err := ioutil.TempDir("", "convert")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		outputFile := filepath.Join(dir, "deps.yaml")
+
+		testhelpers.CommandTest{
+			Args:                []string{"-f", "./testdata/v1-commented-deps.yaml", "--output-file", outputFile},
+			ExpectedErrorOutput: notes + "Wrote kp.kpack.io/v1alpha4 descriptor '" + outputFile + "'\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+
+		contents, err := ioutil.ReadFile(outputFile)
+		require.NoError(t, err)
+		require.Equal(t, converted, string(contents))
+	})
+
+	it("reads the descriptor from stdin", func() {
+		contents, err := ioutil.ReadFile("./testdata/v1-commented-deps.yaml")
+		require.NoError(t, err)
+
+		testhelpers.CommandTest{
+			Args:                []string{"-f", "-"},
+			StdIn:               string(contents),
+			ExpectedOutput:      converted,
+			ExpectedErrorOutput: notes,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("reads https descriptors with the registry ca certificate", func() {
+		contents, err := ioutil.ReadFile("./testdata/namespaced-deps.yaml")
+		require.NoError(t, err)
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write(contents)
+		}))
+		defer server.Close()
+
+		dir, err := ioutil.TempDir("", "convert")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		caCertPath := filepath.Join(dir, "ca.crt")
+		caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		require.NoError(t, ioutil.WriteFile(caCertPath, caCert, 0644))
+
+		testhelpers.CommandTest{
+			Args:                []string{"-f", server.URL + "/deps.yaml", "--registry-ca-cert-path", caCertPath},
+			ExpectedOutput:      string(contents),
+			ExpectedErrorOutput: "Descriptor is already at kp.kpack.io/v1alpha4\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("leaves current descriptors unchanged", func() {
+		contents, err := ioutil.ReadFile("./testdata/namespaced-deps.yaml")
+		require.NoError(t, err)
+
+		testhelpers.CommandTest{
+			Args:                []string{"-f", "./testdata/namespaced-deps.yaml"},
+			ExpectedOutput:      string(contents),
+			ExpectedErrorOutput: "Descriptor is already at kp.kpack.io/v1alpha4\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("writes current descriptors to the output file", func() {
+		contents, err := ioutil.ReadFile("./testdata/namespaced-deps.yaml")
+		require.NoError(t, err)
+
+		dir, err := ioutil.TempDir("", "convert")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+		outputFile := filepath.Join(dir, "deps.yaml")
+
+		testhelpers.CommandTest{
+			Args: []string{"-f", "./testdata/namespaced-deps.yaml", "--output-file", outputFile},
+			ExpectedErrorOutput: "Descriptor is already at kp.kpack.io/v1alpha4\n" +
+				"Wrote kp.kpack.io/v1alpha4 descriptor '" + outputFile + "'\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+
+		written, err := ioutil.ReadFile(outputFile)
+		require.NoError(t, err)
+		require.Equal(t, string(contents), string(written))
+	})
+
+	it("fails for invalid descriptors", func() {
+		testhelpers.CommandTest{
+			Args:                []string{"-f", "./testdata/invalid-deps.yaml"},
+			ExpectErr:           true,
+			ExpectedErrorOutput: "Error: did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3 kp.kpack.io/v1alpha4]\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+}
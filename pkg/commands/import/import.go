@@ -4,11 +4,14 @@
 package _import
 
 import (
-	"io"
-	"io/ioutil"
+	"bytes"
+	"fmt"
+	"net/http"
 	"os"
 
+	"github.com/ghodss/yaml"
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
@@ -33,10 +36,22 @@ func NewImportCommand(
 	newWaiter func(dynamic.Interface) commands.ResourceWaiter) *cobra.Command {
 
 	var (
-		filename    string
+		filenames   []string
 		showChanges bool
+		changesOut  string
+		lockfile    string
+		writeLock   bool
+		locked      bool
+		reportFile  string
 		force       bool
+		writeBundle string
+		fromBundle  string
+		prune       bool
+		forcePrune  bool
+		parallelism int
+		alwaysTag   bool
 		tlsConfig   registry.TLSConfig
+		retryConfig registry.RetryConfig
 	)
 
 	const (
@@ -50,16 +65,114 @@ func NewImportCommand(
 	}
 
 	cmd := &cobra.Command{
-		Use:   "import -f <filename>",
+		Use:   "import -f <filename>...",
 		Short: "Import dependencies for stores, stacks, and cluster builders",
 		Long: `This operation will create or update clusterstores, clusterstacks, and clusterbuilders defined in the dependency descriptor.
+Descriptors with apiVersion kp.kpack.io/v1alpha4 can also define namespaced builders and images, which are created or
+updated after the clusterbuilders. Images are not waited on to build.
 
-kp import will always attempt to upload the stack, store, and builder images, even if the resources have not changed.
-This can be used as a way to repair resources when registry images have been unexpectedly removed.`,
+kp import checks the stack, store, and builder images even if the resources have not changed, and uploads any image
+whose digest is missing from the destination repository. This can be used as a way to repair resources when registry
+images have been unexpectedly removed. Use --always-tag to upload and tag every image regardless.
+
+A descriptor can be read from a local file, from stdin with "-", from an https URL, or from an OCI artifact
+published with "kp import push". OCI artifacts must be fully qualified, such as registry.example.com/deps:1.0.0,
+and are fetched with the same credentials and registry flags as other images.
+
+The --filename flag can be repeated to merge several descriptors, for example a base descriptor and per-environment
+overlays. Each descriptor is merged over the ones before it by resource name: clusterstores append the sources they
+do not already have, or replace them when the later clusterstore sets "replaceSources: true"; clusterstacks replace
+the images that are set; clusterbuilders replace the stack, store, and order that are set; builders and images,
+matched by namespace and name, replace the fields that are set. The default clusterstack,
+default clusterbuilder, and lifecycle are replaced when set. Every descriptor must match the schema of its
+apiVersion, but only the merged descriptor needs to be complete, and --show-changes prints it before the summary of
+changes. Use "kp import validate" to check descriptors without a cluster.
+
+Use --write-lockfile to record, for every image the descriptor references, the digest it resolved to and the image it
+was relocated to. The lockfile is written next to the last local descriptor, such as dependencies.lock.yaml for
+dependencies.yaml, or to --lockfile. Importing with --locked then fetches exactly the locked digests and fails if the
+lockfile is missing or does not match the images of the descriptor.
+
+Use --changes-output json or --changes-output yaml to print the changes as a plan instead of importing. The plan has
+one entry per lifecycle, clusterstore, clusterstack, clusterbuilder, builder, and image with its kind, namespace, name,
+action (create, update, noop, or delete), and old and new values such as the lifecycle image, store sources, stack
+images, builder order, and image source. It is computed from the same diffs as --show-changes, so it can be used to
+gate imports in automation.
+
+Images whose digest is already present in the default repository are reused without being uploaded or tagged again;
+use --always-tag to upload and tag them with a new timestamp tag anyway.
+
+After importing, a summary table lists every resource with its action (created, updated, or unchanged), the images
+uploaded for it and already present in the default repository, the bytes uploaded, and the time spent waiting for it
+to become ready, followed by the totals and the time spent relocating images. Use --report-file to also write the
+report as json.
+
+If creating or updating any resource fails, every change already made by the import is rolled back: updated resources
+and the lifecycle image are restored to their previous state and newly created resources are deleted.
+
+For clusters without access to the source registries, use --write-bundle to save the descriptor and every image it
+references to a single OCI layout tarball. The tarball can then be imported with --from-bundle, which relocates the
+images from the bundle into the default repository instead of fetching them from their source registries.
+
+With --prune, clusterstores, clusterstacks, and clusterbuilders previously created by kp import that are no longer
+listed in the dependency descriptor are deleted after the import. Resources still used by images, builders, or
+remaining clusterbuilders are not pruned unless --force-prune is provided.
+
+Registries that need their own CA certificate, client certificate, or verification mode can be listed in a file passed
+with --registry-tls-config. Registries that are not listed use --registry-ca-cert-path and --registry-verify-certs.
+Relative paths are resolved against the directory of the file:
+
+  registries:
+    mirror.internal.example.com:
+      caCertPath: mirror-ca.crt
+      clientCertPath: client.crt
+      clientKeyPath: client.key
+      verifyCerts: true`,
 		Example: `kp import -f dependencies.yaml
-cat dependencies.yaml | kp import -f -`,
+cat dependencies.yaml | kp import -f -
+kp import -f base.yaml -f prod.yaml --show-changes
+kp import -f https://example.com/releases/1.0.0/dependencies.yaml
+kp import -f registry.example.com/platform/dependencies:1.0.0
+kp import -f dependencies.yaml --prune --show-changes
+kp import -f dependencies.yaml --changes-output json
+kp import -f dependencies.yaml --write-lockfile
+kp import -f dependencies.yaml --locked
+kp import -f dependencies.yaml --report-file import-report.json
+kp import -f dependencies.yaml --write-bundle dependencies.tar
+kp import --from-bundle dependencies.tar`,
 		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if len(filenames) == 0 && fromBundle == "" {
+				return fmt.Errorf("required flag(s) \"filename\" or \"from-bundle\" not set\n\n%s", cmd.UsageString())
+			}
+			if len(filenames) > 0 && fromBundle != "" {
+				return errors.New("only one of --filename or --from-bundle may be provided")
+			}
+			if err := checkStdin(filenames); err != nil {
+				return err
+			}
+			if changesOut != "" && changesOut != "json" && changesOut != "yaml" {
+				return errors.Errorf("unsupported changes output '%s', must be one of: json, yaml", changesOut)
+			}
+			if changesOut != "" && writeBundle != "" {
+				return errors.New("--changes-output cannot be used with --write-bundle")
+			}
+			if writeLock && locked {
+				return errors.New("only one of --write-lockfile or --locked may be provided")
+			}
+			if locked && fromBundle != "" {
+				return errors.New("--locked cannot be used with --from-bundle")
+			}
+			if writeBundle != "" && fromBundle != "" {
+				return errors.New("--write-bundle cannot be used with --from-bundle")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if writeBundle != "" {
+				return writeDescriptorBundle(cmd, rup.Fetcher(tlsConfig, retryConfig), tlsConfig, filenames, writeBundle)
+			}
+
 			cs, err := clientSetProvider.GetClientSet("")
 			if err != nil {
 				return err
@@ -74,8 +187,47 @@ cat dependencies.yaml | kp import -f -`,
 
 			kpConfig := config.NewKpConfigProvider(cs.K8sClient).GetKpConfig(ctx)
 
-			imgFetcher := rup.Fetcher(tlsConfig)
-			imgRelocator := rup.Relocator(ch.Writer(), tlsConfig, ch.CanChangeState())
+			imgFetcher := rup.Fetcher(tlsConfig, retryConfig)
+			var rawDescriptor string
+			if fromBundle != "" {
+				bundle, err := registry.OpenBundle(fromBundle)
+				if err != nil {
+					return err
+				}
+				defer bundle.Close()
+
+				imgFetcher = bundle
+				rawDescriptor = string(bundle.Descriptor())
+			} else {
+				rawDescriptor, err = readDescriptors(cmd, imgFetcher, tlsConfig, filenames)
+				if err != nil {
+					return err
+				}
+			}
+
+			var imgRelocator registry.Relocator = registry.NewParallelRelocatorFromProvider(rup, ch.Writer(), tlsConfig, retryConfig, ch.CanChangeState(), alwaysTag, parallelism)
+
+			var (
+				lock         importpkg.Lockfile
+				lockRecorder *importpkg.LockRecorder
+				lockPath     string
+			)
+			if locked || writeLock {
+				lockPath, err = lockfilePath(filenames, lockfile)
+				if err != nil {
+					return err
+				}
+			}
+			if locked {
+				lock, err = importpkg.ReadLockfile(lockPath)
+				if err != nil {
+					return err
+				}
+				imgFetcher = importpkg.LockedFetcher{Fetcher: imgFetcher, Lockfile: lock}
+			} else if writeLock {
+				lockRecorder = importpkg.NewLockRecorder(imgFetcher, imgRelocator)
+				imgFetcher, imgRelocator = lockRecorder.Fetcher(), lockRecorder.Relocator()
+			}
 
 			importer := importpkg.NewImporter(
 				ch,
@@ -87,19 +239,49 @@ cat dependencies.yaml | kp import -f -`,
 				timestampProvider,
 			)
 
-			rawDescriptor, err := readDescriptor(cmd, filename)
+			descriptor, err := importer.ReadDescriptor(rawDescriptor)
 			if err != nil {
 				return err
 			}
 
-			descriptor, err := importer.ReadDescriptor(rawDescriptor)
-			if err != nil {
-				return err
+			if locked {
+				if err := lock.Check(descriptor); err != nil {
+					return err
+				}
+			}
+
+			var prunable importpkg.Prunable
+			if prune {
+				prunable, err = importer.FindPrunable(ctx, descriptor, forcePrune)
+				if err != nil {
+					return err
+				}
 			}
 
 			defaultKeychain := authn.DefaultKeychain
+			if changesOut != "" {
+				plan, err := importpkg.PlanChange(ctx, defaultKeychain, descriptor, prunable, kpConfig, importpkg.NewDefaultRelocatedImageProvider(imgFetcher), differ, cs)
+				if err != nil {
+					return err
+				}
+
+				buf, err := plan.Marshal(changesOut)
+				if err != nil {
+					return err
+				}
+
+				_, err = cmd.OutOrStdout().Write(append(bytes.TrimRight(buf, "\n"), '\n'))
+				return err
+			}
+
 			if showChanges {
-				hasChanges, summary, err := importpkg.SummarizeChange(ctx, defaultKeychain, descriptor, kpConfig, importpkg.NewDefaultRelocatedImageProvider(imgFetcher), differ, cs)
+				if len(filenames) > 1 {
+					if err := printEffectiveDescriptor(ch, descriptor); err != nil {
+						return err
+					}
+				}
+
+				hasChanges, summary, err := importpkg.SummarizeChange(ctx, defaultKeychain, descriptor, prunable, kpConfig, importpkg.NewDefaultRelocatedImageProvider(imgFetcher), differ, cs)
 				if err != nil {
 					return err
 				}
@@ -144,6 +326,34 @@ cat dependencies.yaml | kp import -f -`,
 				}
 			}
 
+			if ch.IsDryRun() {
+				err = importer.PruneDryRun(ctx, prunable)
+			} else {
+				err = importer.Prune(ctx, prunable)
+			}
+			if err != nil {
+				return err
+			}
+
+			if lockRecorder != nil && !ch.IsDryRun() {
+				if err := writeLockfile(ch, lockRecorder, descriptor, lockPath); err != nil {
+					return err
+				}
+			}
+
+			if !ch.IsDryRun() {
+				report := importer.Report()
+				if err := printImportReport(ch, report); err != nil {
+					return err
+				}
+
+				if reportFile != "" {
+					if err := writeImportReport(ch, report, reportFile); err != nil {
+						return err
+					}
+				}
+			}
+
 			if err := ch.PrintObjs(objs); err != nil {
 				return err
 			}
@@ -151,35 +361,182 @@ cat dependencies.yaml | kp import -f -`,
 			return ch.PrintResult("Imported resources")
 		},
 	}
-	cmd.Flags().StringVarP(&filename, "filename", "f", "", "dependency descriptor filename")
+	cmd.Flags().StringArrayVarP(&filenames, "filename", "f", []string{}, "dependency descriptor filename, https url, or OCI artifact, repeat to merge descriptors in order")
 	cmd.Flags().BoolVar(&showChanges, "show-changes", false, "show a summary of resource changes before importing")
+	cmd.Flags().StringVar(&changesOut, "changes-output", "", "print the changes as a json or yaml plan instead of importing (format: json, yaml)")
+	cmd.Flags().BoolVar(&writeLock, "write-lockfile", false, "write a lockfile pinning the digest of every image after importing")
+	cmd.Flags().BoolVar(&locked, "locked", false, "import the image digests pinned by the lockfile and fail if it is missing or stale")
+	cmd.Flags().StringVar(&lockfile, "lockfile", "", "lockfile path (default: next to the last local descriptor, such as dependencies.lock.yaml)")
+	cmd.Flags().StringVar(&reportFile, "report-file", "", "write a json report of the import to this file")
 	cmd.Flags().BoolVar(&force, "force", false, "import without confirmation when showing changes")
+	cmd.Flags().BoolVar(&prune, "prune", false, "delete imported clusterstores, clusterstacks, and clusterbuilders that are not in the dependency descriptor")
+	cmd.Flags().BoolVar(&forcePrune, "force-prune", false, "prune resources even if they are still in use")
+	cmd.Flags().StringVar(&writeBundle, "write-bundle", "", "write the descriptor and all referenced images to an OCI layout tarball instead of importing")
+	cmd.Flags().StringVar(&fromBundle, "from-bundle", "", "import the descriptor and images from an OCI layout tarball created with --write-bundle")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetParallelismFlag(cmd, &parallelism)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
 	commands.SetTLSFlags(cmd, &tlsConfig)
-	_ = cmd.MarkFlagRequired("filename")
+	commands.SetRetryFlags(cmd, &retryConfig)
 	return cmd
 }
 
-func readDescriptor(cmd *cobra.Command, filename string) (string, error) {
+func writeDescriptorBundle(cmd *cobra.Command, fetcher registry.Fetcher, tlsConfig registry.TLSConfig, filenames []string, path string) error {
+	ch, err := commands.NewCommandHelper(cmd)
+	if err != nil {
+		return err
+	}
+
+	rawDescriptor, err := readDescriptors(cmd, fetcher, tlsConfig, filenames)
+	if err != nil {
+		return err
+	}
+
+	if err := ch.PrintStatus("Fetching images..."); err != nil {
+		return err
+	}
+
+	writer := importpkg.NewBundleWriter(ch, fetcher)
+	if err := writer.WriteBundle(authn.DefaultKeychain, rawDescriptor, path); err != nil {
+		return err
+	}
+
+	return ch.PrintResult("Wrote bundle '%s'", path)
+}
+
+func lockfilePath(filenames []string, lockfile string) (string, error) {
+	if lockfile != "" {
+		return lockfile, nil
+	}
+
+	for i := len(filenames) - 1; i >= 0; i-- {
+		if fi, err := os.Stat(filenames[i]); err == nil && !fi.IsDir() {
+			return importpkg.DefaultLockfilePath(filenames[i]), nil
+		}
+	}
+	return "", errors.New("--lockfile is required when no descriptor is a local file")
+}
+
+func writeLockfile(ch *commands.CommandHelper, recorder *importpkg.LockRecorder, descriptor importpkg.DependencyDescriptor, path string) error {
+	lock, err := recorder.Lockfile(descriptor)
+	if err != nil {
+		return err
+	}
+
+	if err := ch.PrintStatus("Writing lockfile '%s'...", path); err != nil {
+		return err
+	}
+	return lock.Write(path)
+}
+
+func printEffectiveDescriptor(ch *commands.CommandHelper, descriptor importpkg.DependencyDescriptor) error {
+	buf, err := yaml.Marshal(descriptor)
+	if err != nil {
+		return err
+	}
+
+	return ch.Printlnf("Effective Descriptor\n\n%s", buf)
+}
+
+func validateFilenames(cmd *cobra.Command, filenames []string) error {
+	if len(filenames) == 0 {
+		return fmt.Errorf("required flag(s) \"filename\" not set\n\n%s", cmd.UsageString())
+	}
+	return checkStdin(filenames)
+}
+
+func checkStdin(filenames []string) error {
+	n := 0
+	for _, filename := range filenames {
+		if filename == "-" {
+			n++
+		}
+	}
+	if n > 1 {
+		return errors.New("--filename may only read from stdin once")
+	}
+	return nil
+}
+
+// newDescriptorLoader returns a loader that reads https descriptors with the
+// same TLS settings as the registry requests of the command.
+func newDescriptorLoader(cmd *cobra.Command, fetcher registry.Fetcher, tlsConfig registry.TLSConfig) importpkg.DescriptorLoader {
+	return importpkg.DescriptorLoader{
+		Stdin:      cmd.InOrStdin(),
+		HTTPClient: &http.Client{Transport: descriptorTransport{tlsConfig: tlsConfig}},
+		Fetcher:    fetcher,
+		Keychain:   authn.DefaultKeychain,
+	}
+}
+
+// descriptorTransport reads the certificates of the TLS config only when a
+// descriptor url is fetched, so they are not required for local descriptors.
+type descriptorTransport struct {
+	tlsConfig registry.TLSConfig
+}
+
+func (t descriptorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	transport, err := t.tlsConfig.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return transport.RoundTrip(req)
+}
+
+func readDescriptors(cmd *cobra.Command, fetcher registry.Fetcher, tlsConfig registry.TLSConfig, filenames []string) (string, error) {
+	loader := newDescriptorLoader(cmd, fetcher, tlsConfig)
+
 	var (
-		reader io.ReadCloser
-		err    error
+		rawDescriptors []string
+		errs           importpkg.ValidationErrors
 	)
-
-	if filename == "-" {
-		reader = ioutil.NopCloser(cmd.InOrStdin())
-	} else {
-		reader, err = os.Open(filename)
+	for _, filename := range filenames {
+		rawDescriptor, err := loader.Load(filename)
 		if err != nil {
 			return "", err
 		}
+
+		if err := importpkg.ValidateDescriptorSchema(rawDescriptor); err != nil {
+			schemaErrs, ok := err.(importpkg.ValidationErrors)
+			if !ok {
+				return "", err
+			}
+			errs = append(errs, schemaErrs.WithFile(descriptorName(filename))...)
+		}
+		rawDescriptors = append(rawDescriptors, rawDescriptor)
+	}
+	if len(errs) > 0 {
+		return "", errs
 	}
-	defer reader.Close()
 
-	buf, err := ioutil.ReadAll(reader)
+	rawDescriptor, err := importpkg.MergeRawDescriptors(rawDescriptors...)
 	if err != nil {
 		return "", err
 	}
 
-	return string(buf), nil
+	descriptor, err := importpkg.ReadDescriptor(rawDescriptor)
+	if err != nil {
+		return "", err
+	}
+
+	if errs := descriptor.ValidateReferences(); len(errs) > 0 {
+		return "", locateErrors(filenames, rawDescriptor, errs)
+	}
+	return rawDescriptor, nil
+}
+
+// locateErrors locates errors in the descriptor when it was read from a single
+// file. The lines of merged descriptors do not match any file.
+func locateErrors(filenames []string, rawDescriptor string, errs importpkg.ValidationErrors) importpkg.ValidationErrors {
+	if len(filenames) != 1 {
+		return errs
+	}
+	return importpkg.LocateErrors(rawDescriptor, errs).WithFile(descriptorName(filenames[0]))
+}
+
+func descriptorName(filename string) string {
+	if filename == "-" {
+		return "stdin"
+	}
+	return filename
 }
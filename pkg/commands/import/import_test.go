@@ -4,9 +4,15 @@
 package _import_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
 	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
@@ -16,6 +22,8 @@ import (
 	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
 	commandsfakes "github.com/vmware-tanzu/kpack-cli/pkg/commands/fakes"
 	importcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/import"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
 	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
 	corev1 "k8s.io/api/core/v1"
@@ -23,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/dynamic"
 	k8sfakes "k8s.io/client-go/kubernetes/fake"
+	clientgotesting "k8s.io/client-go/testing"
 )
 
 func TestImportCommand(t *testing.T) {
@@ -134,8 +143,9 @@ func testImportCommand(t *testing.T, when spec.G, it spec.S) {
 		ObjectMeta: metav1.ObjectMeta{
 			Name: "store-name",
 			Annotations: map[string]string{
-				"kubectl.kubernetes.io/last-applied-configuration": `{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-image-digest"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}`,
-				importTimestampKey: timestampProvider.timestamp,
+				"kpack.io/upstream-images":                         `{"default-registry.io/default-repo@sha256:buildpack-image-digest":"some-registry.io/repo/buildpack-image"}`,
+				"kubectl.kubernetes.io/last-applied-configuration": `{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null,"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:buildpack-image-digest\":\"some-registry.io/repo/buildpack-image\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-image-digest"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}`,
+				importTimestampKey:                                 timestampProvider.timestamp,
 			},
 		},
 		Spec: v1alpha2.ClusterStoreSpec{
@@ -275,6 +285,17 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated    1           0          0 B     0s
+ClusterStore      store-name             created    1           0          0 B     0s
+ClusterStack      stack-name             created    2           0          0 B     0s
+ClusterStack      default                created    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    created    0           0          0 B     0s
+ClusterBuilder    default                created    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 				ExpectCreates: []runtime.Object{
@@ -325,6 +346,17 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated    1           0          0 B     0s
+ClusterStore      store-name             created    1           0          0 B     0s
+ClusterStack      stack-name             created    2           0          0 B     0s
+ClusterStack      default                created    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    created    0           0          0 B     0s
+ClusterBuilder    default                created    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 				ExpectCreates: []runtime.Object{
@@ -369,6 +401,16 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+ClusterStore      store-name             created    1           0          0 B     0s
+ClusterStack      stack-name             created    2           0          0 B     0s
+ClusterStack      default                created    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    created    0           0          0 B     0s
+ClusterBuilder    default                created    0           0          0 B     0s
+
+Uploaded 5 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 				ExpectCreates: []runtime.Object{
@@ -432,6 +474,17 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated    1           0          0 B     0s
+ClusterStore      store-name             created    1           0          0 B     0s
+ClusterStack      stack-name             created    2           0          0 B     0s
+ClusterStack      default                created    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    created    0           0          0 B     0s
+ClusterBuilder    default                created    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 					ExpectCreates: []runtime.Object{
@@ -499,6 +552,17 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated    1           0          0 B     0s
+ClusterStore      store-name             created    1           0          0 B     0s
+ClusterStack      stack-name             created    2           0          0 B     0s
+ClusterStack      default                created    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    created    0           0          0 B     0s
+ClusterBuilder    default                created    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 					ExpectCreates: []runtime.Object{
@@ -581,6 +645,17 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION       UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated      1           0          0 B     0s
+ClusterStore      store-name             unchanged    1           0          0 B     0s
+ClusterStack      stack-name             updated      2           0          0 B     0s
+ClusterStack      default                updated      2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    unchanged    0           0          0 B     0s
+ClusterBuilder    default                unchanged    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 					ExpectPatches: []string{
@@ -605,7 +680,10 @@ Imported resources
 				defaultBuilder.Annotations = nil
 
 				expectedLifecycleImageConfig.Annotations = map[string]string{importTimestampKey: newTimestamp}
-				expectedStore.Annotations = map[string]string{importTimestampKey: newTimestamp}
+				expectedStore.Annotations = map[string]string{
+					importTimestampKey:         newTimestamp,
+					"kpack.io/upstream-images": `{"default-registry.io/default-repo@sha256:buildpack-image-digest":"some-registry.io/repo/buildpack-image"}`,
+				}
 				expectedBuilder.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = `{"kind":"ClusterBuilder","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"clusterbuilder-name","creationTimestamp":null},"spec":{"tag":"default-registry.io/default-repo:clusterbuilder-clusterbuilder-name","stack":{"kind":"ClusterStack","name":"stack-name"},"store":{"kind":"ClusterStore","name":"store-name"},"order":[{"group":[{"id":"buildpack-id"}]}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{"stack":{}}}`
 				expectedDefaultBuilder.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = `{"kind":"ClusterBuilder","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"default","creationTimestamp":null},"spec":{"tag":"default-registry.io/default-repo:clusterbuilder-default","stack":{"kind":"ClusterStack","name":"stack-name"},"store":{"kind":"ClusterStore","name":"store-name"},"order":[{"group":[{"id":"buildpack-id"}]}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{"stack":{}}}`
 
@@ -637,10 +715,22 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION       UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated      1           0          0 B     0s
+ClusterStore      store-name             unchanged    1           0          0 B     0s
+ClusterStack      stack-name             unchanged    2           0          0 B     0s
+ClusterStack      default                unchanged    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    unchanged    0           0          0 B     0s
+ClusterBuilder    default                unchanged    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 					ExpectPatches: []string{
 						`{"data":{"image":"default-registry.io/default-repo@sha256:lifecycle-image-digest"},"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp"}}}`,
+						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp","kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:buildpack-image-digest\":\"some-registry.io/repo/buildpack-image\"}"}}}`,
 						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp"}}}`,
 						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp","kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"clusterbuilder-name\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"default-registry.io/default-repo:clusterbuilder-clusterbuilder-name\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"stack-name\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"store-name\"},\"order\":[{\"group\":[{\"id\":\"buildpack-id\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}}}`,
 						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp","kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"default\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"default-registry.io/default-repo:clusterbuilder-default\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"stack-name\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"store-name\"},\"order\":[{\"group\":[{\"id\":\"buildpack-id\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}}}`,
@@ -734,13 +824,24 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:another-run-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated    1           0          0 B     0s
+ClusterStore      store-name             updated    1           0          0 B     0s
+ClusterStack      stack-name             updated    2           0          0 B     0s
+ClusterStack      default                updated    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    updated    0           0          0 B     0s
+ClusterBuilder    default                updated    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 Imported resources
 `,
 					ExpectPatches: []string{
 						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp","kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"default\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"default-registry.io/default-repo:clusterbuilder-default\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"stack-name\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"store-name\"},\"order\":[{\"group\":[{\"id\":\"another-buildpack-id\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}},"spec":{"order":[{"group":[{"id":"another-buildpack-id"}]}]}}`,
 						`{"data":{"image":"default-registry.io/default-repo@sha256:another-lifecycle-image-digest"},"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp"}}}`,
-						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-image-digest"},{"image":"default-registry.io/default-repo@sha256:another-buildpack-image-digest"}]}}`,
-						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp"}},"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:another-build-image-digest"},"id":"another-stack-id","runImage":{"image":"default-registry.io/default-repo@sha256:another-run-image-digest"}}}`,
+						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp","kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:another-buildpack-image-digest\":\"some-registry.io/repo/another-buildpack-image\",\"default-registry.io/default-repo@sha256:buildpack-image-digest\":\"some-registry.io/repo/buildpack-image\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-image-digest"},{"image":"default-registry.io/default-repo@sha256:another-buildpack-image-digest"}]}}`,
+						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp","kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:another-build-image-digest\":\"some-registry.io/repo/another-build-image\",\"default-registry.io/default-repo@sha256:another-run-image-digest\":\"some-registry.io/repo/another-run-image\"}"}},"spec":{"buildImage":{"image":"default-registry.io/default-repo@sha256:another-build-image-digest"},"id":"another-stack-id","runImage":{"image":"default-registry.io/default-repo@sha256:another-run-image-digest"}}}`,
 						`{"metadata":{"annotations":{"kpack.io/import-timestamp":"new-timestamp","kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"clusterbuilder-name\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"default-registry.io/default-repo:clusterbuilder-clusterbuilder-name\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"stack-name\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"store-name\"},\"order\":[{\"group\":[{\"id\":\"another-buildpack-id\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}},"spec":{"order":[{"group":[{"id":"another-buildpack-id"}]}]}}`,
 					},
 				}.TestK8sAndKpack(t, cmdFunc)
@@ -754,7 +855,18 @@ Imported resources
 			Args: []string{
 				"-f", "./testdata/invalid-deps.yaml",
 			},
-			ExpectedErrorOutput: "Error: did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3]\n",
+			ExpectedErrorOutput: "Error: did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3 kp.kpack.io/v1alpha4]\n",
+			ExpectErr:           true,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("errors when the descriptor does not match its schema", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{kpConfig},
+			Args: []string{
+				"-f", "./testdata/typo-deps.yaml",
+			},
+			ExpectedErrorOutput: "Error: ./testdata/typo-deps.yaml:15:3: clusterBuilders[0]: unknown field 'clusterstack', did you mean 'clusterStack'?\n",
 			ExpectErr:           true,
 		}.TestK8sAndKpack(t, cmdFunc)
 	})
@@ -774,6 +886,17 @@ Uploading to 'default-registry.io/default-repo'...
 	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
 Importing ClusterBuilder 'clusterbuilder-name'...
 Importing ClusterBuilder 'default'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated    1           0          0 B     0s
+ClusterStore      store-name             created    1           0          0 B     0s
+ClusterStack      stack-name             created    2           0          0 B     0s
+ClusterStack      default                created    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    created    0           0          0 B     0s
+ClusterBuilder    default                created    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
 `
 
 		builder.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = `{"kind":"ClusterBuilder","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"clusterbuilder-name","creationTimestamp":null},"spec":{"tag":"default-registry.io/default-repo:clusterbuilder-clusterbuilder-name","stack":{"kind":"ClusterStack","name":"stack-name"},"store":{"kind":"ClusterStore","name":"store-name"},"order":[{"group":[{"id":"buildpack-id"}]}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{"stack":{}}}`
@@ -796,7 +919,8 @@ kind: ClusterStore
 metadata:
   annotations:
     kpack.io/import-timestamp: "2006-01-02T15:04:05Z"
-    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-image-digest"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
+    kpack.io/upstream-images: '{"default-registry.io/default-repo@sha256:buildpack-image-digest":"some-registry.io/repo/buildpack-image"}'
+    kubectl.kubernetes.io/last-applied-configuration: '{"kind":"ClusterStore","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"store-name","creationTimestamp":null,"annotations":{"kpack.io/upstream-images":"{\"default-registry.io/default-repo@sha256:buildpack-image-digest\":\"some-registry.io/repo/buildpack-image\"}"}},"spec":{"sources":[{"image":"default-registry.io/default-repo@sha256:buildpack-image-digest"}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{}}'
   creationTimestamp: null
   name: store-name
 spec:
@@ -1016,7 +1140,8 @@ Importing ClusterBuilder 'default'... (dry run with image upload)
         "creationTimestamp": null,
         "annotations": {
             "kpack.io/import-timestamp": "2006-01-02T15:04:05Z",
-            "kubectl.kubernetes.io/last-applied-configuration": "{\"kind\":\"ClusterStore\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"store-name\",\"creationTimestamp\":null},\"spec\":{\"sources\":[{\"image\":\"default-registry.io/default-repo@sha256:buildpack-image-digest\"}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{}}"
+            "kpack.io/upstream-images": "{\"default-registry.io/default-repo@sha256:buildpack-image-digest\":\"some-registry.io/repo/buildpack-image\"}",
+            "kubectl.kubernetes.io/last-applied-configuration": "{\"kind\":\"ClusterStore\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"store-name\",\"creationTimestamp\":null,\"annotations\":{\"kpack.io/upstream-images\":\"{\\\"default-registry.io/default-repo@sha256:buildpack-image-digest\\\":\\\"some-registry.io/repo/buildpack-image\\\"}\"}},\"spec\":{\"sources\":[{\"image\":\"default-registry.io/default-repo@sha256:buildpack-image-digest\"}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{}}"
         }
     },
     "spec": {
@@ -1192,6 +1317,800 @@ Importing ClusterBuilder 'default'... (dry run with image upload)
 			}.TestK8sAndKpack(t, cmdFunc)
 		})
 	})
+
+	when("the prune flag is used", func() {
+		oldStore := &v1alpha2.ClusterStore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "old-store",
+				Annotations: map[string]string{
+					importTimestampKey: "some-old-timestamp",
+				},
+			},
+		}
+
+		unmanagedStore := &v1alpha2.ClusterStore{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "unmanaged-store",
+			},
+		}
+
+		it("deletes imported resources that are not in the descriptor", func() {
+			builder.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = `{"kind":"ClusterBuilder","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"clusterbuilder-name","creationTimestamp":null},"spec":{"tag":"default-registry.io/default-repo:clusterbuilder-clusterbuilder-name","stack":{"kind":"ClusterStack","name":"stack-name"},"store":{"kind":"ClusterStore","name":"store-name"},"order":[{"group":[{"id":"buildpack-id"}]}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{"stack":{}}}`
+			defaultBuilder.Annotations["kubectl.kubernetes.io/last-applied-configuration"] = `{"kind":"ClusterBuilder","apiVersion":"kpack.io/v1alpha2","metadata":{"name":"default","creationTimestamp":null},"spec":{"tag":"default-registry.io/default-repo:clusterbuilder-default","stack":{"kind":"ClusterStack","name":"stack-name"},"store":{"kind":"ClusterStore","name":"store-name"},"order":[{"group":[{"id":"buildpack-id"}]}],"serviceAccountRef":{"namespace":"some-namespace","name":"some-serviceaccount"}},"status":{"stack":{}}}`
+
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+					oldStore,
+					unmanagedStore,
+				},
+				Args: []string{
+					"-f", "./testdata/deps.yaml",
+					"--prune",
+				},
+				ExpectedOutput: `Importing Lifecycle...
+	Uploading 'default-registry.io/default-repo@sha256:lifecycle-image-digest'
+Importing ClusterStore 'store-name'...
+	Uploading 'default-registry.io/default-repo@sha256:buildpack-image-digest'
+Importing ClusterStack 'stack-name'...
+Uploading to 'default-registry.io/default-repo'...
+	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
+	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
+Importing ClusterStack 'default'...
+Uploading to 'default-registry.io/default-repo'...
+	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
+	Uploading 'default-registry.io/default-repo@sha256:build-image-digest'
+Importing ClusterBuilder 'clusterbuilder-name'...
+Importing ClusterBuilder 'default'...
+Pruning ClusterStore 'old-store'...
+KIND              NAME                   ACTION     UPLOADED    PRESENT    SIZE    WAIT
+Lifecycle         lifecycle-image        updated    1           0          0 B     0s
+ClusterStore      store-name             created    1           0          0 B     0s
+ClusterStack      stack-name             created    2           0          0 B     0s
+ClusterStack      default                created    2           0          0 B     0s
+ClusterBuilder    clusterbuilder-name    created    0           0          0 B     0s
+ClusterBuilder    default                created    0           0          0 B     0s
+
+Uploaded 6 images (0 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
+Imported resources
+`,
+				ExpectCreates: []runtime.Object{
+					store,
+					stack,
+					defaultStack,
+					builder,
+					defaultBuilder,
+				},
+				ExpectDeletes: []clientgotesting.DeleteActionImpl{
+					{
+						Name: oldStore.Name,
+					},
+				},
+				ExpectPatches: []string{
+					`{"data":{"image":"default-registry.io/default-repo@sha256:lifecycle-image-digest"},"metadata":{"annotations":{"kpack.io/import-timestamp":"2006-01-02T15:04:05Z"}}}`,
+				},
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("does not prune resources that are still in use", func() {
+			image := &v1alpha2.Image{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-image",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha2.ImageSpec{
+					Builder: corev1.ObjectReference{
+						Kind: v1alpha2.ClusterBuilderKind,
+						Name: "old-builder",
+					},
+				},
+			}
+			oldBuilder := &v1alpha2.ClusterBuilder{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "old-builder",
+					Annotations: map[string]string{
+						importTimestampKey: "some-old-timestamp",
+					},
+				},
+			}
+
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+					oldBuilder,
+					image,
+				},
+				Args: []string{
+					"-f", "./testdata/deps.yaml",
+					"--prune",
+				},
+				ExpectErr: true,
+				ExpectedErrorOutput: `Error: cannot prune resources that are still in use, use --force-prune to prune them anyway:
+	ClusterBuilder 'old-builder' is used by Image 'some-namespace/some-image'
+`,
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("bundles are used", func() {
+		const bundleDescriptor = `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+lifecycle:
+  image: some-registry.io/repo/bundled-lifecycle-image
+`
+		var (
+			tempDir        string
+			lifecycleImage v1.Image
+		)
+
+		it.Before(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "import-bundle-test")
+			require.NoError(t, err)
+
+			lifecycleImage, err = random.Image(10, 1)
+			require.NoError(t, err)
+			fakeFetcher.AddImage("some-registry.io/repo/bundled-lifecycle-image", lifecycleImage)
+		})
+
+		it.After(func() {
+			require.NoError(t, os.RemoveAll(tempDir))
+		})
+
+		it("writes the descriptor and its images to a bundle", func() {
+			bundlePath := filepath.Join(tempDir, "bundle.tar")
+
+			testhelpers.CommandTest{
+				Args: []string{
+					"-f", "-",
+					"--write-bundle", bundlePath,
+				},
+				StdIn: bundleDescriptor,
+				ExpectedOutput: fmt.Sprintf(`Fetching images...
+	Fetching 'some-registry.io/repo/bundled-lifecycle-image'
+Writing bundle '%[1]s'...
+Wrote bundle '%[1]s'
+`, bundlePath),
+			}.TestK8sAndKpack(t, cmdFunc)
+
+			bundle, err := registry.OpenBundle(bundlePath)
+			require.NoError(t, err)
+			defer bundle.Close()
+
+			require.Equal(t, bundleDescriptor, string(bundle.Descriptor()))
+			_, err = bundle.Fetch(nil, "some-registry.io/repo/bundled-lifecycle-image")
+			require.NoError(t, err)
+		})
+
+		it("imports the descriptor and images from a bundle", func() {
+			bundlePath := filepath.Join(tempDir, "bundle.tar")
+			require.NoError(t, registry.WriteBundle(bundlePath, []byte(bundleDescriptor), []registry.BundleImage{
+				{Ref: "some-registry.io/repo/bundled-lifecycle-image", Image: lifecycleImage},
+			}))
+
+			digest, err := lifecycleImage.Digest()
+			require.NoError(t, err)
+
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"--from-bundle", bundlePath,
+				},
+				ExpectedOutput: fmt.Sprintf(`Importing Lifecycle...
+	Uploading 'default-registry.io/default-repo@%[1]s'
+KIND         NAME               ACTION     UPLOADED    PRESENT    SIZE     WAIT
+Lifecycle    lifecycle-image    updated    1           0          423 B    0s
+
+Uploaded 1 images (423 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
+Imported resources
+`, digest),
+				ExpectPatches: []string{
+					fmt.Sprintf(`{"data":{"image":"default-registry.io/default-repo@%s"},"metadata":{"annotations":{"kpack.io/import-timestamp":"2006-01-02T15:04:05Z"}}}`, digest),
+				},
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors when both a filename and a bundle are provided", func() {
+			testhelpers.CommandTest{
+				Args: []string{
+					"-f", "./testdata/deps.yaml",
+					"--from-bundle", "some-bundle.tar",
+				},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: only one of --filename or --from-bundle may be provided\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("the changes output flag is used", func() {
+		it("prints the plan as json without importing", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", "./testdata/deps.yaml",
+					"--changes-output", "json",
+				},
+				ExpectedOutput: `{
+  "changes": [
+    {
+      "kind": "Lifecycle",
+      "action": "update",
+      "old": {},
+      "new": {
+        "image": "default-registry.io/default-repo@sha256:lifecycle-image-digest"
+      }
+    },
+    {
+      "kind": "ClusterStore",
+      "name": "store-name",
+      "action": "create",
+      "new": {
+        "sources": [
+          "default-registry.io/default-repo@sha256:buildpack-image-digest"
+        ]
+      }
+    },
+    {
+      "kind": "ClusterStack",
+      "name": "stack-name",
+      "action": "create",
+      "new": {
+        "buildImage": "default-registry.io/default-repo@sha256:build-image-digest",
+        "runImage": "default-registry.io/default-repo@sha256:build-image-digest"
+      }
+    },
+    {
+      "kind": "ClusterStack",
+      "name": "default",
+      "action": "create",
+      "new": {
+        "buildImage": "default-registry.io/default-repo@sha256:build-image-digest",
+        "runImage": "default-registry.io/default-repo@sha256:build-image-digest"
+      }
+    },
+    {
+      "kind": "ClusterBuilder",
+      "name": "clusterbuilder-name",
+      "action": "create",
+      "new": {
+        "clusterStack": "stack-name",
+        "clusterStore": "store-name",
+        "order": [
+          {
+            "group": [
+              {
+                "id": "buildpack-id"
+              }
+            ]
+          }
+        ]
+      }
+    },
+    {
+      "kind": "ClusterBuilder",
+      "name": "default",
+      "action": "create",
+      "new": {
+        "clusterStack": "stack-name",
+        "clusterStore": "store-name",
+        "order": [
+          {
+            "group": [
+              {
+                "id": "buildpack-id"
+              }
+            ]
+          }
+        ]
+      }
+    }
+  ]
+}
+`,
+			}.TestK8sAndKpack(t, cmdFunc)
+			require.Len(t, fakeWaiter.WaitCalls, 0)
+		})
+
+		it("prints the plan as yaml and marks unchanged resources as noop", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+					store,
+				},
+				Args: []string{
+					"-f", "./testdata/v1-deps.yaml",
+					"--changes-output", "yaml",
+				},
+				ExpectedOutput: `changes:
+- action: noop
+  kind: ClusterStore
+  name: store-name
+  new:
+    sources:
+    - default-registry.io/default-repo@sha256:buildpack-image-digest
+  old:
+    sources:
+    - default-registry.io/default-repo@sha256:buildpack-image-digest
+- action: create
+  kind: ClusterStack
+  name: stack-name
+  new:
+    buildImage: default-registry.io/default-repo@sha256:build-image-digest
+    runImage: default-registry.io/default-repo@sha256:build-image-digest
+- action: create
+  kind: ClusterStack
+  name: default
+  new:
+    buildImage: default-registry.io/default-repo@sha256:build-image-digest
+    runImage: default-registry.io/default-repo@sha256:build-image-digest
+- action: create
+  kind: ClusterBuilder
+  name: clusterbuilder-name
+  new:
+    clusterStack: stack-name
+    clusterStore: store-name
+    order:
+    - group:
+      - id: buildpack-id
+- action: create
+  kind: ClusterBuilder
+  name: default
+  new:
+    clusterStack: stack-name
+    clusterStore: store-name
+    order:
+    - group:
+      - id: buildpack-id
+`,
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors on an unsupported format", func() {
+			testhelpers.CommandTest{
+				Args: []string{
+					"-f", "./testdata/deps.yaml",
+					"--changes-output", "xml",
+				},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: unsupported changes output 'xml', must be one of: json, yaml\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("the descriptor is an OCI artifact", func() {
+		it("fetches the descriptor with the image fetcher", func() {
+			descriptor, err := ioutil.ReadFile("./testdata/deps.yaml")
+			require.NoError(t, err)
+			artifact, err := registry.NewDescriptorArtifact(descriptor)
+			require.NoError(t, err)
+			fakeFetcher.AddImage("some-registry.io/platform/deps:1.0.0", artifact)
+
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", "some-registry.io/platform/deps:1.0.0",
+					"--dry-run",
+				},
+				ExpectedOutput: `Importing Lifecycle... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:lifecycle-image-digest'
+Importing ClusterStore 'store-name'... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:buildpack-image-digest'
+Importing ClusterStack 'stack-name'... (dry run)
+Uploading to 'default-registry.io/default-repo'... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+Importing ClusterStack 'default'... (dry run)
+Uploading to 'default-registry.io/default-repo'... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+Importing ClusterBuilder 'clusterbuilder-name'... (dry run)
+Importing ClusterBuilder 'default'... (dry run)
+Imported resources (dry run)
+`,
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("multiple descriptors are provided", func() {
+		it("merges the descriptors in order and shows the effective descriptor", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", "./testdata/deps.yaml",
+					"-f", "./testdata/overlay-deps.yaml",
+					"--show-changes",
+					"--force",
+					"--dry-run",
+				},
+				ExpectedOutput: `Effective Descriptor
+
+apiVersion: kp.kpack.io/v1alpha4
+clusterBuilders:
+- clusterStack: stack-name
+  clusterStore: store-name
+  name: clusterbuilder-name
+  order:
+  - group:
+    - id: another-buildpack-id
+clusterStacks:
+- buildImage:
+    image: some-registry.io/repo/build-image
+  name: stack-name
+  runImage:
+    image: some-registry.io/repo/run-image
+clusterStores:
+- name: store-name
+  sources:
+  - image: some-registry.io/repo/buildpack-image
+  - image: some-registry.io/repo/another-buildpack-image
+defaultClusterBuilder: clusterbuilder-name
+defaultClusterStack: stack-name
+kind: DependencyDescriptor
+lifecycle:
+  image: some-registry.io/repo/lifecycle-image
+
+Changes
+
+Lifecycle
+
+some-diff
+
+ClusterStores
+
+some-diff
+
+ClusterStacks
+
+some-diff
+
+some-diff
+
+ClusterBuilders
+
+some-diff
+
+some-diff
+
+
+Importing Lifecycle... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:lifecycle-image-digest'
+Importing ClusterStore 'store-name'... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:buildpack-image-digest'
+	Skipping 'default-registry.io/default-repo@sha256:another-buildpack-image-digest'
+Importing ClusterStack 'stack-name'... (dry run)
+Uploading to 'default-registry.io/default-repo'... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+Importing ClusterStack 'default'... (dry run)
+Uploading to 'default-registry.io/default-repo'... (dry run)
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+	Skipping 'default-registry.io/default-repo@sha256:build-image-digest'
+Importing ClusterBuilder 'clusterbuilder-name'... (dry run)
+Importing ClusterBuilder 'default'... (dry run)
+Imported resources (dry run)
+`,
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("validates the merged descriptor", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", "./testdata/deps.yaml",
+					"-f", "./testdata/invalid-overlay-deps.yaml",
+				},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: default cluster stack 'missing-stack' not found\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors when stdin is provided more than once", func() {
+			testhelpers.CommandTest{
+				Args: []string{
+					"-f", "-",
+					"-f", "-",
+				},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: --filename may only read from stdin once\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("the descriptor has builders and images", func() {
+		it("shows their changes and imports them in a dry run", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", "./testdata/namespaced-deps.yaml",
+					"--show-changes",
+					"--dry-run",
+				},
+				ExpectedOutput: `Changes
+
+Lifecycle
+
+No Changes
+
+ClusterStores
+
+No Changes
+
+ClusterStacks
+
+No Changes
+
+ClusterBuilders
+
+No Changes
+
+Builders
+
+some-diff
+
+Images
+
+some-diff
+
+
+Importing Builder 'team/team-builder'... (dry run)
+Importing Image 'team/bootstrap'... (dry run)
+Imported resources (dry run)
+`,
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("the lockfile flags are used", func() {
+		const lockDescriptor = `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+lifecycle:
+  image: some-registry.io/repo/locked-lifecycle-image
+`
+		var (
+			tempDir        string
+			descriptorPath string
+			lockfilePath   string
+			lifecycleImage v1.Image
+			digest         v1.Hash
+		)
+
+		it.Before(func() {
+			var err error
+			tempDir, err = ioutil.TempDir("", "import-lockfile-test")
+			require.NoError(t, err)
+
+			descriptorPath = filepath.Join(tempDir, "deps.yaml")
+			lockfilePath = filepath.Join(tempDir, "deps.lock.yaml")
+			require.NoError(t, ioutil.WriteFile(descriptorPath, []byte(lockDescriptor), 0644))
+
+			lifecycleImage, err = random.Image(10, 1)
+			require.NoError(t, err)
+			digest, err = lifecycleImage.Digest()
+			require.NoError(t, err)
+			fakeFetcher.AddImage("some-registry.io/repo/locked-lifecycle-image", lifecycleImage)
+			fakeFetcher.AddImage("some-registry.io/repo/locked-lifecycle-image@"+digest.String(), lifecycleImage)
+		})
+
+		it.After(func() {
+			require.NoError(t, os.RemoveAll(tempDir))
+		})
+
+		it("writes the lockfile next to the descriptor", func() {
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", descriptorPath,
+					"--write-lockfile",
+				},
+				ExpectedOutput: fmt.Sprintf(`Importing Lifecycle...
+	Uploading 'default-registry.io/default-repo@%[1]s'
+Writing lockfile '%[2]s'...
+KIND         NAME               ACTION     UPLOADED    PRESENT    SIZE     WAIT
+Lifecycle    lifecycle-image    updated    1           0          423 B    0s
+
+Uploaded 1 images (423 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
+Imported resources
+`, digest, lockfilePath),
+				ExpectPatches: []string{
+					fmt.Sprintf(`{"data":{"image":"default-registry.io/default-repo@%s"},"metadata":{"annotations":{"kpack.io/import-timestamp":"2006-01-02T15:04:05Z"}}}`, digest),
+				},
+			}.TestK8sAndKpack(t, cmdFunc)
+
+			lockfile, err := ioutil.ReadFile(lockfilePath)
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf(`apiVersion: kp.kpack.io/v1alpha1
+images:
+- digest: %[1]s
+  relocated: default-registry.io/default-repo@%[1]s
+  source: some-registry.io/repo/locked-lifecycle-image
+kind: DependencyLockfile
+`, digest), string(lockfile))
+		})
+
+		it("imports the locked digests", func() {
+			require.NoError(t, ioutil.WriteFile(lockfilePath, []byte(fmt.Sprintf(`apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyLockfile
+images:
+- source: some-registry.io/repo/locked-lifecycle-image
+  digest: %s
+`, digest)), 0644))
+
+			// the tag now resolves to a different image than the one that was locked
+			fakeFetcher.AddImage("some-registry.io/repo/locked-lifecycle-image", registryfakes.NewFakeImage("moved-digest"))
+
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", descriptorPath,
+					"--locked",
+				},
+				ExpectedOutput: fmt.Sprintf(`Importing Lifecycle...
+	Uploading 'default-registry.io/default-repo@%[1]s'
+KIND         NAME               ACTION     UPLOADED    PRESENT    SIZE     WAIT
+Lifecycle    lifecycle-image    updated    1           0          423 B    0s
+
+Uploaded 1 images (423 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
+Imported resources
+`, digest),
+				ExpectPatches: []string{
+					fmt.Sprintf(`{"data":{"image":"default-registry.io/default-repo@%s"},"metadata":{"annotations":{"kpack.io/import-timestamp":"2006-01-02T15:04:05Z"}}}`, digest),
+				},
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors when the lockfile does not exist", func() {
+			testhelpers.CommandTest{
+				Args: []string{
+					"-f", descriptorPath,
+					"--locked",
+				},
+				ExpectErr:           true,
+				ExpectedErrorOutput: fmt.Sprintf("Error: lockfile '%s' does not exist\n", lockfilePath),
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors when the lockfile is stale", func() {
+			otherLockfile := filepath.Join(tempDir, "other.lock.yaml")
+			require.NoError(t, ioutil.WriteFile(otherLockfile, []byte(`apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyLockfile
+images:
+- source: some-registry.io/repo/old-lifecycle-image
+  digest: sha256:old-digest
+`), 0644))
+
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", descriptorPath,
+					"--locked",
+					"--lockfile", otherLockfile,
+				},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: lockfile is stale: 'some-registry.io/repo/locked-lifecycle-image' not locked\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("requires a lockfile path when no descriptor is a local file", func() {
+			testhelpers.CommandTest{
+				Args: []string{
+					"-f", "-",
+					"--write-lockfile",
+				},
+				StdIn:               lockDescriptor,
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: --lockfile is required when no descriptor is a local file\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+
+		it("errors when both --locked and --write-lockfile are provided", func() {
+			testhelpers.CommandTest{
+				Args: []string{
+					"-f", descriptorPath,
+					"--locked",
+					"--write-lockfile",
+				},
+				ExpectErr:           true,
+				ExpectedErrorOutput: "Error: only one of --write-lockfile or --locked may be provided\n",
+			}.TestK8sAndKpack(t, cmdFunc)
+		})
+	})
+
+	when("the report-file flag is used", func() {
+		it("writes the import report as json", func() {
+			tempDir, err := ioutil.TempDir("", "import-report-test")
+			require.NoError(t, err)
+			defer os.RemoveAll(tempDir)
+
+			lifecycleImage, err := random.Image(10, 1)
+			require.NoError(t, err)
+			digest, err := lifecycleImage.Digest()
+			require.NoError(t, err)
+			fakeFetcher.AddImage("some-registry.io/repo/report-lifecycle-image", lifecycleImage)
+
+			reportPath := filepath.Join(tempDir, "report.json")
+			testhelpers.CommandTest{
+				Objects: []runtime.Object{
+					kpConfig,
+					lifecycleImageConfig,
+				},
+				Args: []string{
+					"-f", "-",
+					"--report-file", reportPath,
+				},
+				StdIn: `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+lifecycle:
+  image: some-registry.io/repo/report-lifecycle-image
+`,
+				ExpectedOutput: fmt.Sprintf(`Importing Lifecycle...
+	Uploading 'default-registry.io/default-repo@%[1]s'
+KIND         NAME               ACTION     UPLOADED    PRESENT    SIZE     WAIT
+Lifecycle    lifecycle-image    updated    1           0          423 B    0s
+
+Uploaded 1 images (423 B), 0 already present
+Relocating took 0s, waiting for readiness took 0s
+
+Writing report '%[2]s'...
+Imported resources
+`, digest, reportPath),
+				ExpectPatches: []string{
+					fmt.Sprintf(`{"data":{"image":"default-registry.io/default-repo@%s"},"metadata":{"annotations":{"kpack.io/import-timestamp":"2006-01-02T15:04:05Z"}}}`, digest),
+				},
+			}.TestK8sAndKpack(t, cmdFunc)
+
+			buf, err := ioutil.ReadFile(reportPath)
+			require.NoError(t, err)
+
+			var report importpkg.ImportReport
+			require.NoError(t, json.Unmarshal(buf, &report))
+			report.RelocationSeconds = 0
+			require.Equal(t, importpkg.ImportReport{
+				Resources: []importpkg.ResourceReport{
+					{Kind: "Lifecycle", Name: "lifecycle-image", Action: "updated", ImagesUploaded: 1, BytesUploaded: 423},
+				},
+				ImagesUploaded: 1,
+				BytesUploaded:  423,
+			}, report)
+		})
+	})
 }
 
 type FakeTimestampProvider struct {
@@ -0,0 +1,72 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func NewPushCommand(rup registry.UtilProvider) *cobra.Command {
+	var (
+		filenames   []string
+		tlsConfig   registry.TLSConfig
+		retryConfig registry.RetryConfig
+	)
+
+	cmd := &cobra.Command{
+		Use:   "push <image> -f <filename>...",
+		Short: "Publish a dependency descriptor as an OCI artifact",
+		Long: `Publish a dependency descriptor to a registry so it can be imported with "kp import -f <image>".
+
+The descriptor is validated and written as the only layer of an OCI artifact at the provided image reference,
+keeping its tag. When --filename is repeated, the merged descriptor is published.`,
+		Example: `kp import push registry.example.com/platform/dependencies:1.0.0 -f dependencies.yaml
+kp import push registry.example.com/platform/dependencies:1.0.0-prod -f base.yaml -f prod.yaml`,
+		Args:         commands.ExactArgsWithUsage(1),
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return validateFilenames(cmd, filenames)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ch, err := commands.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			rawDescriptor, err := readDescriptors(cmd, rup.Fetcher(tlsConfig, retryConfig), tlsConfig, filenames)
+			if err != nil {
+				return err
+			}
+
+			if _, err := importpkg.ReadDescriptor(rawDescriptor); err != nil {
+				return err
+			}
+
+			artifact, err := registry.NewDescriptorArtifact([]byte(rawDescriptor))
+			if err != nil {
+				return err
+			}
+
+			if err := ch.PrintStatus("Pushing descriptor to '%s'...", args[0]); err != nil {
+				return err
+			}
+
+			ref, err := rup.RepositoryClient(tlsConfig, retryConfig).Write(authn.DefaultKeychain, artifact, args[0])
+			if err != nil {
+				return err
+			}
+
+			return ch.PrintResult("Pushed descriptor '%s'", ref)
+		},
+	}
+	cmd.Flags().StringArrayVarP(&filenames, "filename", "f", []string{}, "dependency descriptor filename, https url, or OCI artifact, repeat to merge descriptors in order")
+	commands.SetTLSFlags(cmd, &tlsConfig)
+	commands.SetRetryFlags(cmd, &retryConfig)
+	return cmd
+}
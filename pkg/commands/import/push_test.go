@@ -0,0 +1,100 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"io/ioutil"
+	"testing"
+
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	importcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/import"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+)
+
+func TestPushCommand(t *testing.T) {
+	spec.Run(t, "TestPushCommand", testPushCommand)
+}
+
+func testPushCommand(t *testing.T, when spec.G, it spec.S) {
+	var (
+		repositoryClient = registryfakes.NewRepositoryClient()
+		fakeUtilProvider = &registryfakes.UtilProvider{
+			FakeFetcher:          &registryfakes.Fetcher{},
+			FakeRepositoryClient: repositoryClient,
+		}
+	)
+
+	cmdFunc := func(k8sClientSet *k8sfakes.Clientset, kpackClientSet *kpackfakes.Clientset) *cobra.Command {
+		return importcmds.NewPushCommand(fakeUtilProvider)
+	}
+
+	it("publishes the descriptor as an artifact at the provided tag", func() {
+		descriptor, err := ioutil.ReadFile("./testdata/deps.yaml")
+		require.NoError(t, err)
+		artifact, err := registry.NewDescriptorArtifact(descriptor)
+		require.NoError(t, err)
+		digest, err := artifact.Digest()
+		require.NoError(t, err)
+
+		testhelpers.CommandTest{
+			Args: []string{
+				"some-registry.io/platform/deps:1.0.0",
+				"-f", "./testdata/deps.yaml",
+			},
+			ExpectedOutput: `Pushing descriptor to 'some-registry.io/platform/deps:1.0.0'...
+Pushed descriptor 'some-registry.io/platform/deps@` + digest.String() + `'
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+
+		require.Equal(t, digest.String(), repositoryClient.Tags["some-registry.io/platform/deps"]["1.0.0"])
+		require.Len(t, repositoryClient.Written, 1)
+		contents, err := registry.ReadDescriptorArtifact(repositoryClient.Written[0])
+		require.NoError(t, err)
+		require.Equal(t, string(descriptor), string(contents))
+	})
+
+	it("publishes the merged descriptor when several are provided", func() {
+		base, err := ioutil.ReadFile("./testdata/deps.yaml")
+		require.NoError(t, err)
+		overlay, err := ioutil.ReadFile("./testdata/overlay-deps.yaml")
+		require.NoError(t, err)
+		merged, err := importpkg.MergeRawDescriptors(string(base), string(overlay))
+		require.NoError(t, err)
+		artifact, err := registry.NewDescriptorArtifact([]byte(merged))
+		require.NoError(t, err)
+		digest, err := artifact.Digest()
+		require.NoError(t, err)
+
+		testhelpers.CommandTest{
+			Args: []string{
+				"some-registry.io/platform/deps:1.0.0",
+				"-f", "./testdata/deps.yaml",
+				"-f", "./testdata/overlay-deps.yaml",
+			},
+			ExpectedOutput: `Pushing descriptor to 'some-registry.io/platform/deps:1.0.0'...
+Pushed descriptor 'some-registry.io/platform/deps@` + digest.String() + `'
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("does not publish invalid descriptors", func() {
+		testhelpers.CommandTest{
+			Args: []string{
+				"some-registry.io/platform/deps:1.0.0",
+				"-f", "./testdata/invalid-deps.yaml",
+			},
+			ExpectErr:           true,
+			ExpectedErrorOutput: "Error: did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3 kp.kpack.io/v1alpha4]\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+		require.Len(t, repositoryClient.Written, 0)
+	})
+}
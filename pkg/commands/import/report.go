@@ -0,0 +1,72 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func printImportReport(ch *commands.CommandHelper, report importpkg.ImportReport) error {
+	writer, err := commands.NewTableWriter(ch.Writer(), "Kind", "Name", "Action", "Uploaded", "Present", "Size", "Wait")
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range report.Resources {
+		name := resource.Name
+		if resource.Namespace != "" {
+			name = resource.Namespace + "/" + resource.Name
+		}
+
+		err := writer.AddRow(
+			resource.Kind,
+			name,
+			string(resource.Action),
+			strconv.Itoa(resource.ImagesUploaded),
+			strconv.Itoa(resource.ImagesPresent),
+			registry.ReadableSize(resource.BytesUploaded),
+			readableSeconds(resource.WaitSeconds),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Write(); err != nil {
+		return err
+	}
+
+	return ch.Printlnf(
+		"Uploaded %d images (%s), %d already present\nRelocating took %s, waiting for readiness took %s\n",
+		report.ImagesUploaded,
+		registry.ReadableSize(report.BytesUploaded),
+		report.ImagesPresent,
+		readableSeconds(report.RelocationSeconds),
+		readableSeconds(report.WaitSeconds),
+	)
+}
+
+func writeImportReport(ch *commands.CommandHelper, report importpkg.ImportReport, path string) error {
+	buf, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ch.PrintStatus("Writing report '%s'...", path); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(buf, '\n'), 0644)
+}
+
+func readableSeconds(seconds float64) string {
+	return fmt.Sprint(time.Duration(seconds * float64(time.Second)).Round(time.Second))
+}
@@ -0,0 +1,99 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func NewValidateCommand(rup registry.UtilProvider) *cobra.Command {
+	var (
+		filenames       []string
+		checkBuildpacks bool
+		printSchema     bool
+		tlsConfig       registry.TLSConfig
+		retryConfig     registry.RetryConfig
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate -f <filename>...",
+		Short: "Validate a dependency descriptor without a cluster",
+		Long: `Validate a dependency descriptor without a cluster or a registry.
+
+Every descriptor is validated against the JSON Schema of its apiVersion, which rejects unknown fields and
+fields of the wrong type. The clusterStacks, clusterStores, builders, and clusterBuilders that resources
+refer to must be defined in the descriptor when it defines resources of that kind. Problems are reported
+with the file, line, and column they are found at. When --filename is repeated, the merged descriptor is
+validated and reference problems are reported without a line.
+
+The same validation runs at the start of every "kp import".
+
+With --check-buildpacks, the sources of every clusterStore are fetched to check that every buildpack in the
+order of a builder is in its store.
+
+Use --print-schema to print the JSON Schema of the current apiVersion, for use by editors.`,
+		Example: `kp import validate -f dependencies.yaml
+kp import validate -f base.yaml -f prod.yaml
+kp import validate -f dependencies.yaml --check-buildpacks
+kp import validate --print-schema > dependency-descriptor.json`,
+		Args:         commands.ExactArgsWithUsage(0),
+		SilenceUsage: true,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if printSchema {
+				return nil
+			}
+			return validateFilenames(cmd, filenames)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ch, err := commands.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			if printSchema {
+				schema, err := importpkg.DescriptorSchema(importpkg.CurrentAPIVersion)
+				if err != nil {
+					return err
+				}
+				_, err = cmd.OutOrStdout().Write(schema)
+				return err
+			}
+
+			fetcher := rup.Fetcher(tlsConfig, retryConfig)
+			rawDescriptor, err := readDescriptors(cmd, fetcher, tlsConfig, filenames)
+			if err != nil {
+				return err
+			}
+
+			if checkBuildpacks {
+				descriptor, err := importpkg.ReadDescriptor(rawDescriptor)
+				if err != nil {
+					return err
+				}
+
+				storeBuildpacks, err := importpkg.StoreBuildpacks(authn.DefaultKeychain, fetcher, descriptor)
+				if err != nil {
+					return err
+				}
+
+				if errs := descriptor.ValidateOrders(storeBuildpacks); len(errs) > 0 {
+					return locateErrors(filenames, rawDescriptor, errs)
+				}
+			}
+
+			return ch.PrintResult("Descriptor is valid")
+		},
+	}
+	cmd.Flags().StringArrayVarP(&filenames, "filename", "f", []string{}, "dependency descriptor filename, https url, or OCI artifact, repeat to merge descriptors in order")
+	cmd.Flags().BoolVar(&checkBuildpacks, "check-buildpacks", false, "fetch the clusterStore sources to check the buildpacks in builder orders")
+	cmd.Flags().BoolVar(&printSchema, "print-schema", false, "print the JSON Schema of the current descriptor apiVersion")
+	commands.SetTLSFlags(cmd, &tlsConfig)
+	commands.SetRetryFlags(cmd, &retryConfig)
+	return cmd
+}
@@ -0,0 +1,120 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"testing"
+
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	importcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/import"
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+)
+
+func TestValidateCommand(t *testing.T) {
+	spec.Run(t, "TestValidateCommand", testValidateCommand)
+}
+
+func testValidateCommand(t *testing.T, when spec.G, it spec.S) {
+	fetcher := registryfakes.NewBuildpackImagesFetcher(
+		registryfakes.BuildpackImgInfo{
+			Id:        "buildpack-id",
+			ImageInfo: registryfakes.ImageInfo{Ref: "some-registry.io/repo/buildpack-image", Digest: "buildpack-image-digest"},
+		},
+		registryfakes.BuildpackImgInfo{
+			Id:        "another-buildpack-id",
+			ImageInfo: registryfakes.ImageInfo{Ref: "some-registry.io/repo/another-buildpack-image", Digest: "another-buildpack-image-digest"},
+		},
+	)
+	fakeUtilProvider := &registryfakes.UtilProvider{FakeFetcher: fetcher}
+
+	cmdFunc := func(k8sClientSet *k8sfakes.Clientset, kpackClientSet *kpackfakes.Clientset) *cobra.Command {
+		return importcmds.NewValidateCommand(fakeUtilProvider)
+	}
+
+	it("validates descriptors", func() {
+		testhelpers.CommandTest{
+			Args:           []string{"-f", "./testdata/deps.yaml"},
+			ExpectedOutput: "Descriptor is valid\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("validates the merged descriptor when several are provided", func() {
+		testhelpers.CommandTest{
+			Args:           []string{"-f", "./testdata/deps.yaml", "-f", "./testdata/overlay-deps.yaml", "--check-buildpacks"},
+			ExpectedOutput: "Descriptor is valid\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("reports schema problems with their file, line, and column", func() {
+		testhelpers.CommandTest{
+			Args:                []string{"-f", "./testdata/deps.yaml", "-f", "./testdata/typo-deps.yaml"},
+			ExpectErr:           true,
+			ExpectedErrorOutput: "Error: ./testdata/typo-deps.yaml:15:3: clusterBuilders[0]: unknown field 'clusterstack', did you mean 'clusterStack'?\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("reports references to resources that are not defined", func() {
+		testhelpers.CommandTest{
+			Args: []string{"-f", "-"},
+			StdIn: `apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+clusterStores:
+- name: store-name
+clusterBuilders:
+- name: clusterbuilder-name
+  clusterStore: missing-store
+`,
+			ExpectErr: true,
+			ExpectedErrorOutput: `Error: stdin:6:3: clusterBuilders[0]: missing required field 'clusterStack'
+stdin:7:3: clusterBuilders[0].clusterStore: clusterStore 'missing-store' is not defined
+`,
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("checks builder orders against the store buildpacks", func() {
+		testhelpers.CommandTest{
+			Args: []string{"-f", "-", "--check-buildpacks"},
+			StdIn: `apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+clusterStores:
+- name: store-name
+  sources:
+  - image: some-registry.io/repo/buildpack-image
+clusterStacks:
+- name: stack-name
+  buildImage:
+    image: some-registry.io/repo/build-image
+  runImage:
+    image: some-registry.io/repo/run-image
+clusterBuilders:
+- name: clusterbuilder-name
+  clusterStack: stack-name
+  clusterStore: store-name
+  order:
+  - group:
+    - id: buildpack-id
+    - id: another-buildpack-id
+`,
+			ExpectErr:           true,
+			ExpectedErrorOutput: "Error: stdin:20:7: clusterBuilders[0].order[0].group[1].id: buildpack 'another-buildpack-id' is not in clusterStore 'store-name'\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("prints the schema of the current apiVersion", func() {
+		schema, err := importpkg.DescriptorSchema(importpkg.CurrentAPIVersion)
+		require.NoError(t, err)
+
+		testhelpers.CommandTest{
+			Args:           []string{"--print-schema"},
+			ExpectedOutput: string(schema),
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+}
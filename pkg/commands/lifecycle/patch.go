@@ -17,8 +17,10 @@ import (
 
 func NewUpdateCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilProvider) *cobra.Command {
 	var (
-		image  string
-		tlsCfg registry.TLSConfig
+		image     string
+		alwaysTag bool
+		tlsCfg    registry.TLSConfig
+		retryCfg  registry.RetryConfig
 	)
 
 	cmd := &cobra.Command{
@@ -59,8 +61,8 @@ The default repository is read from the "default.repository" key of the "kp-conf
 			cfg := lifecycle.ImageUpdaterConfig{
 				DryRun:       ch.IsDryRun(),
 				IOWriter:     ch.Writer(),
-				ImgFetcher:   rup.Fetcher(tlsCfg),
-				ImgRelocator: rup.Relocator(ch.Writer(), tlsCfg, ch.CanChangeState()),
+				ImgFetcher:   rup.Fetcher(tlsCfg, retryCfg),
+				ImgRelocator: rup.Relocator(ch.Writer(), tlsCfg, retryCfg, ch.CanChangeState(), alwaysTag),
 				ClientSet:    cs,
 				TLSConfig:    tlsCfg,
 			}
@@ -79,6 +81,8 @@ The default repository is read from the "default.repository" key of the "kp-conf
 	}
 	cmd.Flags().StringVarP(&image, "image", "i", "", "location of the image")
 	commands.SetImgUploadDryRunOutputFlags(cmd)
+	commands.SetAlwaysTagFlag(cmd, &alwaysTag)
 	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
 	return cmd
 }
@@ -0,0 +1,83 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/spf13/cobra"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/commands"
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/gc"
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+const defaultRetention = 7 * 24 * time.Hour
+
+func NewGCCommand(clientSetProvider k8s.ClientSetProvider, rup registry.UtilProvider) *cobra.Command {
+	var (
+		retention time.Duration
+		tlsCfg    registry.TLSConfig
+		retryCfg  registry.RetryConfig
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Delete unused images kp uploaded to the default repository",
+		Long: `Delete images that kp uploaded to the default repository and that are no longer used by the cluster.
+
+kp tags every image it uploads to the default repository with a timestamp. An image is deleted when all of its
+tags are timestamp tags older than the retention window and its digest is not referenced by:
+  - the spec or status of a ClusterStack
+  - the sources or status of a ClusterStore
+  - the tag or status of a ClusterBuilder or Builder
+  - the source image of an Image
+  - the lifecycle image ConfigMap
+
+Images with any other tag, such as the tags kpack gives builder images, are never deleted.
+Repositories below the default repository, such as "<default-repository>/lifecycle", are collected as well.
+
+Use --dry-run to list the images that would be deleted without deleting them.
+
+The default repository is read from the "default.repository" key in the "kp-config" ConfigMap within "kpack" namespace.`,
+		Example: `kp registry gc --dry-run
+kp registry gc --retention 72h`,
+		Args:         commands.ExactArgsWithUsage(0),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			cs, err := clientSetProvider.GetClientSet("")
+			if err != nil {
+				return err
+			}
+
+			ch, err := commands.NewCommandHelper(cmd)
+			if err != nil {
+				return err
+			}
+
+			kpConfig := config.NewKpConfigProvider(cs.K8sClient).GetKpConfig(ctx)
+
+			collector := gc.NewCollector(ch, cs.K8sClient, cs.KpackClient, rup.RepositoryClient(tlsCfg, retryCfg))
+			deleted, err := collector.Collect(ctx, authn.DefaultKeychain, kpConfig, gc.Options{
+				Retention: retention,
+				DryRun:    ch.IsDryRun(),
+			})
+			if err != nil {
+				return err
+			}
+
+			return ch.PrintResult("Deleted %d image(s)", len(deleted))
+		},
+	}
+	cmd.Flags().Bool(commands.DryRunFlag, false, "list the images that would be deleted without deleting them")
+	cmd.Flags().DurationVar(&retention, "retention", defaultRetention, "keep unused images uploaded within this long")
+	commands.SetTLSFlags(cmd, &tlsCfg)
+	commands.SetRetryFlags(cmd, &retryCfg)
+	return cmd
+}
@@ -0,0 +1,252 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	registrycmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/registry"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+	"github.com/vmware-tanzu/kpack-cli/pkg/testhelpers"
+)
+
+func TestGCCommand(t *testing.T) {
+	spec.Run(t, "TestGCCommand", testGCCommand)
+}
+
+func digest(n int) string {
+	return fmt.Sprintf("sha256:%064x", n)
+}
+
+func testGCCommand(t *testing.T, when spec.G, it spec.S) {
+	const (
+		defaultRepo   = "default-registry.io/default-repo"
+		lifecycleRepo = "default-registry.io/default-repo/lifecycle"
+	)
+
+	var (
+		repoClient *registryfakes.RepositoryClient
+		recentTag  = time.Now().Add(-time.Hour).Format("20060102150405")
+	)
+
+	cmdFunc := func(k8sClient *k8sfakes.Clientset, kpackClient *kpackfakes.Clientset) *cobra.Command {
+		clientSetProvider := testhelpers.GetFakeClusterProvider(k8sClient, kpackClient)
+		return registrycmds.NewGCCommand(clientSetProvider, registryfakes.UtilProvider{FakeRepositoryClient: repoClient})
+	}
+
+	kpConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kp-config",
+			Namespace: "kpack",
+		},
+		Data: map[string]string{
+			"default.repository": defaultRepo,
+		},
+	}
+
+	lifecycleImageConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "lifecycle-image",
+			Namespace: "kpack",
+		},
+		Data: map[string]string{
+			"image": lifecycleRepo + "@" + digest(10),
+		},
+	}
+
+	stack := &v1alpha2.ClusterStack{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-stack",
+		},
+		Spec: v1alpha2.ClusterStackSpec{
+			BuildImage: v1alpha2.ClusterStackSpecImage{Image: defaultRepo + "@" + digest(1)},
+			RunImage:   v1alpha2.ClusterStackSpecImage{Image: defaultRepo + "@" + digest(2)},
+		},
+		Status: v1alpha2.ClusterStackStatus{
+			ResolvedClusterStack: v1alpha2.ResolvedClusterStack{
+				RunImage: v1alpha2.ClusterStackStatusImage{LatestImage: defaultRepo + "@" + digest(3)},
+			},
+		},
+	}
+
+	store := &v1alpha2.ClusterStore{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "some-store",
+		},
+		Spec: v1alpha2.ClusterStoreSpec{
+			Sources: []corev1alpha1.StoreImage{{Image: defaultRepo + "@" + digest(4)}},
+		},
+	}
+
+	it.Before(func() {
+		repoClient = registryfakes.NewRepositoryClient()
+		repoClient.AddTag(defaultRepo, "20200101000001", digest(1))
+		repoClient.AddTag(defaultRepo, "20200101000002", digest(2))
+		repoClient.AddTag(defaultRepo, "20200101000003", digest(3))
+		repoClient.AddTag(defaultRepo, "20200101000004", digest(4))
+		repoClient.AddTag(defaultRepo, "20200101000005", digest(5))
+		repoClient.AddTag(defaultRepo, "20200101000006", digest(6))
+		repoClient.AddTag(defaultRepo, "20200101000007", digest(6))
+		repoClient.AddTag(defaultRepo, "clusterbuilder-some-builder", digest(7))
+		repoClient.AddTag(defaultRepo, recentTag, digest(8))
+		repoClient.AddTag(defaultRepo, "20200101000009", digest(9))
+		repoClient.AddTag(defaultRepo, "my-tag", digest(9))
+		repoClient.AddTag(lifecycleRepo, "20200101000010", digest(10))
+		repoClient.AddTag(lifecycleRepo, "20200101000011", digest(11))
+	})
+
+	it("deletes unreferenced images older than the retention window", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{kpConfig, lifecycleImageConfig, stack, store},
+			Args:    []string{},
+			ExpectedOutput: fmt.Sprintf(`Collecting '%[1]s'...
+	Deleting '%[1]s@%[3]s' (20200101000005)
+	Deleting '%[1]s@%[4]s' (20200101000006, 20200101000007)
+Collecting '%[2]s'...
+	Deleting '%[2]s@%[5]s' (20200101000011)
+Deleted 3 image(s)
+`, defaultRepo, lifecycleRepo, digest(5), digest(6), digest(11)),
+		}.TestK8sAndKpack(t, cmdFunc)
+
+		require.Equal(t, []string{
+			defaultRepo + "@" + digest(5),
+			defaultRepo + "@" + digest(6),
+			lifecycleRepo + "@" + digest(11),
+		}, repoClient.Deleted)
+	})
+
+	it("lists the images that would be deleted with --dry-run", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{kpConfig, lifecycleImageConfig, stack, store},
+			Args:    []string{"--dry-run"},
+			ExpectedOutput: fmt.Sprintf(`Collecting '%[1]s'... (dry run)
+	Would delete '%[1]s@%[3]s' (20200101000005)
+	Would delete '%[1]s@%[4]s' (20200101000006, 20200101000007)
+Collecting '%[2]s'... (dry run)
+	Would delete '%[2]s@%[5]s' (20200101000011)
+Deleted 3 image(s) (dry run)
+`, defaultRepo, lifecycleRepo, digest(5), digest(6), digest(11)),
+		}.TestK8sAndKpack(t, cmdFunc)
+
+		require.Empty(t, repoClient.Deleted)
+	})
+
+	it("deletes recent unreferenced images when the retention window is shorter", func() {
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{kpConfig, lifecycleImageConfig, stack, store},
+			Args:    []string{"--retention", "1m"},
+			ExpectedOutput: fmt.Sprintf(`Collecting '%[1]s'...
+	Deleting '%[1]s@%[3]s' (20200101000005)
+	Deleting '%[1]s@%[4]s' (20200101000006, 20200101000007)
+	Deleting '%[1]s@%[5]s' (%[6]s)
+Collecting '%[2]s'...
+	Deleting '%[2]s@%[7]s' (20200101000011)
+Deleted 4 image(s)
+`, defaultRepo, lifecycleRepo, digest(5), digest(6), digest(8), recentTag, digest(11)),
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("collects repositories below the default repository listed by the catalog", func() {
+		repoClient.CatalogEnabled = true
+		repoClient.AddTag(defaultRepo+"/buildpacks/some-buildpack", "20200101000012", digest(12))
+		repoClient.AddTag("default-registry.io/other-repo", "20200101000013", digest(13))
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{kpConfig, lifecycleImageConfig, stack, store},
+			Args:    []string{"--dry-run"},
+			ExpectedOutput: fmt.Sprintf(`Collecting '%[1]s'... (dry run)
+	Would delete '%[1]s@%[3]s' (20200101000005)
+	Would delete '%[1]s@%[4]s' (20200101000006, 20200101000007)
+Collecting '%[1]s/buildpacks/some-buildpack'... (dry run)
+	Would delete '%[1]s/buildpacks/some-buildpack@%[6]s' (20200101000012)
+Collecting '%[2]s'... (dry run)
+	Would delete '%[2]s@%[5]s' (20200101000011)
+Deleted 4 image(s) (dry run)
+`, defaultRepo, lifecycleRepo, digest(5), digest(6), digest(11), digest(12)),
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("keeps source images referenced by images", func() {
+		repoClient.CatalogEnabled = true
+		repoClient.AddTag(defaultRepo+"/app-source", "20200101000012", digest(12))
+		repoClient.AddTag(defaultRepo+"/app-source", "20200101000013", digest(13))
+
+		image := &v1alpha2.Image{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "some-image",
+				Namespace: "some-namespace",
+			},
+			Spec: v1alpha2.ImageSpec{
+				Tag: defaultRepo + "/app",
+				Source: corev1alpha1.SourceConfig{
+					Registry: &corev1alpha1.Registry{Image: defaultRepo + "/app-source@" + digest(12)},
+				},
+			},
+		}
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{kpConfig, lifecycleImageConfig, stack, store, image},
+			Args:    []string{},
+			ExpectedOutput: fmt.Sprintf(`Collecting '%[1]s'...
+	Deleting '%[1]s@%[3]s' (20200101000005)
+	Deleting '%[1]s@%[4]s' (20200101000006, 20200101000007)
+Collecting '%[1]s/app-source'...
+	Deleting '%[1]s/app-source@%[6]s' (20200101000013)
+Collecting '%[2]s'...
+	Deleting '%[2]s@%[5]s' (20200101000011)
+Deleted 4 image(s)
+`, defaultRepo, lifecycleRepo, digest(5), digest(6), digest(11), digest(13)),
+		}.TestK8sAndKpack(t, cmdFunc)
+
+		require.Equal(t, map[string]string{"20200101000012": digest(12)}, repoClient.Tags[defaultRepo+"/app-source"])
+	})
+
+	it("keeps the images builder tags point at", func() {
+		repoClient.AddTag(defaultRepo, "20200101000012", digest(5))
+
+		builder := &v1alpha2.ClusterBuilder{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "some-builder",
+			},
+			Spec: v1alpha2.ClusterBuilderSpec{
+				BuilderSpec: v1alpha2.BuilderSpec{
+					Tag: defaultRepo + ":20200101000012",
+				},
+			},
+		}
+
+		testhelpers.CommandTest{
+			Objects: []runtime.Object{kpConfig, lifecycleImageConfig, stack, store, builder},
+			Args:    []string{"--dry-run"},
+			ExpectedOutput: fmt.Sprintf(`Collecting '%[1]s'... (dry run)
+	Would delete '%[1]s@%[3]s' (20200101000006, 20200101000007)
+Collecting '%[2]s'... (dry run)
+	Would delete '%[2]s@%[4]s' (20200101000011)
+Deleted 2 image(s) (dry run)
+`, defaultRepo, lifecycleRepo, digest(6), digest(11)),
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+
+	it("errors when the default repository is not set", func() {
+		testhelpers.CommandTest{
+			Objects:             []runtime.Object{lifecycleImageConfig},
+			Args:                []string{},
+			ExpectErr:           true,
+			ExpectedErrorOutput: "Error: failed to get default repository: use \"kp config default-repository\" to set\n",
+		}.TestK8sAndKpack(t, cmdFunc)
+	})
+}
@@ -25,8 +25,9 @@ const (
 )
 
 type KpConfig struct {
-	defaultRepository string
-	serviceAccount    corev1.ObjectReference
+	defaultRepository   string
+	serviceAccount      corev1.ObjectReference
+	repositoryTemplates map[ImageKind]string
 }
 
 func NewKpConfig(defaultRepository string, serviceAccount corev1.ObjectReference) KpConfig {
@@ -90,6 +91,7 @@ func (d KpConfigProvider) GetKpConfig(ctx context.Context) KpConfig {
 			Name:      serviceAccountName,
 			Namespace: serviceAccountNamespace,
 		},
+		repositoryTemplates: repositoryTemplates(kpConfig.Data),
 	}
 }
 
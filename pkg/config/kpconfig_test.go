@@ -32,6 +32,52 @@ func testKpConfig(t *testing.T, when spec.G, it spec.S) {
 			require.Equal(t, want, got)
 		})
 	})
+
+	when("ImageRepository", func() {
+		kpConfig := NewKpConfig("some-registry.io/some-repo/",
+			corev1.ObjectReference{Name: "some-sa", Namespace: "some-ns"})
+
+		it("uses the default layout when no template is set", func() {
+			got, err := kpConfig.ImageRepository(LifecycleImage, RepositoryValues{})
+			require.NoError(t, err)
+			require.Equal(t, "some-registry.io/some-repo", got)
+
+			got, err = kpConfig.ImageRepository(BuildpackageImage, RepositoryValues{BuildpackID: "some-id"})
+			require.NoError(t, err)
+			require.Equal(t, "some-registry.io/some-repo", got)
+		})
+
+		it("renders the template for the image kind", func() {
+			templated := kpConfig.
+				WithRepositoryTemplate(BuildpackageImage, "{{.Repo}}/buildpacks/{{.BuildpackID}}").
+				WithRepositoryTemplate(RunImage, "{{.Repo}}/stacks/{{.Name}}/run")
+
+			got, err := templated.ImageRepository(BuildpackageImage, RepositoryValues{BuildpackID: "paketo-buildpacks/java"})
+			require.NoError(t, err)
+			require.Equal(t, "some-registry.io/some-repo/buildpacks/paketo-buildpacks/java", got)
+
+			got, err = templated.ImageRepository(RunImage, RepositoryValues{Name: "base"})
+			require.NoError(t, err)
+			require.Equal(t, "some-registry.io/some-repo/stacks/base/run", got)
+
+			got, err = templated.ImageRepository(BuildImage, RepositoryValues{Name: "base"})
+			require.NoError(t, err)
+			require.Equal(t, "some-registry.io/some-repo", got)
+
+			require.True(t, templated.HasRepositoryTemplate(RunImage))
+			require.False(t, templated.HasRepositoryTemplate(LifecycleImage))
+		})
+
+		it("errors when the template does not render a valid repository", func() {
+			templated := kpConfig.WithRepositoryTemplate(BuildImage, "{{.Repo}}/{{.Unknown}}")
+			_, err := templated.ImageRepository(BuildImage, RepositoryValues{})
+			require.Error(t, err)
+
+			templated = kpConfig.WithRepositoryTemplate(BuildpackageImage, "{{.Repo}}/Buildpacks/{{.BuildpackID}}")
+			_, err = templated.ImageRepository(BuildpackageImage, RepositoryValues{BuildpackID: "some-id"})
+			require.Error(t, err)
+		})
+	})
 }
 
 func testKpConfigProvider(t *testing.T, when spec.G, it spec.S) {
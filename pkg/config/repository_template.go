@@ -0,0 +1,179 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+)
+
+// ImageKind identifies which repository template applies to a relocated image.
+type ImageKind string
+
+const (
+	LifecycleImage    ImageKind = "lifecycle"
+	BuildImage        ImageKind = "build-image"
+	RunImage          ImageKind = "run-image"
+	BuildpackageImage ImageKind = "buildpackage"
+
+	repositoryTemplateKeyPrefix = "repository.template."
+)
+
+var defaultRepositoryTemplates = map[ImageKind]string{
+	LifecycleImage:    "{{.Repo}}",
+	BuildImage:        "{{.Repo}}",
+	RunImage:          "{{.Repo}}",
+	BuildpackageImage: "{{.Repo}}",
+}
+
+// ParseImageKind returns the ImageKind named by kind.
+func ParseImageKind(kind string) (ImageKind, error) {
+	if _, ok := defaultRepositoryTemplates[ImageKind(kind)]; !ok {
+		return "", errors.Errorf("unknown image kind '%s', must be one of %s", kind, strings.Join(imageKinds(), ", "))
+	}
+	return ImageKind(kind), nil
+}
+
+func imageKinds() []string {
+	var kinds []string
+	for kind := range defaultRepositoryTemplates {
+		kinds = append(kinds, string(kind))
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// RepositoryValues are the fields available to repository templates. Repo is
+// always the default repository; Name is the ClusterStack name for build and
+// run images and BuildpackID is the id of a buildpackage.
+type RepositoryValues struct {
+	Repo        string
+	Name        string
+	BuildpackID string
+}
+
+// ImageRepository returns the repository images of the given kind are
+// relocated to. It renders the "repository.template.<kind>" template from the
+// kp-config ConfigMap, falling back to the default layout when it is not set.
+func (c KpConfig) ImageRepository(kind ImageKind, values RepositoryValues) (string, error) {
+	repo, err := c.DefaultRepository()
+	if err != nil {
+		return "", err
+	}
+	values.Repo = repo
+
+	return renderRepositoryTemplate(kind, c.RepositoryTemplate(kind), values)
+}
+
+// RepositoryTemplate returns the template used for images of the given kind.
+func (c KpConfig) RepositoryTemplate(kind ImageKind) string {
+	if text, ok := c.repositoryTemplates[kind]; ok {
+		return text
+	}
+	return defaultRepositoryTemplates[kind]
+}
+
+// HasRepositoryTemplate reports whether a template is configured for kind.
+func (c KpConfig) HasRepositoryTemplate(kind ImageKind) bool {
+	_, ok := c.repositoryTemplates[kind]
+	return ok
+}
+
+func renderRepositoryTemplate(kind ImageKind, text string, values RepositoryValues) (string, error) {
+	tmpl, err := template.New(string(kind)).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid %s repository template", kind)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, values); err != nil {
+		return "", errors.Wrapf(err, "invalid %s repository template", kind)
+	}
+
+	rendered := sanitize(buf.String())
+	if _, err := name.NewRepository(rendered, name.WeakValidation); err != nil {
+		return "", errors.Wrapf(err, "invalid %s repository '%s'", kind, rendered)
+	}
+
+	return rendered, nil
+}
+
+// WithRepositoryTemplate returns a copy of the config that uses text as the
+// repository template for kind.
+func (c KpConfig) WithRepositoryTemplate(kind ImageKind, text string) KpConfig {
+	templates := map[ImageKind]string{}
+	for k, v := range c.repositoryTemplates {
+		templates[k] = v
+	}
+	templates[kind] = text
+	c.repositoryTemplates = templates
+	return c
+}
+
+func repositoryTemplates(data map[string]string) map[ImageKind]string {
+	var templates map[ImageKind]string
+	for kind := range defaultRepositoryTemplates {
+		if text, ok := data[repositoryTemplateKeyPrefix+string(kind)]; ok && text != "" {
+			if templates == nil {
+				templates = map[ImageKind]string{}
+			}
+			templates[kind] = text
+		}
+	}
+	return templates
+}
+
+// SetRepositoryTemplate stores the repository template for kind in the kp-config
+// ConfigMap. An empty template restores the default layout.
+func (d KpConfigProvider) SetRepositoryTemplate(ctx context.Context, kind ImageKind, text string) error {
+	if text != "" {
+		sample := RepositoryValues{Repo: "registry.io/repo", Name: "name", BuildpackID: "buildpack/id"}
+		if _, err := renderRepositoryTemplate(kind, text, sample); err != nil {
+			return err
+		}
+	}
+
+	key := repositoryTemplateKeyPrefix + string(kind)
+
+	existingKpConfig, err := d.getKpConfigMap(ctx)
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	if k8serrors.IsNotFound(err) {
+		if text == "" {
+			return nil
+		}
+		return d.createKpConfigMap(ctx, map[string]string{key: text})
+	}
+
+	updatedConfig := existingKpConfig.DeepCopy()
+	if updatedConfig.Data == nil {
+		updatedConfig.Data = map[string]string{}
+	}
+	if text == "" {
+		delete(updatedConfig.Data, key)
+	} else {
+		updatedConfig.Data[key] = text
+	}
+
+	patch, err := k8s.CreatePatch(existingKpConfig, updatedConfig)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.client.CoreV1().ConfigMaps(kpConfigNamespace).Patch(ctx, updatedConfig.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
@@ -0,0 +1,314 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package gc
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pivotal/kpack/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/lifecycle"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+type Printer interface {
+	Printlnf(format string, args ...interface{}) error
+	PrintStatus(format string, args ...interface{}) error
+}
+
+// Collector deletes the images kp relocated into the default repository that
+// are no longer referenced by the cluster.
+type Collector struct {
+	printer    Printer
+	k8sClient  kubernetes.Interface
+	client     versioned.Interface
+	repoClient registry.RepositoryClient
+	now        func() time.Time
+}
+
+func NewCollector(printer Printer, k8sClient kubernetes.Interface, client versioned.Interface, repoClient registry.RepositoryClient) *Collector {
+	return &Collector{
+		printer:    printer,
+		k8sClient:  k8sClient,
+		client:     client,
+		repoClient: repoClient,
+		now:        time.Now,
+	}
+}
+
+// Options controls which images a collection deletes.
+type Options struct {
+	// Retention keeps images tagged within this long of now even when nothing
+	// references them.
+	Retention time.Duration
+
+	// DryRun reports the images that would be deleted without deleting them.
+	DryRun bool
+}
+
+// Collect deletes every image in the default repository, and the repositories
+// below it, that has a timestamp tag written by kp, is older than the
+// retention window, and is not referenced by a ClusterStack, ClusterStore,
+// ClusterBuilder, Builder, Image, or the lifecycle ConfigMap. Images with any
+// other tag are always kept. It returns the references of the deleted images.
+func (c *Collector) Collect(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, opts Options) ([]string, error) {
+	defaultRepo, err := kpConfig.DefaultRepository()
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := name.NewRepository(defaultRepo, name.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	references, err := c.references(ctx, keychain, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []string
+	for _, repository := range c.repositories(keychain, kpConfig, root, references) {
+		refs, err := c.collectRepository(keychain, repository, references[repository], opts)
+		if err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, refs...)
+	}
+
+	return deleted, nil
+}
+
+func (c *Collector) collectRepository(keychain authn.Keychain, repository string, referenced map[string]struct{}, opts Options) ([]string, error) {
+	tags, err := c.repoClient.ListTags(keychain, repository)
+	if err != nil {
+		if registry.ClassifyError(err) == registry.NotFoundError {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := c.printer.PrintStatus("Collecting '%s'...", repository); err != nil {
+		return nil, err
+	}
+
+	tagsByDigest := map[string][]string{}
+	for _, tag := range tags {
+		digest, err := c.repoClient.Digest(keychain, repository+":"+tag)
+		if err != nil {
+			return nil, err
+		}
+		tagsByDigest[digest] = append(tagsByDigest[digest], tag)
+	}
+
+	var digests []string
+	for digest := range tagsByDigest {
+		digests = append(digests, digest)
+	}
+	sort.Strings(digests)
+
+	var deleted []string
+	for _, digest := range digests {
+		if _, ok := referenced[digest]; ok {
+			continue
+		}
+		if !c.expired(tagsByDigest[digest], opts.Retention) {
+			continue
+		}
+
+		ref := repository + "@" + digest
+		if opts.DryRun {
+			if err := c.printer.Printlnf("\tWould delete '%s' (%s)", ref, strings.Join(tagsByDigest[digest], ", ")); err != nil {
+				return deleted, err
+			}
+			deleted = append(deleted, ref)
+			continue
+		}
+
+		if err := c.printer.Printlnf("\tDeleting '%s' (%s)", ref, strings.Join(tagsByDigest[digest], ", ")); err != nil {
+			return deleted, err
+		}
+		if err := c.repoClient.Delete(keychain, ref); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, ref)
+	}
+
+	return deleted, nil
+}
+
+// expired reports whether every tag of an image is a timestamp tag older than
+// the retention window.
+func (c *Collector) expired(tags []string, retention time.Duration) bool {
+	cutoff := c.now().Add(-retention)
+	for _, tag := range tags {
+		written, ok := registry.ParseTimestampTag(tag)
+		if !ok || written.After(cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+// repositories returns the default repository, the lifecycle repository, and
+// every repository below the default repository that is referenced by the
+// cluster or listed by the registry catalog.
+func (c *Collector) repositories(keychain authn.Keychain, kpConfig config.KpConfig, root name.Repository, references map[string]map[string]struct{}) []string {
+	repositories := map[string]struct{}{root.Name(): {}}
+	add := func(repository string) {
+		if repository == root.Name() || strings.HasPrefix(repository, root.Name()+"/") {
+			repositories[repository] = struct{}{}
+		}
+	}
+
+	// kp lifecycle patch uploads to <default-repository>/lifecycle when no
+	// lifecycle template is set, even though kp import does not
+	add(root.Name() + "/lifecycle")
+	if lifecycleRepo, err := kpConfig.ImageRepository(config.LifecycleImage, config.RepositoryValues{}); err == nil {
+		if repo, err := name.NewRepository(lifecycleRepo, name.WeakValidation); err == nil {
+			add(repo.Name())
+		}
+	}
+
+	for repository := range references {
+		add(repository)
+	}
+
+	// Most registries only list repositories to admins, if at all, so the
+	// catalog is a best effort to find repositories nothing references.
+	if catalog, err := c.repoClient.Catalog(keychain, root.RegistryStr()); err == nil {
+		for _, repository := range catalog {
+			add(repository)
+		}
+	}
+
+	var result []string
+	for repository := range repositories {
+		result = append(result, repository)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// references returns the digests referenced by the cluster, keyed by the
+// repository they are in. Tags below root, such as builder tags, are resolved
+// to the digest they currently point at.
+func (c *Collector) references(ctx context.Context, keychain authn.Keychain, root name.Repository) (map[string]map[string]struct{}, error) {
+	var images []string
+
+	lifecycleImage, err := lifecycle.GetImage(ctx, c.k8sClient)
+	if err != nil {
+		return nil, err
+	}
+	images = append(images, lifecycleImage)
+
+	stacks, err := c.client.KpackV1alpha2().ClusterStacks().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, stack := range stacks.Items {
+		images = append(images,
+			stack.Spec.BuildImage.Image,
+			stack.Spec.RunImage.Image,
+			stack.Status.BuildImage.Image,
+			stack.Status.BuildImage.LatestImage,
+			stack.Status.RunImage.Image,
+			stack.Status.RunImage.LatestImage,
+		)
+	}
+
+	stores, err := c.client.KpackV1alpha2().ClusterStores().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, store := range stores.Items {
+		for _, source := range store.Spec.Sources {
+			images = append(images, source.Image)
+		}
+		for _, buildpack := range store.Status.Buildpacks {
+			images = append(images, buildpack.StoreImage.Image)
+		}
+	}
+
+	clusterBuilders, err := c.client.KpackV1alpha2().ClusterBuilders().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, builder := range clusterBuilders.Items {
+		images = append(images, builder.Spec.Tag, builder.Status.LatestImage, builder.Status.Stack.RunImage)
+	}
+
+	builders, err := c.client.KpackV1alpha2().Builders("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, builder := range builders.Items {
+		images = append(images, builder.Spec.Tag, builder.Status.LatestImage, builder.Status.Stack.RunImage)
+	}
+
+	// source images uploaded with --local-path live in <tag>-source, which
+	// may be below the default repository
+	imgs, err := c.client.KpackV1alpha2().Images("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range imgs.Items {
+		if img.Spec.Source.Registry != nil {
+			images = append(images, img.Spec.Source.Registry.Image)
+		}
+	}
+
+	references := map[string]map[string]struct{}{}
+	for _, image := range images {
+		repository, digest, err := c.resolve(keychain, root, image)
+		if err != nil {
+			return nil, err
+		} else if digest == "" {
+			continue
+		}
+
+		if references[repository] == nil {
+			references[repository] = map[string]struct{}{}
+		}
+		references[repository][digest] = struct{}{}
+	}
+
+	return references, nil
+}
+
+// resolve returns the repository and digest of an image reference. Tags are
+// only resolved when they are below root, as nothing else is collected. The
+// digest is empty when the reference is not an image or does not exist.
+func (c *Collector) resolve(keychain authn.Keychain, root name.Repository, image string) (string, string, error) {
+	if digest, err := name.NewDigest(image, name.WeakValidation); err == nil {
+		return digest.Context().Name(), digest.DigestStr(), nil
+	}
+
+	tag, err := name.NewTag(image, name.WeakValidation)
+	if err != nil {
+		return "", "", nil
+	}
+
+	repository := tag.Context().Name()
+	if repository != root.Name() && !strings.HasPrefix(repository, root.Name()+"/") {
+		return "", "", nil
+	}
+
+	digest, err := c.repoClient.Digest(keychain, tag.Name())
+	if err != nil {
+		if registry.ClassifyError(err) == registry.NotFoundError {
+			return "", "", nil
+		}
+		return "", "", err
+	}
+	return repository, digest, nil
+}
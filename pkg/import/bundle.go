@@ -0,0 +1,51 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"github.com/google/go-containerregistry/pkg/authn"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+type BundleWriter struct {
+	printer      Printer
+	imageFetcher registry.Fetcher
+}
+
+func NewBundleWriter(printer Printer, fetcher registry.Fetcher) *BundleWriter {
+	return &BundleWriter{
+		printer:      printer,
+		imageFetcher: fetcher,
+	}
+}
+
+// WriteBundle fetches every image referenced by the descriptor and writes them,
+// together with the descriptor, to a bundle that can be imported with --from-bundle.
+func (b *BundleWriter) WriteBundle(keychain authn.Keychain, rawDescriptor, path string) error {
+	descriptor, err := ReadDescriptor(rawDescriptor)
+	if err != nil {
+		return err
+	}
+
+	var images []registry.BundleImage
+	for _, ref := range descriptor.ImageRefs() {
+		if err := b.printer.Printlnf("\tFetching '%s'", ref); err != nil {
+			return err
+		}
+
+		image, err := b.imageFetcher.Fetch(keychain, ref)
+		if err != nil {
+			return err
+		}
+
+		images = append(images, registry.BundleImage{Ref: ref, Image: image})
+	}
+
+	if err := b.printer.PrintStatus("Writing bundle '%s'...", path); err != nil {
+		return err
+	}
+
+	return registry.WriteBundle(path, []byte(rawDescriptor), images)
+}
@@ -0,0 +1,155 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"encoding/json"
+
+	"github.com/ghodss/yaml"
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const lifecycleKind = "Lifecycle"
+
+type ChangeAction string
+
+const (
+	ActionCreate ChangeAction = "create"
+	ActionUpdate ChangeAction = "update"
+	ActionNoop   ChangeAction = "noop"
+	ActionDelete ChangeAction = "delete"
+)
+
+// ChangePlan is a machine-readable summary of the changes an import makes,
+// with one entry per lifecycle, clusterstore, clusterstack, clusterbuilder,
+// builder, and image.
+type ChangePlan struct {
+	Changes []ResourceChange `json:"changes"`
+}
+
+type ResourceChange struct {
+	Kind      string          `json:"kind"`
+	Namespace string          `json:"namespace,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Action    ChangeAction    `json:"action"`
+	Old       *ResourceValues `json:"old,omitempty"`
+	New       *ResourceValues `json:"new,omitempty"`
+}
+
+// ResourceValues holds the values of a resource that an import can change.
+// Images are the relocated images the resource refers to.
+type ResourceValues struct {
+	Image          string                     `json:"image,omitempty"`
+	BuildImage     string                     `json:"buildImage,omitempty"`
+	RunImage       string                     `json:"runImage,omitempty"`
+	Sources        []string                   `json:"sources,omitempty"`
+	Tag            string                     `json:"tag,omitempty"`
+	ClusterStack   string                     `json:"clusterStack,omitempty"`
+	ClusterStore   string                     `json:"clusterStore,omitempty"`
+	Order          []corev1alpha1.OrderEntry  `json:"order,omitempty"`
+	Source         *corev1alpha1.SourceConfig `json:"source,omitempty"`
+	Builder        string                     `json:"builder,omitempty"`
+	ClusterBuilder string                     `json:"clusterBuilder,omitempty"`
+	Env            []corev1.EnvVar            `json:"env,omitempty"`
+}
+
+// HasChanges reports whether any entry is not a noop.
+func (p ChangePlan) HasChanges() bool {
+	for _, c := range p.Changes {
+		if c.Action != ActionNoop {
+			return true
+		}
+	}
+	return false
+}
+
+// Marshal renders the plan in format, which must be json or yaml.
+func (p ChangePlan) Marshal(format string) ([]byte, error) {
+	if p.Changes == nil {
+		p.Changes = []ResourceChange{}
+	}
+
+	switch format {
+	case "json":
+		return json.MarshalIndent(p, "", "  ")
+	case "yaml":
+		return yaml.Marshal(p)
+	default:
+		return nil, errors.Errorf("unsupported changes output '%s', must be one of: json, yaml", format)
+	}
+}
+
+func (p *ChangePlan) add(kind, name string, exists bool, diff string, old, new *ResourceValues) {
+	p.addNamespaced(kind, "", name, exists, diff, old, new)
+}
+
+func (p *ChangePlan) addNamespaced(kind, namespace, name string, exists bool, diff string, old, new *ResourceValues) {
+	if p == nil {
+		return
+	}
+
+	action := ActionNoop
+	if !exists {
+		action = ActionCreate
+		old = nil
+	} else if diff != "" {
+		action = ActionUpdate
+	}
+
+	p.Changes = append(p.Changes, ResourceChange{
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		Action:    action,
+		Old:       old,
+		New:       new,
+	})
+}
+
+func (p *ChangePlan) addPruned(old interface{}) {
+	if p == nil {
+		return
+	}
+
+	change := ResourceChange{Action: ActionDelete}
+	switch o := old.(type) {
+	case ClusterStore:
+		change.Kind, change.Name, change.Old = v1alpha2.ClusterStoreKind, o.Name, storeValues(o.Sources)
+	case ClusterStack:
+		change.Kind, change.Name, change.Old = v1alpha2.ClusterStackKind, o.Name, stackValues(o)
+	case ClusterBuilder:
+		change.Kind, change.Name, change.Old = v1alpha2.ClusterBuilderKind, o.Name, builderValues(o)
+	default:
+		return
+	}
+	p.Changes = append(p.Changes, change)
+}
+
+func storeValues(sources []Source) *ResourceValues {
+	values := &ResourceValues{Sources: []string{}}
+	for _, s := range sources {
+		values.Sources = append(values.Sources, s.Image)
+	}
+	return values
+}
+
+func stackValues(stack ClusterStack) *ResourceValues {
+	return &ResourceValues{BuildImage: stack.BuildImage.Image, RunImage: stack.RunImage.Image}
+}
+
+func builderValues(builder ClusterBuilder) *ResourceValues {
+	return &ResourceValues{ClusterStack: builder.ClusterStack, ClusterStore: builder.ClusterStore, Order: builder.Order}
+}
+
+func namespacedBuilderValues(builder Builder) *ResourceValues {
+	return &ResourceValues{Tag: builder.Tag, ClusterStack: builder.ClusterStack, ClusterStore: builder.ClusterStore, Order: builder.Order}
+}
+
+func imageValues(image Image) *ResourceValues {
+	source := image.Source
+	return &ResourceValues{Tag: image.Tag, Source: &source, Builder: image.Builder, ClusterBuilder: image.ClusterBuilder, Env: image.Env}
+}
@@ -18,14 +18,44 @@ func SummarizeChange(
 	ctx context.Context,
 	keychain authn.Keychain,
 	desc DependencyDescriptor,
+	prunable Prunable,
 	kpConfig config.KpConfig,
 	relocatedImageProvider RelocatedImageProvider,
 	differ Differ, cs buildk8s.ClientSet) (hasChanges bool, changes string, err error) {
 
+	return summarizeChange(ctx, keychain, desc, prunable, kpConfig, relocatedImageProvider, differ, cs, nil)
+}
+
+// PlanChange returns the changes an import of desc makes as a ChangePlan. It
+// is computed by the same diffs that SummarizeChange renders.
+func PlanChange(
+	ctx context.Context,
+	keychain authn.Keychain,
+	desc DependencyDescriptor,
+	prunable Prunable,
+	kpConfig config.KpConfig,
+	relocatedImageProvider RelocatedImageProvider,
+	differ Differ, cs buildk8s.ClientSet) (ChangePlan, error) {
+
+	plan := &ChangePlan{}
+	_, _, err := summarizeChange(ctx, keychain, desc, prunable, kpConfig, relocatedImageProvider, differ, cs, plan)
+	return *plan, err
+}
+
+func summarizeChange(
+	ctx context.Context,
+	keychain authn.Keychain,
+	desc DependencyDescriptor,
+	prunable Prunable,
+	kpConfig config.KpConfig,
+	relocatedImageProvider RelocatedImageProvider,
+	differ Differ, cs buildk8s.ClientSet, plan *ChangePlan) (hasChanges bool, changes string, err error) {
+
 	var summarizer changeSummarizer
 	iDiffer := &ImportDiffer{
 		Differ:                 differ,
 		RelocatedImageProvider: relocatedImageProvider,
+		Plan:                   plan,
 	}
 
 	err = writeLifecycleChange(ctx, keychain, kpConfig, desc.Lifecycle, iDiffer, cs, &summarizer)
@@ -33,21 +63,36 @@ func SummarizeChange(
 		return
 	}
 
-	err = writeClusterStoresChange(ctx, keychain, kpConfig, desc.ClusterStores, iDiffer, cs, &summarizer)
+	err = writeClusterStoresChange(ctx, keychain, kpConfig, desc.ClusterStores, prunable.ClusterStores, iDiffer, cs, &summarizer)
 	if err != nil {
 		return
 	}
 
-	err = writeClusterStacksChange(ctx, keychain, kpConfig, desc.GetClusterStacks(), iDiffer, cs, &summarizer)
+	err = writeClusterStacksChange(ctx, keychain, kpConfig, desc.GetClusterStacks(), prunable.ClusterStacks, iDiffer, cs, &summarizer)
 	if err != nil {
 		return
 	}
 
-	err = writeClusterBuildersChange(ctx, desc.GetClusterBuilders(), iDiffer, cs, &summarizer)
+	err = writeClusterBuildersChange(ctx, desc.GetClusterBuilders(), prunable.ClusterBuilders, iDiffer, cs, &summarizer)
 	if err != nil {
 		return
 	}
 
+	// Builders and Images are only summarized for descriptors that define them.
+	if len(desc.Builders) > 0 {
+		err = writeBuildersChange(ctx, kpConfig, desc.Builders, iDiffer, cs, &summarizer)
+		if err != nil {
+			return
+		}
+	}
+
+	if len(desc.Images) > 0 {
+		err = writeImagesChange(ctx, desc.Images, iDiffer, cs, &summarizer)
+		if err != nil {
+			return
+		}
+	}
+
 	return summarizer.hasChanges, summarizer.changes.String(), nil
 }
 
@@ -7,6 +7,7 @@ import (
 	"context"
 
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -41,7 +42,7 @@ func writeLifecycleChange(ctx context.Context, keychain authn.Keychain, kpConfig
 	return nil
 }
 
-func writeClusterStoresChange(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, stores []ClusterStore, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
+func writeClusterStoresChange(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, stores []ClusterStore, prunedStores []v1alpha2.ClusterStore, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
 	for _, store := range stores {
 		oldStore, err := cs.KpackClient.KpackV1alpha2().ClusterStores().Get(ctx, store.Name, metav1.GetOptions{})
 		if err != nil && !k8serrors.IsNotFound(err) {
@@ -60,11 +61,17 @@ func writeClusterStoresChange(ctx context.Context, keychain authn.Keychain, kpCo
 		}
 	}
 
+	for _, store := range prunedStores {
+		if err := writePrunedDiff(toDescriptorClusterStore(store), differ, cw); err != nil {
+			return err
+		}
+	}
+
 	cw.writeChange("ClusterStores")
 	return nil
 }
 
-func writeClusterStacksChange(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, stacks []ClusterStack, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
+func writeClusterStacksChange(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, stacks []ClusterStack, prunedStacks []v1alpha2.ClusterStack, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
 	for _, stack := range stacks {
 		oldStack, err := cs.KpackClient.KpackV1alpha2().ClusterStacks().Get(ctx, stack.Name, metav1.GetOptions{})
 		if err != nil && !k8serrors.IsNotFound(err) {
@@ -83,11 +90,17 @@ func writeClusterStacksChange(ctx context.Context, keychain authn.Keychain, kpCo
 		}
 	}
 
+	for _, stack := range prunedStacks {
+		if err := writePrunedDiff(toDescriptorClusterStack(stack), differ, cw); err != nil {
+			return err
+		}
+	}
+
 	cw.writeChange("ClusterStacks")
 	return nil
 }
 
-func writeClusterBuildersChange(ctx context.Context, builders []ClusterBuilder, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
+func writeClusterBuildersChange(ctx context.Context, builders []ClusterBuilder, prunedBuilders []v1alpha2.ClusterBuilder, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
 	for _, builder := range builders {
 		oldBuilder, err := cs.KpackClient.KpackV1alpha2().ClusterBuilders().Get(ctx, builder.Name, metav1.GetOptions{})
 		if err != nil && !k8serrors.IsNotFound(err) {
@@ -106,6 +119,66 @@ func writeClusterBuildersChange(ctx context.Context, builders []ClusterBuilder,
 		}
 	}
 
+	for _, builder := range prunedBuilders {
+		if err := writePrunedDiff(toDescriptorClusterBuilder(builder), differ, cw); err != nil {
+			return err
+		}
+	}
+
 	cw.writeChange("ClusterBuilders")
 	return nil
 }
+
+func writeBuildersChange(ctx context.Context, kpConfig config.KpConfig, builders []Builder, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
+	for _, builder := range builders {
+		oldBuilder, err := cs.KpackClient.KpackV1alpha2().Builders(builder.Namespace).Get(ctx, builder.Name, metav1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if k8serrors.IsNotFound(err) {
+			oldBuilder = nil
+		}
+
+		diff, err := differ.DiffBuilder(kpConfig, oldBuilder, builder)
+		if err != nil {
+			return err
+		}
+		if err = cw.writeDiff(diff); err != nil {
+			return err
+		}
+	}
+
+	cw.writeChange("Builders")
+	return nil
+}
+
+func writeImagesChange(ctx context.Context, images []Image, differ *ImportDiffer, cs buildk8s.ClientSet, cw changeWriter) error {
+	for _, image := range images {
+		oldImage, err := cs.KpackClient.KpackV1alpha2().Images(image.Namespace).Get(ctx, image.Name, metav1.GetOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		if k8serrors.IsNotFound(err) {
+			oldImage = nil
+		}
+
+		diff, err := differ.DiffImage(oldImage, image)
+		if err != nil {
+			return err
+		}
+		if err = cw.writeDiff(diff); err != nil {
+			return err
+		}
+	}
+
+	cw.writeChange("Images")
+	return nil
+}
+
+func writePrunedDiff(old interface{}, differ *ImportDiffer, cw changeWriter) error {
+	diff, err := differ.DiffPruned(old)
+	if err != nil {
+		return err
+	}
+	return cw.writeDiff(diff)
+}
@@ -4,12 +4,14 @@
 package _import
 
 import (
+	"github.com/ghodss/yaml"
 	"github.com/google/go-containerregistry/pkg/name"
 	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 )
 
-const CurrentAPIVersion = "kp.kpack.io/v1alpha3"
+const CurrentAPIVersion = "kp.kpack.io/v1alpha4"
 
 type API struct {
 	Version string `yaml:"apiVersion" json:"apiVersion"`
@@ -18,16 +20,18 @@ type API struct {
 type DependencyDescriptor struct {
 	APIVersion            string           `yaml:"apiVersion" json:"apiVersion"`
 	Kind                  string           `yaml:"kind" json:"kind"`
-	DefaultClusterStack   string           `yaml:"defaultClusterStack" json:"defaultClusterStack"`
-	DefaultClusterBuilder string           `yaml:"defaultClusterBuilder" json:"defaultClusterBuilder"`
+	DefaultClusterStack   string           `yaml:"defaultClusterStack,omitempty" json:"defaultClusterStack,omitempty"`
+	DefaultClusterBuilder string           `yaml:"defaultClusterBuilder,omitempty" json:"defaultClusterBuilder,omitempty"`
 	Lifecycle             Lifecycle        `yaml:"lifecycle" json:"lifecycle"`
 	ClusterStores         []ClusterStore   `yaml:"clusterStores" json:"clusterStores"`
 	ClusterStacks         []ClusterStack   `yaml:"clusterStacks" json:"clusterStacks"`
 	ClusterBuilders       []ClusterBuilder `yaml:"clusterBuilders" json:"clusterBuilders"`
+	Builders              []Builder        `yaml:"builders,omitempty" json:"builders,omitempty"`
+	Images                []Image          `yaml:"images,omitempty" json:"images,omitempty"`
 }
 
 type Source struct {
-	Image string `yaml:"image"`
+	Image string `yaml:"image" json:"image"`
 }
 
 type Lifecycle Source
@@ -35,6 +39,9 @@ type Lifecycle Source
 type ClusterStore struct {
 	Name    string   `yaml:"name" json:"name"`
 	Sources []Source `yaml:"sources" json:"sources"`
+	// ReplaceSources makes the sources of this store replace, rather than
+	// extend, the sources of a store with the same name in an earlier descriptor.
+	ReplaceSources bool `yaml:"replaceSources,omitempty" json:"replaceSources,omitempty"`
 }
 
 type ClusterStack struct {
@@ -50,6 +57,73 @@ type ClusterBuilder struct {
 	Order        []corev1alpha1.OrderEntry `yaml:"order" json:"order"`
 }
 
+// Builder is a namespaced kpack Builder. The tag defaults to one in the
+// default repository when it is not set.
+type Builder struct {
+	Name         string                    `yaml:"name" json:"name"`
+	Namespace    string                    `yaml:"namespace" json:"namespace"`
+	Tag          string                    `yaml:"tag,omitempty" json:"tag,omitempty"`
+	ClusterStack string                    `yaml:"clusterStack" json:"clusterStack"`
+	ClusterStore string                    `yaml:"clusterStore" json:"clusterStore"`
+	Order        []corev1alpha1.OrderEntry `yaml:"order" json:"order"`
+}
+
+// Image is a kpack Image built by either a Builder in its namespace or a
+// ClusterBuilder.
+type Image struct {
+	Name           string                    `yaml:"name" json:"name"`
+	Namespace      string                    `yaml:"namespace" json:"namespace"`
+	Tag            string                    `yaml:"tag" json:"tag"`
+	Source         corev1alpha1.SourceConfig `yaml:"source" json:"source"`
+	Builder        string                    `yaml:"builder,omitempty" json:"builder,omitempty"`
+	ClusterBuilder string                    `yaml:"clusterBuilder,omitempty" json:"clusterBuilder,omitempty"`
+	Env            []corev1.EnvVar           `yaml:"env,omitempty" json:"env,omitempty"`
+}
+
+func ReadDescriptor(rawDescriptor string) (DependencyDescriptor, error) {
+	descriptor, err := parseDescriptor(rawDescriptor)
+	if err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	if err := descriptor.Validate(); err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	return descriptor, nil
+}
+
+func parseDescriptor(rawDescriptor string) (DependencyDescriptor, error) {
+	var api API
+	if err := yaml.Unmarshal([]byte(rawDescriptor), &api); err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	var descriptor DependencyDescriptor
+	switch api.Version {
+	case APIVersionV1:
+		var d1 DependencyDescriptorV1
+		if err := yaml.Unmarshal([]byte(rawDescriptor), &d1); err != nil {
+			return DependencyDescriptor{}, err
+		}
+		descriptor = d1.ToNextVersion()
+	case APIVersionV3:
+		var d3 DependencyDescriptorV3
+		if err := yaml.Unmarshal([]byte(rawDescriptor), &d3); err != nil {
+			return DependencyDescriptor{}, err
+		}
+		descriptor = d3.ToNextVersion()
+	case CurrentAPIVersion:
+		if err := yaml.Unmarshal([]byte(rawDescriptor), &descriptor); err != nil {
+			return DependencyDescriptor{}, err
+		}
+	default:
+		return DependencyDescriptor{}, unsupportedAPIVersionError()
+	}
+
+	return descriptor, nil
+}
+
 func (d DependencyDescriptor) Validate() error {
 	storeSet := map[string]interface{}{}
 	for _, store := range d.ClusterStores {
@@ -100,6 +174,68 @@ func (d DependencyDescriptor) Validate() error {
 		return errors.Errorf("default cluster builder '%s' not found", d.DefaultClusterBuilder)
 	}
 
+	builderSet := map[string]interface{}{}
+	for _, builder := range d.Builders {
+		if builder.Namespace == "" {
+			return errors.Errorf("builder '%s' must have a namespace", builder.Name)
+		}
+
+		key := builder.Namespace + "/" + builder.Name
+		if _, ok := builderSet[key]; ok {
+			return errors.Errorf("duplicate builder name '%s'", key)
+		}
+		builderSet[key] = nil
+
+		if builder.Tag != "" {
+			if _, err := name.ParseReference(builder.Tag, name.WeakValidation); err != nil {
+				return err
+			}
+		}
+	}
+
+	imageSet := map[string]interface{}{}
+	for _, image := range d.Images {
+		if image.Namespace == "" {
+			return errors.Errorf("image '%s' must have a namespace", image.Name)
+		}
+
+		key := image.Namespace + "/" + image.Name
+		if _, ok := imageSet[key]; ok {
+			return errors.Errorf("duplicate image name '%s'", key)
+		}
+		imageSet[key] = nil
+
+		if _, err := name.ParseReference(image.Tag, name.WeakValidation); err != nil {
+			return err
+		}
+
+		if err := validateImageSource(key, image.Source); err != nil {
+			return err
+		}
+
+		if (image.Builder == "") == (image.ClusterBuilder == "") {
+			return errors.Errorf("image '%s' must have exactly one of builder or clusterBuilder", key)
+		}
+	}
+
+	return nil
+}
+
+func validateImageSource(key string, source corev1alpha1.SourceConfig) error {
+	sources := 0
+	if source.Git != nil {
+		sources++
+	}
+	if source.Blob != nil {
+		sources++
+	}
+	if source.Registry != nil {
+		sources++
+	}
+
+	if sources != 1 {
+		return errors.Errorf("image '%s' source must be one of git, blob, or registry", key)
+	}
 	return nil
 }
 
@@ -115,7 +251,7 @@ func (d DependencyDescriptor) GetClusterStacks() []ClusterStack {
 	for _, stack := range d.ClusterStacks {
 		if stack.Name == d.DefaultClusterStack {
 			d.ClusterStacks = append(d.ClusterStacks, ClusterStack{
-				Name:       "default",
+				Name:       defaultResourceName,
 				BuildImage: stack.BuildImage,
 				RunImage:   stack.RunImage,
 			})
@@ -129,7 +265,7 @@ func (d DependencyDescriptor) GetClusterBuilders() []ClusterBuilder {
 	for _, cb := range d.ClusterBuilders {
 		if cb.Name == d.DefaultClusterBuilder {
 			d.ClusterBuilders = append(d.ClusterBuilders, ClusterBuilder{
-				Name:         "default",
+				Name:         defaultResourceName,
 				ClusterStack: cb.ClusterStack,
 				ClusterStore: cb.ClusterStore,
 				Order:        cb.Order,
@@ -139,3 +275,31 @@ func (d DependencyDescriptor) GetClusterBuilders() []ClusterBuilder {
 	}
 	return d.ClusterBuilders
 }
+
+// ImageRefs returns every image referenced by the descriptor without duplicates,
+// in the order they are relocated during an import.
+func (d DependencyDescriptor) ImageRefs() []string {
+	var (
+		images []string
+		seen   = map[string]struct{}{}
+	)
+	add := func(image string) {
+		if _, ok := seen[image]; ok || image == "" {
+			return
+		}
+		seen[image] = struct{}{}
+		images = append(images, image)
+	}
+
+	add(d.Lifecycle.Image)
+	for _, store := range d.ClusterStores {
+		for _, src := range store.Sources {
+			add(src.Image)
+		}
+	}
+	for _, stack := range d.ClusterStacks {
+		add(stack.BuildImage.Image)
+		add(stack.RunImage.Image)
+	}
+	return images
+}
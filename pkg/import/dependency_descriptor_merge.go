@@ -0,0 +1,270 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"github.com/ghodss/yaml"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ReadDescriptors reads one or more dependency descriptors and merges each one
+// over the ones before it. Only the merged descriptor is validated, so later
+// descriptors may be partial overlays of earlier ones.
+func ReadDescriptors(rawDescriptors ...string) (DependencyDescriptor, error) {
+	var merged DependencyDescriptor
+	for i, rawDescriptor := range rawDescriptors {
+		descriptor, err := parseDescriptor(rawDescriptor)
+		if err != nil {
+			return DependencyDescriptor{}, err
+		}
+
+		if i == 0 {
+			merged = descriptor
+		} else {
+			merged = merged.Merge(descriptor)
+		}
+	}
+
+	merged.APIVersion = CurrentAPIVersion
+	merged.Kind = "DependencyDescriptor"
+	for i := range merged.ClusterStores {
+		merged.ClusterStores[i].ReplaceSources = false
+	}
+
+	if err := merged.Validate(); err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	return merged, nil
+}
+
+// MergeRawDescriptors merges rawDescriptors with ReadDescriptors and returns
+// the effective descriptor as yaml. A single descriptor is returned as is.
+func MergeRawDescriptors(rawDescriptors ...string) (string, error) {
+	if len(rawDescriptors) == 1 {
+		return rawDescriptors[0], nil
+	}
+
+	descriptor, err := ReadDescriptors(rawDescriptors...)
+	if err != nil {
+		return "", err
+	}
+
+	buf, err := yaml.Marshal(descriptor)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+// Merge returns d with overlay merged over it. Resources are matched by name:
+// stores append the overlay sources they do not already have, or replace them
+// when the overlay store sets replaceSources; stacks replace the images the
+// overlay sets; builders replace the stack, store, and order the overlay sets,
+// and namespaced builders also the tag; images replace the tag, source,
+// builder, and env the overlay sets. Namespaced builders and images are matched
+// by namespace and name.
+// Resources only in overlay are appended.
+func (d DependencyDescriptor) Merge(overlay DependencyDescriptor) DependencyDescriptor {
+	merged := d.DeepCopy()
+
+	if overlay.DefaultClusterStack != "" {
+		merged.DefaultClusterStack = overlay.DefaultClusterStack
+	}
+	if overlay.DefaultClusterBuilder != "" {
+		merged.DefaultClusterBuilder = overlay.DefaultClusterBuilder
+	}
+	if overlay.Lifecycle.Image != "" {
+		merged.Lifecycle = overlay.Lifecycle
+	}
+
+	for _, store := range overlay.ClusterStores {
+		i := findClusterStore(merged.ClusterStores, store.Name)
+		if i < 0 {
+			merged.ClusterStores = append(merged.ClusterStores, store)
+			continue
+		}
+
+		if store.ReplaceSources {
+			merged.ClusterStores[i].Sources = append([]Source{}, store.Sources...)
+			continue
+		}
+
+		for _, src := range store.Sources {
+			if !containsSource(merged.ClusterStores[i].Sources, src) {
+				merged.ClusterStores[i].Sources = append(merged.ClusterStores[i].Sources, src)
+			}
+		}
+	}
+
+	for _, stack := range overlay.ClusterStacks {
+		i := findClusterStack(merged.ClusterStacks, stack.Name)
+		if i < 0 {
+			merged.ClusterStacks = append(merged.ClusterStacks, stack)
+			continue
+		}
+
+		if stack.BuildImage.Image != "" {
+			merged.ClusterStacks[i].BuildImage = stack.BuildImage
+		}
+		if stack.RunImage.Image != "" {
+			merged.ClusterStacks[i].RunImage = stack.RunImage
+		}
+	}
+
+	for _, builder := range overlay.ClusterBuilders {
+		i := findClusterBuilder(merged.ClusterBuilders, builder.Name)
+		if i < 0 {
+			merged.ClusterBuilders = append(merged.ClusterBuilders, builder)
+			continue
+		}
+
+		if builder.ClusterStack != "" {
+			merged.ClusterBuilders[i].ClusterStack = builder.ClusterStack
+		}
+		if builder.ClusterStore != "" {
+			merged.ClusterBuilders[i].ClusterStore = builder.ClusterStore
+		}
+		if len(builder.Order) > 0 {
+			merged.ClusterBuilders[i].Order = builder.Order
+		}
+	}
+
+	for _, builder := range overlay.Builders {
+		i := findBuilder(merged.Builders, builder.Namespace, builder.Name)
+		if i < 0 {
+			merged.Builders = append(merged.Builders, builder)
+			continue
+		}
+
+		if builder.Tag != "" {
+			merged.Builders[i].Tag = builder.Tag
+		}
+		if builder.ClusterStack != "" {
+			merged.Builders[i].ClusterStack = builder.ClusterStack
+		}
+		if builder.ClusterStore != "" {
+			merged.Builders[i].ClusterStore = builder.ClusterStore
+		}
+		if len(builder.Order) > 0 {
+			merged.Builders[i].Order = builder.Order
+		}
+	}
+
+	for _, image := range overlay.Images {
+		i := findImage(merged.Images, image.Namespace, image.Name)
+		if i < 0 {
+			merged.Images = append(merged.Images, image)
+			continue
+		}
+
+		if image.Tag != "" {
+			merged.Images[i].Tag = image.Tag
+		}
+		if image.Source.Git != nil || image.Source.Blob != nil || image.Source.Registry != nil {
+			merged.Images[i].Source = image.Source
+		}
+		if image.Builder != "" || image.ClusterBuilder != "" {
+			merged.Images[i].Builder = image.Builder
+			merged.Images[i].ClusterBuilder = image.ClusterBuilder
+		}
+		if len(image.Env) > 0 {
+			merged.Images[i].Env = image.Env
+		}
+	}
+
+	return merged
+}
+
+// DeepCopy returns a copy of d that shares no slices with it.
+func (d DependencyDescriptor) DeepCopy() DependencyDescriptor {
+	c := d
+	c.ClusterStores = nil
+	for _, store := range d.ClusterStores {
+		store.Sources = append([]Source(nil), store.Sources...)
+		c.ClusterStores = append(c.ClusterStores, store)
+	}
+	c.ClusterStacks = append([]ClusterStack(nil), d.ClusterStacks...)
+	c.ClusterBuilders = nil
+	for _, builder := range d.ClusterBuilders {
+		var order []corev1alpha1.OrderEntry
+		for _, entry := range builder.Order {
+			order = append(order, *entry.DeepCopy())
+		}
+		builder.Order = order
+		c.ClusterBuilders = append(c.ClusterBuilders, builder)
+	}
+	c.Builders = nil
+	for _, builder := range d.Builders {
+		var order []corev1alpha1.OrderEntry
+		for _, entry := range builder.Order {
+			order = append(order, *entry.DeepCopy())
+		}
+		builder.Order = order
+		c.Builders = append(c.Builders, builder)
+	}
+	c.Images = nil
+	for _, image := range d.Images {
+		image.Source = *image.Source.DeepCopy()
+		image.Env = append([]corev1.EnvVar(nil), image.Env...)
+		c.Images = append(c.Images, image)
+	}
+	return c
+}
+
+func findClusterStore(stores []ClusterStore, name string) int {
+	for i, store := range stores {
+		if store.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func findClusterStack(stacks []ClusterStack, name string) int {
+	for i, stack := range stacks {
+		if stack.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func findClusterBuilder(builders []ClusterBuilder, name string) int {
+	for i, builder := range builders {
+		if builder.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func findBuilder(builders []Builder, namespace, name string) int {
+	for i, builder := range builders {
+		if builder.Namespace == namespace && builder.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func findImage(images []Image, namespace, name string) int {
+	for i, image := range images {
+		if image.Namespace == namespace && image.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func containsSource(sources []Source, src Source) bool {
+	for _, s := range sources {
+		if s.Image == src.Image {
+			return true
+		}
+	}
+	return false
+}
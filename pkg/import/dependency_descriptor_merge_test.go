@@ -0,0 +1,275 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"testing"
+
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+)
+
+func TestDescriptorMerge(t *testing.T) {
+	spec.Run(t, "TestDescriptorMerge", testDescriptorMerge)
+}
+
+func testDescriptorMerge(t *testing.T, when spec.G, it spec.S) {
+	const base = `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+defaultClusterBuilder: base
+defaultClusterStack: some-stack
+lifecycle:
+  image: some-registry.io/lifecycle
+clusterStores:
+- name: some-store
+  sources:
+  - image: some-registry.io/buildpack-a
+clusterStacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/build
+  runImage:
+    image: some-registry.io/run
+clusterBuilders:
+- name: base
+  clusterStack: some-stack
+  clusterStore: some-store
+  order:
+  - group:
+    - id: buildpack-a
+`
+
+	when("ReadDescriptors", func() {
+		it("returns a single descriptor at the current apiVersion", func() {
+			descriptor, err := importpkg.ReadDescriptors(base)
+			require.NoError(t, err)
+
+			expected, err := importpkg.ReadDescriptor(base)
+			require.NoError(t, err)
+			expected.APIVersion = importpkg.CurrentAPIVersion
+			require.Equal(t, expected, descriptor)
+		})
+
+		it("appends store sources and resources that are not in earlier descriptors", func() {
+			descriptor, err := importpkg.ReadDescriptors(base, `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+clusterStores:
+- name: some-store
+  sources:
+  - image: some-registry.io/buildpack-a
+  - image: some-registry.io/buildpack-b
+- name: other-store
+  sources:
+  - image: some-registry.io/buildpack-c
+`)
+			require.NoError(t, err)
+
+			require.Equal(t, []importpkg.ClusterStore{
+				{
+					Name: "some-store",
+					Sources: []importpkg.Source{
+						{Image: "some-registry.io/buildpack-a"},
+						{Image: "some-registry.io/buildpack-b"},
+					},
+				},
+				{
+					Name: "other-store",
+					Sources: []importpkg.Source{
+						{Image: "some-registry.io/buildpack-c"},
+					},
+				},
+			}, descriptor.ClusterStores)
+		})
+
+		it("replaces store sources when replaceSources is set", func() {
+			descriptor, err := importpkg.ReadDescriptors(base, `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+clusterStores:
+- name: some-store
+  replaceSources: true
+  sources:
+  - image: some-registry.io/buildpack-b
+`)
+			require.NoError(t, err)
+
+			require.Equal(t, []importpkg.ClusterStore{
+				{
+					Name: "some-store",
+					Sources: []importpkg.Source{
+						{Image: "some-registry.io/buildpack-b"},
+					},
+				},
+			}, descriptor.ClusterStores)
+		})
+
+		it("replaces the fields that later descriptors set", func() {
+			descriptor, err := importpkg.ReadDescriptors(base, `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+defaultClusterBuilder: prod
+clusterStacks:
+- name: some-stack
+  runImage:
+    image: some-registry.io/new-run
+clusterBuilders:
+- name: base
+  order:
+  - group:
+    - id: buildpack-b
+- name: prod
+  clusterStack: some-stack
+  clusterStore: some-store
+  order:
+  - group:
+    - id: buildpack-a
+`)
+			require.NoError(t, err)
+
+			require.Equal(t, "prod", descriptor.DefaultClusterBuilder)
+			require.Equal(t, "some-stack", descriptor.DefaultClusterStack)
+			require.Equal(t, "some-registry.io/lifecycle", descriptor.Lifecycle.Image)
+			require.Equal(t, []importpkg.ClusterStack{
+				{
+					Name:       "some-stack",
+					BuildImage: importpkg.Source{Image: "some-registry.io/build"},
+					RunImage:   importpkg.Source{Image: "some-registry.io/new-run"},
+				},
+			}, descriptor.ClusterStacks)
+			require.Equal(t, []importpkg.ClusterBuilder{
+				{
+					Name:         "base",
+					ClusterStack: "some-stack",
+					ClusterStore: "some-store",
+					Order:        []corev1alpha1.OrderEntry{{Group: []corev1alpha1.BuildpackRef{{BuildpackInfo: corev1alpha1.BuildpackInfo{Id: "buildpack-b"}}}}},
+				},
+				{
+					Name:         "prod",
+					ClusterStack: "some-stack",
+					ClusterStore: "some-store",
+					Order:        []corev1alpha1.OrderEntry{{Group: []corev1alpha1.BuildpackRef{{BuildpackInfo: corev1alpha1.BuildpackInfo{Id: "buildpack-a"}}}}},
+				},
+			}, descriptor.ClusterBuilders)
+		})
+
+		it("merges builders and images by namespace and name", func() {
+			descriptor, err := importpkg.ReadDescriptors(`apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+builders:
+- name: some-builder
+  namespace: team-a
+  clusterStack: some-stack
+  clusterStore: some-store
+images:
+- name: some-image
+  namespace: team-a
+  tag: some-registry.io/team-a/some-image
+  source:
+    git:
+      url: https://github.com/some/repo
+      revision: main
+  builder: some-builder
+`, `apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+builders:
+- name: some-builder
+  namespace: team-a
+  tag: some-registry.io/team-a/builder
+- name: some-builder
+  namespace: team-b
+  clusterStack: some-stack
+  clusterStore: some-store
+images:
+- name: some-image
+  namespace: team-a
+  clusterBuilder: base
+  env:
+  - name: BP_DEBUG
+    value: "true"
+`)
+			require.NoError(t, err)
+
+			require.Equal(t, []importpkg.Builder{
+				{Name: "some-builder", Namespace: "team-a", Tag: "some-registry.io/team-a/builder", ClusterStack: "some-stack", ClusterStore: "some-store"},
+				{Name: "some-builder", Namespace: "team-b", ClusterStack: "some-stack", ClusterStore: "some-store"},
+			}, descriptor.Builders)
+			require.Equal(t, []importpkg.Image{
+				{
+					Name:           "some-image",
+					Namespace:      "team-a",
+					Tag:            "some-registry.io/team-a/some-image",
+					Source:         corev1alpha1.SourceConfig{Git: &corev1alpha1.Git{URL: "https://github.com/some/repo", Revision: "main"}},
+					ClusterBuilder: "base",
+					Env:            []corev1.EnvVar{{Name: "BP_DEBUG", Value: "true"}},
+				},
+			}, descriptor.Images)
+		})
+
+		it("converts earlier api versions before merging", func() {
+			descriptor, err := importpkg.ReadDescriptors(`apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyDescriptor
+defaultStack: some-stack
+stores:
+- name: some-store
+  sources:
+  - image: some-registry.io/buildpack-a
+stacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/build
+  runImage:
+    image: some-registry.io/run
+`, `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+clusterStores:
+- name: some-store
+  sources:
+  - image: some-registry.io/buildpack-b
+`)
+			require.NoError(t, err)
+
+			require.Equal(t, importpkg.CurrentAPIVersion, descriptor.APIVersion)
+			require.Len(t, descriptor.ClusterStores[0].Sources, 2)
+		})
+
+		it("validates only the merged descriptor", func() {
+			_, err := importpkg.ReadDescriptors(`apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+defaultClusterStack: some-stack
+`, base)
+			require.NoError(t, err)
+
+			_, err = importpkg.ReadDescriptors(base, `apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+defaultClusterBuilder: missing
+`)
+			require.EqualError(t, err, "default cluster builder 'missing' not found")
+		})
+
+		it("errors on an unexpected api version in any descriptor", func() {
+			_, err := importpkg.ReadDescriptors(base, "apiVersion: invalid")
+			require.EqualError(t, err, "did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3 kp.kpack.io/v1alpha4]")
+		})
+	})
+
+	when("Merge", func() {
+		it("does not modify the descriptor it is called on", func() {
+			descriptor, err := importpkg.ReadDescriptor(base)
+			require.NoError(t, err)
+
+			_ = descriptor.Merge(importpkg.DependencyDescriptor{
+				ClusterStores: []importpkg.ClusterStore{
+					{
+						Name:    "some-store",
+						Sources: []importpkg.Source{{Image: "some-registry.io/buildpack-b"}},
+					},
+				},
+			})
+
+			require.Len(t, descriptor.ClusterStores[0].Sources, 1)
+		})
+	})
+}
@@ -132,6 +132,92 @@ func testDescriptor(t *testing.T, when spec.G, it spec.S) {
 				require.NoError(t, desc.Validate())
 			})
 		})
+
+		when("there are builders and images", func() {
+			desc.Builders = []importpkg.Builder{
+				{Name: "some-builder", Namespace: "some-namespace", ClusterStack: "some-stack", ClusterStore: "some-store"},
+			}
+			desc.Images = []importpkg.Image{
+				{
+					Name:      "some-image",
+					Namespace: "some-namespace",
+					Tag:       "some-registry.io/some-image",
+					Source:    corev1alpha1.SourceConfig{Git: &corev1alpha1.Git{URL: "https://github.com/some/repo", Revision: "main"}},
+					Builder:   "some-builder",
+				},
+			}
+
+			it("validates successfully", func() {
+				require.NoError(t, desc.Validate())
+			})
+
+			it("fails validation for a builder without a namespace", func() {
+				desc.Builders[0].Namespace = ""
+				require.EqualError(t, desc.Validate(), "builder 'some-builder' must have a namespace")
+			})
+
+			it("fails validation for a duplicate builder", func() {
+				desc.Builders = append(desc.Builders, desc.Builders[0])
+				require.EqualError(t, desc.Validate(), "duplicate builder name 'some-namespace/some-builder'")
+			})
+
+			it("fails validation for a duplicate image", func() {
+				desc.Images = append(desc.Images, desc.Images[0])
+				require.EqualError(t, desc.Validate(), "duplicate image name 'some-namespace/some-image'")
+			})
+
+			it("fails validation for an image without exactly one source", func() {
+				desc.Images[0].Source.Blob = &corev1alpha1.Blob{URL: "https://some-blob.io/source.zip"}
+				require.EqualError(t, desc.Validate(), "image 'some-namespace/some-image' source must be one of git, blob, or registry")
+			})
+
+			it("fails validation for an image without exactly one builder", func() {
+				desc.Images[0].ClusterBuilder = "some-cb"
+				require.EqualError(t, desc.Validate(), "image 'some-namespace/some-image' must have exactly one of builder or clusterBuilder")
+			})
+		})
+	})
+
+	when("#ReadDescriptor", func() {
+		const builders = `
+builders:
+- name: some-builder
+  namespace: some-namespace
+  clusterStack: some-stack
+  clusterStore: some-store
+images:
+- name: some-image
+  namespace: some-namespace
+  tag: some-registry.io/some-image
+  source:
+    blob:
+      url: https://some-blob.io/source.zip
+  clusterBuilder: some-cb
+`
+
+		it("reads builders and images from the current version", func() {
+			d, err := importpkg.ReadDescriptor("apiVersion: kp.kpack.io/v1alpha4\nkind: DependencyDescriptor\n" + builders)
+			require.NoError(t, err)
+			require.Equal(t, []importpkg.Builder{
+				{Name: "some-builder", Namespace: "some-namespace", ClusterStack: "some-stack", ClusterStore: "some-store"},
+			}, d.Builders)
+			require.Equal(t, []importpkg.Image{
+				{
+					Name:           "some-image",
+					Namespace:      "some-namespace",
+					Tag:            "some-registry.io/some-image",
+					Source:         corev1alpha1.SourceConfig{Blob: &corev1alpha1.Blob{URL: "https://some-blob.io/source.zip"}},
+					ClusterBuilder: "some-cb",
+				},
+			}, d.Images)
+		})
+
+		it("does not read builders or images from v1alpha3", func() {
+			d, err := importpkg.ReadDescriptor("apiVersion: kp.kpack.io/v1alpha3\nkind: DependencyDescriptor\n" + builders)
+			require.NoError(t, err)
+			require.Empty(t, d.Builders)
+			require.Empty(t, d.Images)
+		})
 	})
 
 	when("#GetClusterStacks", func() {
@@ -154,4 +240,17 @@ func testDescriptor(t *testing.T, when spec.G, it spec.S) {
 			require.Equal(t, expectedBuilders, builders)
 		})
 	})
+
+	when("#ImageRefs", func() {
+		it("returns every referenced image once", func() {
+			desc.Lifecycle.Image = "lifecycle-image"
+			desc.ClusterStacks = append(desc.ClusterStacks, importpkg.ClusterStack{
+				Name:       "another-stack",
+				BuildImage: importpkg.Source{Image: "build-image"},
+				RunImage:   importpkg.Source{Image: "another-run-image"},
+			})
+
+			require.Equal(t, []string{"lifecycle-image", "some-store-image", "build-image", "run-image", "another-run-image"}, desc.ImageRefs())
+		})
+	})
 }
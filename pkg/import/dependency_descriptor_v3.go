@@ -0,0 +1,30 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+const APIVersionV3 = "kp.kpack.io/v1alpha3"
+
+type DependencyDescriptorV3 struct {
+	APIVersion            string           `yaml:"apiVersion" json:"apiVersion"`
+	Kind                  string           `yaml:"kind" json:"kind"`
+	DefaultClusterStack   string           `yaml:"defaultClusterStack,omitempty" json:"defaultClusterStack,omitempty"`
+	DefaultClusterBuilder string           `yaml:"defaultClusterBuilder,omitempty" json:"defaultClusterBuilder,omitempty"`
+	Lifecycle             Lifecycle        `yaml:"lifecycle" json:"lifecycle"`
+	ClusterStores         []ClusterStore   `yaml:"clusterStores" json:"clusterStores"`
+	ClusterStacks         []ClusterStack   `yaml:"clusterStacks" json:"clusterStacks"`
+	ClusterBuilders       []ClusterBuilder `yaml:"clusterBuilders" json:"clusterBuilders"`
+}
+
+func (d3 DependencyDescriptorV3) ToNextVersion() DependencyDescriptor {
+	return DependencyDescriptor{
+		APIVersion:            d3.APIVersion,
+		Kind:                  d3.Kind,
+		DefaultClusterStack:   d3.DefaultClusterStack,
+		DefaultClusterBuilder: d3.DefaultClusterBuilder,
+		Lifecycle:             d3.Lifecycle,
+		ClusterStores:         d3.ClusterStores,
+		ClusterStacks:         d3.ClusterStacks,
+		ClusterBuilders:       d3.ClusterBuilders,
+	}
+}
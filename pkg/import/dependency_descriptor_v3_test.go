@@ -0,0 +1,44 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+)
+
+func TestDescriptorV3(t *testing.T) {
+	spec.Run(t, "TestDescriptorV3", testDescriptorV3)
+}
+
+func testDescriptorV3(t *testing.T, when spec.G, it spec.S) {
+	when("#ToNextVersion", func() {
+		descV3 := importpkg.DependencyDescriptorV3{
+			APIVersion:          importpkg.APIVersionV3,
+			Kind:                "DependencyDescriptor",
+			DefaultClusterStack: "some-stack",
+			Lifecycle:           importpkg.Lifecycle{Image: "some-lifecycle"},
+			ClusterStacks: []importpkg.ClusterStack{
+				{
+					Name:       "some-stack",
+					BuildImage: importpkg.Source{Image: "build-image"},
+					RunImage:   importpkg.Source{Image: "run-image"},
+				},
+			},
+		}
+
+		it("converts successfully", func() {
+			d := descV3.ToNextVersion()
+			require.NoError(t, d.Validate())
+			require.Equal(t, descV3.ClusterStacks, d.ClusterStacks)
+			require.Equal(t, descV3.Lifecycle, d.Lifecycle)
+			require.Empty(t, d.Builders)
+			require.Empty(t, d.Images)
+		})
+	})
+}
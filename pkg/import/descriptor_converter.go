@@ -0,0 +1,250 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertedDescriptor is a descriptor converted to the current apiVersion.
+// Notes describe every change a conversion step made and any change in how
+// kp import reads the descriptor.
+type ConvertedDescriptor struct {
+	From       string
+	To         string
+	Descriptor string
+	Notes      []string
+}
+
+// descriptorConversion converts a descriptor from one apiVersion to the next.
+// Adding a descriptor version means adding a conversion from the previous
+// current version, so that every older version keeps converting in steps.
+type descriptorConversion struct {
+	from    string
+	to      string
+	fields  interface{}
+	convert func(root *yaml.Node) ([]descriptorEdit, []string, error)
+}
+
+var descriptorConversions = []descriptorConversion{
+	{from: APIVersionV1, to: APIVersionV3, fields: DependencyDescriptorV1{}, convert: convertV1ToV3},
+	{from: APIVersionV3, to: CurrentAPIVersion, fields: DependencyDescriptorV3{}, convert: noConversion},
+}
+
+// descriptorEdit replaces the scalar old at a line and column, both starting
+// at 1, of a descriptor with new. Editing the text rather than re-encoding it
+// keeps the field order, formatting, and comments of the descriptor.
+type descriptorEdit struct {
+	line   int
+	column int
+	old    string
+	new    string
+}
+
+// ConvertDescriptor converts a descriptor of any supported apiVersion to the
+// current apiVersion one step at a time.
+func ConvertDescriptor(rawDescriptor string) (ConvertedDescriptor, error) {
+	if _, err := ReadDescriptor(rawDescriptor); err != nil {
+		return ConvertedDescriptor{}, err
+	}
+
+	var api API
+	if err := yaml.Unmarshal([]byte(rawDescriptor), &api); err != nil {
+		return ConvertedDescriptor{}, err
+	}
+
+	result := ConvertedDescriptor{From: api.Version, To: api.Version, Descriptor: rawDescriptor}
+	for _, conversion := range descriptorConversions {
+		if conversion.from != result.To {
+			continue
+		}
+
+		converted, notes, err := conversion.apply(result.Descriptor)
+		if err != nil {
+			return ConvertedDescriptor{}, errors.Wrapf(err, "converting from %s to %s", conversion.from, conversion.to)
+		}
+
+		result.Descriptor = converted
+		result.To = conversion.to
+		result.Notes = append(result.Notes, notes...)
+	}
+
+	if _, err := ReadDescriptor(result.Descriptor); err != nil {
+		return ConvertedDescriptor{}, errors.Wrap(err, "converted descriptor is invalid")
+	}
+
+	return result, nil
+}
+
+func (c descriptorConversion) apply(rawDescriptor string) (string, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(rawDescriptor), &doc); err != nil {
+		return "", nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return "", nil, errors.New("descriptor must be a mapping")
+	}
+	root := doc.Content[0]
+
+	_, version := mappingEntry(root, "apiVersion")
+	edits := []descriptorEdit{{line: version.Line, column: version.Column, old: version.Value, new: c.to}}
+
+	stepEdits, notes, err := c.convert(root)
+	if err != nil {
+		return "", nil, err
+	}
+	edits = append(edits, stepEdits...)
+	notes = append(notes, c.activatedFields(root)...)
+
+	converted, err := applyEdits(rawDescriptor, edits)
+	return converted, notes, err
+}
+
+// activatedFields reports top-level fields that the from version ignores but
+// the to version reads, because kp import will start acting on them.
+func (c descriptorConversion) activatedFields(root *yaml.Node) []string {
+	fromFields, toFields := descriptorFields(c.from), descriptorFields(c.to)
+
+	var notes []string
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i].Value
+		if !fromFields[key] && toFields[key] {
+			notes = append(notes, fmt.Sprintf("'%s' was ignored by %s and is imported by %s", key, c.from, c.to))
+		}
+	}
+	return notes
+}
+
+// descriptorFields returns the top-level fields of a descriptor apiVersion.
+func descriptorFields(version string) map[string]bool {
+	descriptor := interface{}(DependencyDescriptor{})
+	for _, c := range descriptorConversions {
+		if c.from == version {
+			descriptor = c.fields
+		}
+	}
+
+	fields := map[string]bool{}
+	t := reflect.TypeOf(descriptor)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+func noConversion(*yaml.Node) ([]descriptorEdit, []string, error) {
+	return nil, nil, nil
+}
+
+func convertV1ToV3(root *yaml.Node) ([]descriptorEdit, []string, error) {
+	var (
+		edits []descriptorEdit
+		notes []string
+	)
+
+	rename := func(mapping *yaml.Node, old, new string) (bool, error) {
+		key, _ := mappingEntry(mapping, old)
+		if key == nil {
+			return false, nil
+		}
+		if existing, _ := mappingEntry(mapping, new); existing != nil {
+			return false, errors.Errorf("descriptor sets both '%s' and '%s' at line %d", old, new, existing.Line)
+		}
+		edits = append(edits, descriptorEdit{line: key.Line, column: key.Column, old: old, new: new})
+		return true, nil
+	}
+
+	if ok, err := rename(root, "defaultStack", "defaultClusterStack"); err != nil {
+		return nil, nil, err
+	} else if ok {
+		_, value := mappingEntry(root, "defaultStack")
+		notes = append(notes, fmt.Sprintf("renamed 'defaultStack' to 'defaultClusterStack': ClusterStack 'default' is still created from stack '%s'", value.Value))
+	}
+
+	if _, value := mappingEntry(root, "defaultClusterBuilder"); value != nil && value.Value != "" {
+		notes = append(notes, fmt.Sprintf("kept 'defaultClusterBuilder': ClusterBuilder 'default' is still created from clusterBuilder '%s'", value.Value))
+	}
+
+	for _, r := range [][2]string{{"stores", "clusterStores"}, {"stacks", "clusterStacks"}} {
+		if ok, err := rename(root, r[0], r[1]); err != nil {
+			return nil, nil, err
+		} else if ok {
+			notes = append(notes, fmt.Sprintf("renamed '%s' to '%s'", r[0], r[1]))
+		}
+	}
+
+	renamedBuilderFields := false
+	if _, builders := mappingEntry(root, "clusterBuilders"); builders != nil && builders.Kind == yaml.SequenceNode {
+		for _, builder := range builders.Content {
+			if builder.Kind != yaml.MappingNode {
+				continue
+			}
+			for _, r := range [][2]string{{"stack", "clusterStack"}, {"store", "clusterStore"}} {
+				ok, err := rename(builder, r[0], r[1])
+				if err != nil {
+					return nil, nil, err
+				}
+				renamedBuilderFields = renamedBuilderFields || ok
+			}
+		}
+	}
+	if renamedBuilderFields {
+		notes = append(notes, "renamed 'stack' and 'store' to 'clusterStack' and 'clusterStore' in clusterBuilders")
+	}
+
+	return edits, notes, nil
+}
+
+func mappingEntry(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func applyEdits(rawDescriptor string, edits []descriptorEdit) (string, error) {
+	sort.Slice(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line > edits[j].line
+		}
+		return edits[i].column > edits[j].column
+	})
+
+	lines := strings.SplitAfter(rawDescriptor, "\n")
+	for _, e := range edits {
+		if e.line < 1 || e.line > len(lines) {
+			return "", errors.Errorf("cannot convert '%s' at line %d", e.old, e.line)
+		}
+
+		line := []rune(lines[e.line-1])
+		start := e.column - 1
+		if start < 0 || start > len(line) {
+			return "", errors.Errorf("cannot convert '%s' at line %d", e.old, e.line)
+		}
+
+		rest := string(line[start:])
+		if strings.HasPrefix(rest, `"`) || strings.HasPrefix(rest, `'`) {
+			start++
+			rest = rest[1:]
+		}
+		if !strings.HasPrefix(rest, e.old) {
+			return "", errors.Errorf("cannot convert '%s' at line %d", e.old, e.line)
+		}
+
+		lines[e.line-1] = string(line[:start]) + e.new + rest[len(e.old):]
+	}
+
+	return strings.Join(lines, ""), nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+)
+
+func TestDescriptorConverter(t *testing.T) {
+	spec.Run(t, "TestDescriptorConverter", testDescriptorConverter)
+}
+
+func testDescriptorConverter(t *testing.T, when spec.G, it spec.S) {
+	when("the descriptor is v1alpha1", func() {
+		it("converts it to the current apiVersion keeping order and comments", func() {
+			converted, err := importpkg.ConvertDescriptor(`# platform dependencies
+apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyDescriptor
+defaultClusterBuilder: some-builder # used by most images
+defaultStack: some-stack
+stores:
+- name: some-store
+  sources:
+  - image: some-registry.io/repo/buildpack
+stacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/repo/build
+  runImage:
+    image: some-registry.io/repo/run
+clusterBuilders:
+- name: some-builder
+  # the store goes first
+  store: some-store
+  "stack": some-stack
+  order:
+  - group:
+    - id: some-buildpack
+`)
+			require.NoError(t, err)
+			require.Equal(t, importpkg.APIVersionV1, converted.From)
+			require.Equal(t, importpkg.CurrentAPIVersion, converted.To)
+			require.Equal(t, `# platform dependencies
+apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+defaultClusterBuilder: some-builder # used by most images
+defaultClusterStack: some-stack
+clusterStores:
+- name: some-store
+  sources:
+  - image: some-registry.io/repo/buildpack
+clusterStacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/repo/build
+  runImage:
+    image: some-registry.io/repo/run
+clusterBuilders:
+- name: some-builder
+  # the store goes first
+  clusterStore: some-store
+  "clusterStack": some-stack
+  order:
+  - group:
+    - id: some-buildpack
+`, converted.Descriptor)
+			require.Equal(t, []string{
+				"renamed 'defaultStack' to 'defaultClusterStack': ClusterStack 'default' is still created from stack 'some-stack'",
+				"kept 'defaultClusterBuilder': ClusterBuilder 'default' is still created from clusterBuilder 'some-builder'",
+				"renamed 'stores' to 'clusterStores'",
+				"renamed 'stacks' to 'clusterStacks'",
+				"renamed 'stack' and 'store' to 'clusterStack' and 'clusterStore' in clusterBuilders",
+			}, converted.Notes)
+
+			descriptor, err := importpkg.ReadDescriptor(converted.Descriptor)
+			require.NoError(t, err)
+			require.Equal(t, "some-stack", descriptor.ClusterBuilders[0].ClusterStack)
+			require.Equal(t, "some-store", descriptor.ClusterBuilders[0].ClusterStore)
+		})
+
+		it("reports fields that were ignored and are now imported", func() {
+			converted, err := importpkg.ConvertDescriptor(`apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyDescriptor
+defaultStack: some-stack
+lifecycle:
+  image: some-registry.io/repo/lifecycle
+stacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/repo/build
+  runImage:
+    image: some-registry.io/repo/run
+`)
+			require.NoError(t, err)
+			require.Contains(t, converted.Notes, "'lifecycle' was ignored by kp.kpack.io/v1alpha1 and is imported by kp.kpack.io/v1alpha3")
+		})
+
+		it("fails when both the old and new field are set", func() {
+			_, err := importpkg.ConvertDescriptor(`apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyDescriptor
+defaultStack: some-stack
+stacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/repo/build
+  runImage:
+    image: some-registry.io/repo/run
+clusterStacks: []
+`)
+			require.EqualError(t, err, "converting from kp.kpack.io/v1alpha1 to kp.kpack.io/v1alpha3: descriptor sets both 'stacks' and 'clusterStacks' at line 10")
+		})
+	})
+
+	when("the descriptor is v1alpha3", func() {
+		it("reports namespaced resources that are now imported", func() {
+			converted, err := importpkg.ConvertDescriptor(`apiVersion: "kp.kpack.io/v1alpha3"
+kind: DependencyDescriptor
+builders:
+- name: some-builder
+  namespace: some-namespace
+  clusterStack: some-stack
+  clusterStore: some-store
+`)
+			require.NoError(t, err)
+			require.Equal(t, `apiVersion: "kp.kpack.io/v1alpha4"
+kind: DependencyDescriptor
+builders:
+- name: some-builder
+  namespace: some-namespace
+  clusterStack: some-stack
+  clusterStore: some-store
+`, converted.Descriptor)
+			require.Equal(t, []string{
+				"'builders' was ignored by kp.kpack.io/v1alpha3 and is imported by kp.kpack.io/v1alpha4",
+			}, converted.Notes)
+		})
+	})
+
+	when("the descriptor is current", func() {
+		it("does not change it", func() {
+			raw := `apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+lifecycle:
+  image: some-registry.io/repo/lifecycle
+`
+			converted, err := importpkg.ConvertDescriptor(raw)
+			require.NoError(t, err)
+			require.Equal(t, importpkg.CurrentAPIVersion, converted.From)
+			require.Equal(t, importpkg.CurrentAPIVersion, converted.To)
+			require.Equal(t, raw, converted.Descriptor)
+			require.Empty(t, converted.Notes)
+		})
+	})
+
+	it("fails for invalid descriptors", func() {
+		_, err := importpkg.ConvertDescriptor("apiVersion: kp.kpack.io/v1alpha2\n")
+		require.EqualError(t, err, "did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3 kp.kpack.io/v1alpha4]")
+	})
+}
@@ -0,0 +1,97 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+// DescriptorLoader reads raw dependency descriptors from stdin, local files,
+// https URLs, and OCI artifacts written with registry.NewDescriptorArtifact.
+type DescriptorLoader struct {
+	Stdin      io.Reader
+	HTTPClient *http.Client
+	Fetcher    registry.Fetcher
+	Keychain   authn.Keychain
+}
+
+// Load reads the descriptor at src. A src of "-" reads stdin. A src that is
+// not a local file is read as an OCI artifact when it is a fully qualified
+// image reference, such as registry.example.com/descriptors:1.0.0.
+func (l DescriptorLoader) Load(src string) (string, error) {
+	switch {
+	case src == "-":
+		return readAll(ioutil.NopCloser(l.Stdin))
+	case strings.HasPrefix(src, "https://"):
+		return l.loadURL(src)
+	case strings.HasPrefix(src, "http://"):
+		return "", errors.Errorf("descriptor url '%s' must use https", src)
+	}
+
+	file, err := os.Open(src)
+	if err == nil {
+		return readAll(file)
+	} else if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	if _, refErr := name.ParseReference(src, name.StrictValidation); refErr != nil {
+		return "", err
+	}
+
+	return l.loadArtifact(src)
+}
+
+func (l DescriptorLoader) loadURL(url string) (string, error) {
+	client := l.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", errors.Wrapf(err, "fetching descriptor '%s'", url)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return "", errors.Errorf("fetching descriptor '%s': %s", url, resp.Status)
+	}
+
+	return readAll(resp.Body)
+}
+
+func (l DescriptorLoader) loadArtifact(ref string) (string, error) {
+	image, err := l.Fetcher.Fetch(l.Keychain, ref)
+	if err != nil {
+		return "", err
+	}
+
+	descriptor, err := registry.ReadDescriptorArtifact(image)
+	if err != nil {
+		return "", errors.Wrapf(err, "reading descriptor '%s'", ref)
+	}
+
+	return string(descriptor), nil
+}
+
+func readAll(rc io.ReadCloser) (string, error) {
+	defer rc.Close()
+
+	buf, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
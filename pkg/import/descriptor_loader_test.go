@@ -0,0 +1,110 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+)
+
+func TestDescriptorLoader(t *testing.T) {
+	spec.Run(t, "TestDescriptorLoader", testDescriptorLoader)
+}
+
+func testDescriptorLoader(t *testing.T, when spec.G, it spec.S) {
+	const descriptor = "apiVersion: kp.kpack.io/v1alpha3\nkind: DependencyDescriptor\n"
+
+	var (
+		fetcher *registryfakes.Fetcher
+		loader  importpkg.DescriptorLoader
+	)
+
+	it.Before(func() {
+		fetcher = &registryfakes.Fetcher{}
+		loader = importpkg.DescriptorLoader{
+			Stdin:    strings.NewReader(descriptor),
+			Fetcher:  fetcher,
+			Keychain: authn.DefaultKeychain,
+		}
+	})
+
+	it("reads stdin", func() {
+		raw, err := loader.Load("-")
+		require.NoError(t, err)
+		require.Equal(t, descriptor, raw)
+	})
+
+	it("reads local files", func() {
+		dir, err := ioutil.TempDir("", "descriptor-loader")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "deps.yaml")
+		require.NoError(t, ioutil.WriteFile(path, []byte(descriptor), 0644))
+
+		raw, err := loader.Load(path)
+		require.NoError(t, err)
+		require.Equal(t, descriptor, raw)
+	})
+
+	it("reads https urls", func() {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/deps.yaml" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte(descriptor))
+		}))
+		defer server.Close()
+		loader.HTTPClient = server.Client()
+
+		raw, err := loader.Load(server.URL + "/deps.yaml")
+		require.NoError(t, err)
+		require.Equal(t, descriptor, raw)
+
+		_, err = loader.Load(server.URL + "/missing.yaml")
+		require.EqualError(t, err, "fetching descriptor '"+server.URL+"/missing.yaml': 404 Not Found")
+	})
+
+	it("does not read plain http urls", func() {
+		_, err := loader.Load("http://example.com/deps.yaml")
+		require.EqualError(t, err, "descriptor url 'http://example.com/deps.yaml' must use https")
+	})
+
+	it("reads OCI artifacts", func() {
+		artifact, err := registry.NewDescriptorArtifact([]byte(descriptor))
+		require.NoError(t, err)
+		fetcher.AddImage("some-registry.io/platform/deps:1.0.0", artifact)
+
+		raw, err := loader.Load("some-registry.io/platform/deps:1.0.0")
+		require.NoError(t, err)
+		require.Equal(t, descriptor, raw)
+	})
+
+	it("errors when an OCI image is not a descriptor artifact", func() {
+		fetcher.AddImage("some-registry.io/platform/deps:1.0.0", registryfakes.NewFakeImage("some-digest"))
+
+		_, err := loader.Load("some-registry.io/platform/deps:1.0.0")
+		require.EqualError(t, err, "reading descriptor 'some-registry.io/platform/deps:1.0.0': image does not contain a layer of type 'application/vnd.kpack.dependency-descriptor.v1+yaml'")
+	})
+
+	it("returns the file error for missing files that are not fully qualified images", func() {
+		_, err := loader.Load("missing-deps.yaml")
+		require.True(t, os.IsNotExist(err))
+		require.Equal(t, 0, fetcher.CallCount())
+	})
+}
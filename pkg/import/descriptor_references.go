@@ -0,0 +1,205 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
+	"github.com/pivotal/kpack/pkg/registry/imagehelpers"
+	"gopkg.in/yaml.v3"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+const (
+	buildpackageMetadataLabel = "io.buildpacks.buildpackage.metadata"
+	buildpackLayersLabel      = "io.buildpacks.buildpack.layers"
+)
+
+// ValidateReferences checks that every clusterStack, clusterStore, builder,
+// and clusterBuilder a resource refers to is defined in the descriptor.
+// Resources may refer to ones imported by another descriptor, so references
+// of a kind are only checked when the descriptor defines resources of that
+// kind.
+func (d DependencyDescriptor) ValidateReferences() ValidationErrors {
+	stacks := map[string]bool{}
+	for _, stack := range d.GetClusterStacks() {
+		stacks[stack.Name] = true
+	}
+	stores := map[string]bool{}
+	for _, store := range d.ClusterStores {
+		stores[store.Name] = true
+	}
+	clusterBuilders := map[string]bool{}
+	for _, cb := range d.GetClusterBuilders() {
+		clusterBuilders[cb.Name] = true
+	}
+	builders := map[string]bool{}
+	for _, b := range d.Builders {
+		builders[b.Namespace+"/"+b.Name] = true
+	}
+
+	var errs ValidationErrors
+	check := func(defined bool, path, format string, args ...interface{}) {
+		if !defined {
+			errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	checkRequired := func(defined map[string]bool, path, field, value string) {
+		if value == "" {
+			errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("missing required field '%s'", field)})
+			return
+		}
+		if len(defined) > 0 {
+			check(defined[value], path+"."+field, "%s '%s' is not defined", field, value)
+		}
+	}
+
+	for i, cb := range d.ClusterBuilders {
+		path := fmt.Sprintf("clusterBuilders[%d]", i)
+		checkRequired(stacks, path, "clusterStack", cb.ClusterStack)
+		checkRequired(stores, path, "clusterStore", cb.ClusterStore)
+	}
+
+	for i, b := range d.Builders {
+		path := fmt.Sprintf("builders[%d]", i)
+		checkRequired(stacks, path, "clusterStack", b.ClusterStack)
+		checkRequired(stores, path, "clusterStore", b.ClusterStore)
+	}
+
+	for i, image := range d.Images {
+		path := fmt.Sprintf("images[%d]", i)
+		if image.Builder != "" && len(builders) > 0 {
+			check(builders[image.Namespace+"/"+image.Builder], path+".builder", "builder '%s' is not defined in namespace '%s'", image.Builder, image.Namespace)
+		}
+		if image.ClusterBuilder != "" && len(clusterBuilders) > 0 {
+			check(clusterBuilders[image.ClusterBuilder], path+".clusterBuilder", "clusterBuilder '%s' is not defined", image.ClusterBuilder)
+		}
+	}
+
+	return errs
+}
+
+// ValidateOrders checks that every buildpack in the order of a builder is in
+// its store. storeBuildpacks maps store names to the ids of their buildpacks,
+// and stores missing from it are not checked.
+func (d DependencyDescriptor) ValidateOrders(storeBuildpacks map[string][]string) ValidationErrors {
+	var errs ValidationErrors
+	check := func(path, store string, order []corev1alpha1.OrderEntry) {
+		buildpacks, ok := storeBuildpacks[store]
+		if !ok {
+			return
+		}
+		for j, entry := range order {
+			for k, ref := range entry.Group {
+				if ref.Id != "" && !contains(buildpacks, ref.Id) {
+					errs = append(errs, ValidationError{
+						Path:    fmt.Sprintf("%s.order[%d].group[%d].id", path, j, k),
+						Message: fmt.Sprintf("buildpack '%s' is not in clusterStore '%s'", ref.Id, store),
+					})
+				}
+			}
+		}
+	}
+
+	for i, cb := range d.ClusterBuilders {
+		check(fmt.Sprintf("clusterBuilders[%d]", i), cb.ClusterStore, cb.Order)
+	}
+	for i, b := range d.Builders {
+		check(fmt.Sprintf("builders[%d]", i), b.ClusterStore, b.Order)
+	}
+	return errs
+}
+
+// StoreBuildpacks fetches the sources of every store and returns the ids of
+// the buildpacks in them, including the buildpacks of meta-buildpacks.
+func StoreBuildpacks(keychain authn.Keychain, fetcher registry.Fetcher, descriptor DependencyDescriptor) (map[string][]string, error) {
+	storeBuildpacks := map[string][]string{}
+	for _, store := range descriptor.ClusterStores {
+		buildpacks := []string{}
+		for _, src := range store.Sources {
+			image, err := fetcher.Fetch(keychain, src.Image)
+			if err != nil {
+				return nil, err
+			}
+
+			var layers map[string]interface{}
+			if err := imagehelpers.GetLabel(image, buildpackLayersLabel, &layers); err == nil {
+				for id := range layers {
+					buildpacks = append(buildpacks, id)
+				}
+			}
+
+			var metadata struct {
+				ID string `json:"id"`
+			}
+			if err := imagehelpers.GetLabel(image, buildpackageMetadataLabel, &metadata); err == nil && metadata.ID != "" {
+				buildpacks = append(buildpacks, metadata.ID)
+			}
+		}
+		storeBuildpacks[store.Name] = buildpacks
+	}
+	return storeBuildpacks, nil
+}
+
+var pathSegment = regexp.MustCompile(`^([^\[]+)((?:\[\d+\])*)$`)
+
+// LocateErrors sets the line and column of errors found in a descriptor from
+// the raw descriptor. Errors of fields that cannot be found keep no location.
+func LocateErrors(rawDescriptor string, errs ValidationErrors) ValidationErrors {
+	// converting keeps the line and column of every key, and the paths of
+	// errors use the field names of the current apiVersion
+	converted, err := ConvertDescriptor(rawDescriptor)
+	if err != nil {
+		return errs
+	}
+
+	root, err := parseDescriptorNode(converted.Descriptor)
+	if err != nil {
+		return errs
+	}
+
+	located := make(ValidationErrors, len(errs))
+	for i, e := range errs {
+		if node := lookupPath(root, e.Path); node != nil {
+			e.Line, e.Column = node.Line, node.Column
+		}
+		located[i] = e
+	}
+	return located.sorted()
+}
+
+// lookupPath returns the key of the field at path, or the item when path
+// ends with an index.
+func lookupPath(node *yaml.Node, path string) *yaml.Node {
+	var at *yaml.Node
+	for _, segment := range strings.Split(path, ".") {
+		match := pathSegment.FindStringSubmatch(segment)
+		if match == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+
+		if at, node = mappingEntry(node, match[1]); node == nil {
+			return nil
+		}
+
+		for _, index := range strings.Split(strings.Trim(match[2], "[]"), "][") {
+			if index == "" {
+				continue
+			}
+			i, _ := strconv.Atoi(index)
+			if node.Kind != yaml.SequenceNode || i >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[i]
+			at = node
+		}
+	}
+	return at
+}
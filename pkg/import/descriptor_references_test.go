@@ -0,0 +1,152 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+)
+
+func TestDescriptorReferences(t *testing.T) {
+	spec.Run(t, "TestDescriptorReferences", testDescriptorReferences)
+}
+
+func testDescriptorReferences(t *testing.T, when spec.G, it spec.S) {
+	const rawDescriptor = `apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+defaultClusterStack: some-stack
+clusterStores:
+- name: some-store
+  sources:
+  - image: some-registry.io/repo/buildpack
+clusterStacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/repo/build
+  runImage:
+    image: some-registry.io/repo/run
+clusterBuilders:
+- name: some-builder
+  clusterStack: default
+  clusterStore: some-store
+  order:
+  - group:
+    - id: some-buildpack
+- name: broken-builder
+  clusterStack: missing-stack
+  clusterStore: missing-store
+  order:
+  - group:
+    - id: missing-buildpack
+builders:
+- name: some-builder
+  namespace: some-namespace
+  clusterStore: some-store
+images:
+- name: some-image
+  namespace: some-namespace
+  tag: some-registry.io/repo/app
+  builder: some-builder
+  source:
+    registry:
+      image: some-registry.io/repo/source
+- name: other-image
+  namespace: other-namespace
+  tag: some-registry.io/repo/app
+  builder: some-builder
+  source:
+    registry:
+      image: some-registry.io/repo/source
+- name: cluster-image
+  namespace: some-namespace
+  tag: some-registry.io/repo/app
+  clusterBuilder: missing-builder
+  source:
+    registry:
+      image: some-registry.io/repo/source
+`
+
+	var descriptor importpkg.DependencyDescriptor
+
+	it.Before(func() {
+		var err error
+		descriptor, err = importpkg.ReadDescriptor(rawDescriptor)
+		require.NoError(t, err)
+	})
+
+	when("#ValidateReferences", func() {
+		it("reports references to resources the descriptor does not define", func() {
+			require.Equal(t, importpkg.ValidationErrors{
+				{Path: "clusterBuilders[1].clusterStack", Message: "clusterStack 'missing-stack' is not defined"},
+				{Path: "clusterBuilders[1].clusterStore", Message: "clusterStore 'missing-store' is not defined"},
+				{Path: "builders[0]", Message: "missing required field 'clusterStack'"},
+				{Path: "images[1].builder", Message: "builder 'some-builder' is not defined in namespace 'other-namespace'"},
+				{Path: "images[2].clusterBuilder", Message: "clusterBuilder 'missing-builder' is not defined"},
+			}, descriptor.ValidateReferences())
+		})
+
+		it("does not check kinds the descriptor does not define", func() {
+			descriptor, err := importpkg.ReadDescriptor(`apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+builders:
+- name: some-builder
+  namespace: some-namespace
+  clusterStack: platform-stack
+  clusterStore: platform-store
+`)
+			require.NoError(t, err)
+			require.Empty(t, descriptor.ValidateReferences())
+		})
+	})
+
+	when("#ValidateOrders", func() {
+		it("reports buildpacks that are not in the store of the builder", func() {
+			require.Equal(t, importpkg.ValidationErrors{
+				{Path: "clusterBuilders[0].order[0].group[0].id", Message: "buildpack 'some-buildpack' is not in clusterStore 'some-store'"},
+			}, descriptor.ValidateOrders(map[string][]string{"some-store": {"other-buildpack"}}))
+
+			require.Empty(t, descriptor.ValidateOrders(map[string][]string{"some-store": {"some-buildpack"}}))
+		})
+	})
+
+	when("#StoreBuildpacks", func() {
+		it("reads the buildpack ids of the store sources", func() {
+			fetcher := registryfakes.NewBuildpackImagesFetcher(registryfakes.BuildpackImgInfo{
+				Id:        "some-buildpack",
+				ImageInfo: registryfakes.ImageInfo{Ref: "some-registry.io/repo/buildpack", Digest: "buildpack-digest"},
+			})
+
+			storeBuildpacks, err := importpkg.StoreBuildpacks(authn.DefaultKeychain, fetcher, descriptor)
+			require.NoError(t, err)
+			require.Equal(t, map[string][]string{"some-store": {"some-buildpack"}}, storeBuildpacks)
+		})
+	})
+
+	when("#LocateErrors", func() {
+		it("sets the line and column of the field keys", func() {
+			require.EqualError(t, importpkg.LocateErrors(rawDescriptor, descriptor.ValidateReferences()), `line 22, column 3: clusterBuilders[1].clusterStack: clusterStack 'missing-stack' is not defined
+line 23, column 3: clusterBuilders[1].clusterStore: clusterStore 'missing-store' is not defined
+line 28, column 3: builders[0]: missing required field 'clusterStack'
+line 42, column 3: images[1].builder: builder 'some-builder' is not defined in namespace 'other-namespace'
+line 49, column 3: images[2].clusterBuilder: clusterBuilder 'missing-builder' is not defined`)
+		})
+
+		it("locates the fields of older apiVersions", func() {
+			errs := importpkg.ValidationErrors{{Path: "clusterBuilders[0].clusterStore", Message: "some-problem"}}
+			require.EqualError(t, importpkg.LocateErrors(`apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyDescriptor
+clusterBuilders:
+- name: some-builder
+  stack: some-stack
+  store: some-store
+`, errs), "line 6, column 3: clusterBuilders[0].clusterStore: some-problem")
+		})
+	})
+}
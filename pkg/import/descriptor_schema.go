@@ -0,0 +1,245 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// DescriptorSchema returns the published JSON Schema of a descriptor
+// apiVersion, kept in the schemas directory.
+func DescriptorSchema(apiVersion string) ([]byte, error) {
+	for _, version := range supportedAPIVersions() {
+		if version == apiVersion {
+			return schemas.ReadFile(fmt.Sprintf("schemas/dependency-descriptor-%s.json", strings.TrimPrefix(apiVersion, "kp.kpack.io/")))
+		}
+	}
+	return nil, unsupportedAPIVersionError()
+}
+
+func supportedAPIVersions() []string {
+	return []string{APIVersionV1, APIVersionV3, CurrentAPIVersion}
+}
+
+func unsupportedAPIVersionError() error {
+	return errors.Errorf("did not find expected apiVersion, must be one of: %s", supportedAPIVersions())
+}
+
+// ValidationError is a problem with a descriptor field. Path is the field,
+// such as clusterBuilders[0].clusterStack, and Line and Column locate it when
+// they are known.
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	var parts []string
+	switch {
+	case e.File != "" && e.Line > 0:
+		parts = append(parts, fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column))
+	case e.File != "":
+		parts = append(parts, e.File)
+	case e.Line > 0:
+		parts = append(parts, fmt.Sprintf("line %d, column %d", e.Line, e.Column))
+	}
+	if e.Path != "" {
+		parts = append(parts, e.Path)
+	}
+	parts = append(parts, e.Message)
+	return strings.Join(parts, ": ")
+}
+
+// ValidationErrors are every problem found in a descriptor, in the order of
+// the descriptor.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// WithFile returns the errors attributed to file.
+func (e ValidationErrors) WithFile(file string) ValidationErrors {
+	errs := make(ValidationErrors, len(e))
+	for i, err := range e {
+		err.File = file
+		errs[i] = err
+	}
+	return errs
+}
+
+func (e ValidationErrors) sorted() ValidationErrors {
+	sort.SliceStable(e, func(i, j int) bool {
+		if e[i].Line != e[j].Line {
+			return e[i].Line < e[j].Line
+		}
+		return e[i].Column < e[j].Column
+	})
+	return e
+}
+
+// ValidateDescriptorSchema validates a raw descriptor against the schema of
+// its apiVersion, rejecting unknown fields and fields of the wrong type. The
+// error is ValidationErrors when the descriptor does not match the schema.
+func ValidateDescriptorSchema(rawDescriptor string) error {
+	root, err := parseDescriptorNode(rawDescriptor)
+	if err != nil {
+		return err
+	}
+
+	_, version := mappingEntry(root, "apiVersion")
+	if version == nil {
+		return unsupportedAPIVersionError()
+	}
+
+	buf, err := DescriptorSchema(version.Value)
+	if err != nil {
+		return err
+	}
+
+	var schema jsonSchema
+	if err := json.Unmarshal(buf, &schema); err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	schema.validate(&schema, root, "", &errs)
+	if len(errs) > 0 {
+		return errs.sorted()
+	}
+	return nil
+}
+
+func parseDescriptorNode(rawDescriptor string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(rawDescriptor), &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("descriptor must be a mapping")
+	}
+	return doc.Content[0], nil
+}
+
+// jsonSchema is the subset of JSON Schema the descriptor schemas use.
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref"`
+	Type                 string                 `json:"type"`
+	Enum                 []string               `json:"enum"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Required             []string               `json:"required"`
+	Items                *jsonSchema            `json:"items"`
+	Definitions          map[string]*jsonSchema `json:"definitions"`
+}
+
+func (s *jsonSchema) validate(root *jsonSchema, node *yaml.Node, path string, errs *ValidationErrors) {
+	if s.Ref != "" {
+		s = root.Definitions[strings.TrimPrefix(s.Ref, "#/definitions/")]
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+
+	fail := func(n *yaml.Node, path, format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Line: n.Line, Column: n.Column, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		if node.Kind != yaml.MappingNode {
+			fail(node, path, "must be an object")
+			return
+		}
+
+		set := map[string]bool{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			set[key.Value] = true
+
+			if property, ok := s.Properties[key.Value]; ok {
+				property.validate(root, value, joinPath(path, key.Value), errs)
+			} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				fail(key, path, "unknown field '%s'%s", key.Value, s.suggest(key.Value))
+			}
+		}
+
+		for _, required := range s.Required {
+			if !set[required] {
+				fail(node, path, "missing required field '%s'", required)
+			}
+		}
+	case "array":
+		if node.Kind != yaml.SequenceNode {
+			fail(node, path, "must be a list")
+			return
+		}
+		for i, item := range node.Content {
+			s.Items.validate(root, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case "string":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!str" {
+			fail(node, path, "must be a string")
+			return
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, node.Value) {
+			fail(node, path, "must be one of: %s", s.Enum)
+		}
+	case "boolean":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!bool" {
+			fail(node, path, "must be a boolean")
+		}
+	case "integer":
+		if node.Kind != yaml.ScalarNode || node.Tag != "!!int" {
+			fail(node, path, "must be an integer")
+		}
+	}
+}
+
+// suggest returns a hint for an unknown field that only differs from a known
+// field by case, such as clusterstack for clusterStack.
+func (s *jsonSchema) suggest(field string) string {
+	for name := range s.Properties {
+		if strings.EqualFold(name, field) {
+			return fmt.Sprintf(", did you mean '%s'?", name)
+		}
+	}
+	return ""
+}
+
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+)
+
+func TestDescriptorSchema(t *testing.T) {
+	spec.Run(t, "TestDescriptorSchema", testDescriptorSchema)
+}
+
+func testDescriptorSchema(t *testing.T, when spec.G, it spec.S) {
+	when("#DescriptorSchema", func() {
+		it("publishes a JSON Schema for every apiVersion", func() {
+			for _, version := range []string{importpkg.APIVersionV1, importpkg.APIVersionV3, importpkg.CurrentAPIVersion} {
+				buf, err := importpkg.DescriptorSchema(version)
+				require.NoError(t, err)
+
+				var schema map[string]interface{}
+				require.NoError(t, json.Unmarshal(buf, &schema))
+				require.Equal(t, "kp import dependency descriptor "+version, schema["title"])
+			}
+		})
+
+		it("fails for unsupported apiVersions", func() {
+			_, err := importpkg.DescriptorSchema("kp.kpack.io/v1alpha2")
+			require.EqualError(t, err, "did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3 kp.kpack.io/v1alpha4]")
+		})
+	})
+
+	when("#ValidateDescriptorSchema", func() {
+		it("accepts descriptors matching the schema", func() {
+			require.NoError(t, importpkg.ValidateDescriptorSchema(`apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+defaultClusterStack: some-stack
+lifecycle:
+  image: some-registry.io/repo/lifecycle
+clusterStores:
+- name: some-store
+  replaceSources: true
+  sources:
+  - image: some-registry.io/repo/buildpack
+clusterStacks:
+- name: some-stack
+  buildImage:
+    image: some-registry.io/repo/build
+  runImage:
+    image: some-registry.io/repo/run
+clusterBuilders:
+- name: some-builder
+  clusterStack: some-stack
+  clusterStore: some-store
+  order:
+  - group:
+    - id: some-buildpack
+      optional: true
+images:
+- name: some-image
+  namespace: some-namespace
+  tag: some-registry.io/repo/app
+  clusterBuilder: some-builder
+  source:
+    blob:
+      url: https://example.com/app.zip
+      stripComponents: 1
+  env:
+  - name: SOME_VAR
+    value: some-value
+`))
+		})
+
+		it("rejects unknown fields and fields of the wrong type with their line and column", func() {
+			err := importpkg.ValidateDescriptorSchema(`apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+clusterStores:
+- name: some-store
+  replaceSources: yes please
+  sources:
+  - {}
+clusterBuilders:
+- name: some-builder
+  clusterstack: some-stack
+  order:
+  - group:
+    - version: 1.0.0
+      id: [some-buildpack]
+extra: true
+`)
+			require.EqualError(t, err, `line 5, column 19: clusterStores[0].replaceSources: must be a boolean
+line 7, column 5: clusterStores[0].sources[0]: missing required field 'image'
+line 10, column 3: clusterBuilders[0]: unknown field 'clusterstack', did you mean 'clusterStack'?
+line 14, column 11: clusterBuilders[0].order[0].group[0].id: must be a string
+line 15, column 1: unknown field 'extra'`)
+
+			errs, ok := err.(importpkg.ValidationErrors)
+			require.True(t, ok)
+			require.Len(t, errs, 5)
+		})
+
+		it("validates against the schema of the apiVersion", func() {
+			require.NoError(t, importpkg.ValidateDescriptorSchema(`apiVersion: kp.kpack.io/v1alpha1
+kind: DependencyDescriptor
+defaultStack: some-stack
+clusterBuilders:
+- name: some-builder
+  stack: some-stack
+`))
+
+			err := importpkg.ValidateDescriptorSchema(`apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+builders: []
+`)
+			require.EqualError(t, err, "line 3, column 1: unknown field 'builders'")
+		})
+
+		it("fails for unsupported apiVersions", func() {
+			err := importpkg.ValidateDescriptorSchema("kind: DependencyDescriptor\n")
+			require.EqualError(t, err, "did not find expected apiVersion, must be one of: [kp.kpack.io/v1alpha1 kp.kpack.io/v1alpha3 kp.kpack.io/v1alpha4]")
+		})
+	})
+
+	it("formats errors with the file they were found in", func() {
+		errs := importpkg.ValidationErrors{
+			{Line: 3, Column: 5, Path: "clusterBuilders[0]", Message: "some-problem"},
+			{Path: "images[0].builder", Message: "other-problem"},
+		}
+		require.EqualError(t, errs.WithFile("deps.yaml"), `deps.yaml:3:5: clusterBuilders[0]: some-problem
+deps.yaml: images[0].builder: other-problem`)
+	})
+}
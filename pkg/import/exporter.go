@@ -0,0 +1,164 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	"github.com/pivotal/kpack/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/lifecycle"
+)
+
+const defaultResourceName = "default"
+
+type Exporter struct {
+	client    versioned.Interface
+	k8sClient kubernetes.Interface
+}
+
+func NewExporter(k8sClient kubernetes.Interface, client versioned.Interface) *Exporter {
+	return &Exporter{
+		client:    client,
+		k8sClient: k8sClient,
+	}
+}
+
+// ExportDescriptor builds a DependencyDescriptor from the lifecycle, ClusterStores,
+// ClusterStacks and ClusterBuilders currently in the cluster. A "default" ClusterStack
+// or ClusterBuilder that mirrors a named one is exported as defaultClusterStack or
+// defaultClusterBuilder rather than as a resource of its own.
+func (e *Exporter) ExportDescriptor(ctx context.Context) (DependencyDescriptor, error) {
+	lifecycleImage, err := lifecycle.GetImage(ctx, e.k8sClient)
+	if err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	storeList, err := e.client.KpackV1alpha2().ClusterStores().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	stackList, err := e.client.KpackV1alpha2().ClusterStacks().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	builderList, err := e.client.KpackV1alpha2().ClusterBuilders().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return DependencyDescriptor{}, err
+	}
+
+	descriptor := DependencyDescriptor{
+		APIVersion: CurrentAPIVersion,
+		Kind:       "DependencyDescriptor",
+		Lifecycle:  Lifecycle{Image: lifecycleImage},
+	}
+
+	descriptor.ClusterStores = exportClusterStores(storeList.Items)
+	descriptor.DefaultClusterStack, descriptor.ClusterStacks = exportClusterStacks(stackList.Items)
+	descriptor.DefaultClusterBuilder, descriptor.ClusterBuilders = exportClusterBuilders(builderList.Items)
+
+	return descriptor, descriptor.Validate()
+}
+
+func exportClusterStores(stores []v1alpha2.ClusterStore) []ClusterStore {
+	sort.Slice(stores, func(i, j int) bool { return stores[i].Name < stores[j].Name })
+
+	result := make([]ClusterStore, 0, len(stores))
+	for _, store := range stores {
+		result = append(result, toDescriptorClusterStore(store))
+	}
+	return result
+}
+
+func exportClusterStacks(stacks []v1alpha2.ClusterStack) (string, []ClusterStack) {
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].Name < stacks[j].Name })
+
+	var (
+		defaultStack *ClusterStack
+		result       = make([]ClusterStack, 0, len(stacks))
+	)
+	for _, stack := range stacks {
+		s := toDescriptorClusterStack(stack)
+		if s.Name == defaultResourceName {
+			defaultStack = &s
+			continue
+		}
+		result = append(result, s)
+	}
+
+	if defaultStack == nil {
+		return "", result
+	}
+
+	for _, s := range result {
+		if s.BuildImage == defaultStack.BuildImage && s.RunImage == defaultStack.RunImage {
+			return s.Name, result
+		}
+	}
+
+	return "", append(result, *defaultStack)
+}
+
+func exportClusterBuilders(builders []v1alpha2.ClusterBuilder) (string, []ClusterBuilder) {
+	sort.Slice(builders, func(i, j int) bool { return builders[i].Name < builders[j].Name })
+
+	var (
+		defaultBuilder *ClusterBuilder
+		result         = make([]ClusterBuilder, 0, len(builders))
+	)
+	for _, builder := range builders {
+		b := toDescriptorClusterBuilder(builder)
+		if b.Name == defaultResourceName {
+			defaultBuilder = &b
+			continue
+		}
+		result = append(result, b)
+	}
+
+	if defaultBuilder == nil {
+		return "", result
+	}
+
+	for _, b := range result {
+		if b.ClusterStack == defaultBuilder.ClusterStack &&
+			b.ClusterStore == defaultBuilder.ClusterStore &&
+			reflect.DeepEqual(b.Order, defaultBuilder.Order) {
+			return b.Name, result
+		}
+	}
+
+	return "", append(result, *defaultBuilder)
+}
+
+func toDescriptorClusterStore(store v1alpha2.ClusterStore) ClusterStore {
+	s := ClusterStore{Name: store.Name, Sources: []Source{}}
+	for _, src := range store.Spec.Sources {
+		s.Sources = append(s.Sources, Source{Image: src.Image})
+	}
+	return s
+}
+
+func toDescriptorClusterStack(stack v1alpha2.ClusterStack) ClusterStack {
+	return ClusterStack{
+		Name:       stack.Name,
+		BuildImage: Source{Image: stack.Spec.BuildImage.Image},
+		RunImage:   Source{Image: stack.Spec.RunImage.Image},
+	}
+}
+
+func toDescriptorClusterBuilder(builder v1alpha2.ClusterBuilder) ClusterBuilder {
+	return ClusterBuilder{
+		Name:         builder.Name,
+		ClusterStack: builder.Spec.Stack.Name,
+		ClusterStore: builder.Spec.Store.Name,
+		Order:        builder.Spec.Order,
+	}
+}
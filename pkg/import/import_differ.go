@@ -16,7 +16,7 @@ import (
 )
 
 type RelocatedImageProvider interface {
-	RelocatedImage(authn.Keychain, config.KpConfig, string) (string, error)
+	RelocatedImage(authn.Keychain, config.KpConfig, config.ImageKind, config.RepositoryValues, string) (string, error)
 }
 
 type Differ interface {
@@ -26,32 +26,41 @@ type Differ interface {
 type ImportDiffer struct {
 	Differ                 Differ
 	RelocatedImageProvider RelocatedImageProvider
+	// Plan, when set, records the change each diff describes.
+	Plan *ChangePlan
 }
 
 func (id *ImportDiffer) DiffLifecycle(keychain authn.Keychain, kpConfig config.KpConfig, oldImg string, newImg string) (string, error) {
-	relocatedLifecycle, err := id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, newImg)
+	relocatedLifecycle, err := id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, config.LifecycleImage, config.RepositoryValues{}, newImg)
 	if err != nil {
 		return "", err
 	}
 
-	return id.Differ.Diff(oldImg, relocatedLifecycle)
+	diff, err := id.Differ.Diff(oldImg, relocatedLifecycle)
+	if err != nil {
+		return "", err
+	}
+
+	id.Plan.add(lifecycleKind, "", true, diff, &ResourceValues{Image: oldImg}, &ResourceValues{Image: relocatedLifecycle})
+	return diff, nil
 }
 
 func (id *ImportDiffer) DiffClusterStore(keychain authn.Keychain, kpConfig config.KpConfig, oldCS *v1alpha2.ClusterStore, newCS ClusterStore) (string, error) {
-	type void struct{}
-	newBPs := map[string]void{}
+	newBPs := map[string]struct{}{}
+	relocatedBPs := make([]Source, len(newCS.Sources))
 	mux := &sync.Mutex{}
 	errs, _ := errgroup.WithContext(context.Background())
 
-	for _, bp := range newCS.Sources {
-		image := bp.Image
+	for i, bp := range newCS.Sources {
+		i, image := i, bp.Image
 		errs.Go(func() error {
-			relocatedBP, err := id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, image)
+			relocatedBP, err := id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, config.BuildpackageImage, config.RepositoryValues{}, image)
 			if err != nil {
 				return err
 			}
 			mux.Lock()
-			newBPs[relocatedBP] = void{}
+			newBPs[relocatedBP] = struct{}{}
+			relocatedBPs[i] = Source{Image: relocatedBP}
 			mux.Unlock()
 			return nil
 		})
@@ -61,20 +70,33 @@ func (id *ImportDiffer) DiffClusterStore(keychain authn.Keychain, kpConfig confi
 		return "", err
 	}
 
+	var oldValues *ResourceValues
 	oldCSStr := ""
 	if oldCS != nil {
+		oldValues = storeValues(nil)
 		for _, s := range oldCS.Spec.Sources {
 			delete(newBPs, s.Image)
+			oldValues.Sources = append(oldValues.Sources, s.Image)
 		}
 		oldCSStr = fmt.Sprintf(`Name: %s
 Sources:`, oldCS.Name)
 	}
 
+	diff, err := id.diffStoreSources(oldCSStr, newCS.Name, newBPs)
+	if err != nil {
+		return "", err
+	}
+
+	id.Plan.add(v1alpha2.ClusterStoreKind, newCS.Name, oldCS != nil, diff, oldValues, storeValues(relocatedBPs))
+	return diff, nil
+}
+
+func (id *ImportDiffer) diffStoreSources(oldCSStr, name string, newBPs map[string]struct{}) (string, error) {
 	if len(newBPs) == 0 {
 		return "", nil
 	}
 
-	newCS.Sources = []Source{}
+	newCS := ClusterStore{Name: name, Sources: []Source{}}
 	for img := range newBPs {
 		newCS.Sources = append(newCS.Sources, Source{Image: img})
 	}
@@ -83,37 +105,112 @@ Sources:`, oldCS.Name)
 }
 
 func (id *ImportDiffer) DiffClusterStack(keychain authn.Keychain, kpConfig config.KpConfig, oldCS *v1alpha2.ClusterStack, newCS ClusterStack) (diff string, err error) {
-	newCS.BuildImage.Image, err = id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, newCS.BuildImage.Image)
+	newCS.BuildImage.Image, err = id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, config.BuildImage, config.RepositoryValues{Name: newCS.Name}, newCS.BuildImage.Image)
 	if err != nil {
 		return "", err
 	}
-	newCS.RunImage.Image, err = id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, newCS.RunImage.Image)
+	newCS.RunImage.Image, err = id.RelocatedImageProvider.RelocatedImage(keychain, kpConfig, config.RunImage, config.RepositoryValues{Name: newCS.Name}, newCS.RunImage.Image)
 	if err != nil {
 		return "", err
 	}
 
-	var oldDiffableStack interface{}
+	var (
+		oldDiffableStack interface{}
+		oldValues        *ResourceValues
+	)
 	if oldCS != nil {
-		oldDiffableStack = ClusterStack{
+		oldStack := ClusterStack{
 			Name:       oldCS.Name,
 			BuildImage: Source{Image: oldCS.Spec.BuildImage.Image},
 			RunImage:   Source{Image: oldCS.Spec.RunImage.Image},
 		}
+		oldDiffableStack, oldValues = oldStack, stackValues(oldStack)
+	}
+
+	diff, err = id.Differ.Diff(oldDiffableStack, newCS)
+	if err != nil {
+		return "", err
 	}
 
-	return id.Differ.Diff(oldDiffableStack, newCS)
+	id.Plan.add(v1alpha2.ClusterStackKind, newCS.Name, oldCS != nil, diff, oldValues, stackValues(newCS))
+	return diff, nil
 }
 
 func (id *ImportDiffer) DiffClusterBuilder(oldCB *v1alpha2.ClusterBuilder, newCB ClusterBuilder) (string, error) {
-	var oldDiffableCB interface{}
+	var (
+		oldDiffableCB interface{}
+		oldValues     *ResourceValues
+	)
 	if oldCB != nil {
-		oldDiffableCB = ClusterBuilder{
+		oldBuilder := ClusterBuilder{
 			Name:         oldCB.Name,
 			ClusterStack: oldCB.Spec.Stack.Name,
 			ClusterStore: oldCB.Spec.Store.Name,
 			Order:        oldCB.Spec.Order,
 		}
+		oldDiffableCB, oldValues = oldBuilder, builderValues(oldBuilder)
+	}
+
+	diff, err := id.Differ.Diff(oldDiffableCB, newCB)
+	if err != nil {
+		return "", err
+	}
+
+	id.Plan.add(v1alpha2.ClusterBuilderKind, newCB.Name, oldCB != nil, diff, oldValues, builderValues(newCB))
+	return diff, nil
+}
+
+func (id *ImportDiffer) DiffBuilder(kpConfig config.KpConfig, oldB *v1alpha2.Builder, newB Builder) (string, error) {
+	tag, err := builderTag(kpConfig, newB)
+	if err != nil {
+		return "", err
+	}
+	newB.Tag = tag
+
+	var (
+		oldDiffableB interface{}
+		oldValues    *ResourceValues
+	)
+	if oldB != nil {
+		oldBuilder := toDescriptorBuilder(*oldB)
+		oldDiffableB, oldValues = oldBuilder, namespacedBuilderValues(oldBuilder)
+	}
+
+	diff, err := id.Differ.Diff(oldDiffableB, newB)
+	if err != nil {
+		return "", err
+	}
+
+	id.Plan.addNamespaced(v1alpha2.BuilderKind, newB.Namespace, newB.Name, oldB != nil, diff, oldValues, namespacedBuilderValues(newB))
+	return diff, nil
+}
+
+func (id *ImportDiffer) DiffImage(oldImg *v1alpha2.Image, newImg Image) (string, error) {
+	var (
+		oldDiffableImg interface{}
+		oldValues      *ResourceValues
+	)
+	if oldImg != nil {
+		oldImage := toDescriptorImage(*oldImg)
+		oldDiffableImg, oldValues = oldImage, imageValues(oldImage)
+	}
+
+	diff, err := id.Differ.Diff(oldDiffableImg, newImg)
+	if err != nil {
+		return "", err
+	}
+
+	id.Plan.addNamespaced(v1alpha2.ImageKind, newImg.Namespace, newImg.Name, oldImg != nil, diff, oldValues, imageValues(newImg))
+	return diff, nil
+}
+
+// DiffPruned renders a resource that will be deleted from the cluster.
+func (id *ImportDiffer) DiffPruned(old interface{}) (string, error) {
+	diff, err := id.Differ.Diff(old, nil)
+	if err != nil {
+		return "", err
 	}
 
-	return id.Differ.Diff(oldDiffableCB, newCB)
+	id.Plan.addPruned(old)
+	return diff, nil
 }
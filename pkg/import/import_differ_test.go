@@ -33,7 +33,7 @@ func NewFakeRelocatedImageProvider() *FakeRelocatedImageProvider {
 	return &FakeRelocatedImageProvider{}
 }
 
-func (rg *FakeRelocatedImageProvider) RelocatedImage(keychain authn.Keychain, kpConfig config.KpConfig, image string) (string, error) {
+func (rg *FakeRelocatedImageProvider) RelocatedImage(keychain authn.Keychain, kpConfig config.KpConfig, kind config.ImageKind, values config.RepositoryValues, image string) (string, error) {
 	return image, nil
 }
 
@@ -195,4 +195,234 @@ func testImportDiffer(t *testing.T, when spec.G, it spec.S) {
 			require.Equal(t, nil, diffArg0)
 		})
 	})
+
+	when("DiffBuilder", func() {
+		it("returns a diff of old and new builder with the default tag", func() {
+			oldBuilder := &v1alpha2.Builder{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-builder",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha2.NamespacedBuilderSpec{
+					BuilderSpec: v1alpha2.BuilderSpec{
+						Tag:   "my-cool-repo:builder-some-namespace-some-builder",
+						Store: corev1.ObjectReference{Name: "some-store"},
+						Stack: corev1.ObjectReference{Name: "some-stack"},
+					},
+				},
+			}
+			newBuilder := importpkg.Builder{
+				Name:         "some-builder",
+				Namespace:    "some-namespace",
+				ClusterStore: "some-new-store",
+				ClusterStack: "some-stack",
+			}
+
+			diff, err := importDiffer.DiffBuilder(kpConfig, oldBuilder, newBuilder)
+			require.NoError(t, err)
+			require.Equal(t, "some-diff", diff)
+			diffArg0, diffArg1 := fakeDiffer.Args()
+			require.Equal(t, importpkg.Builder{
+				Name:         "some-builder",
+				Namespace:    "some-namespace",
+				Tag:          "my-cool-repo:builder-some-namespace-some-builder",
+				ClusterStore: "some-store",
+				ClusterStack: "some-stack",
+			}, diffArg0)
+			newBuilder.Tag = "my-cool-repo:builder-some-namespace-some-builder"
+			require.Equal(t, newBuilder, diffArg1)
+		})
+
+		it("diffs against nil when old builder does not exist", func() {
+			diff, err := importDiffer.DiffBuilder(kpConfig, nil, importpkg.Builder{Tag: "some-registry.io/some-builder"})
+			require.NoError(t, err)
+			require.Equal(t, "some-diff", diff)
+			diffArg0, _ := fakeDiffer.Args()
+			require.Equal(t, nil, diffArg0)
+		})
+	})
+
+	when("DiffImage", func() {
+		it("returns a diff of old and new image", func() {
+			oldImage := &v1alpha2.Image{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "some-image",
+					Namespace: "some-namespace",
+				},
+				Spec: v1alpha2.ImageSpec{
+					Tag:     "some-registry.io/some-image",
+					Builder: corev1.ObjectReference{Kind: v1alpha2.ClusterBuilderKind, Name: "default"},
+					Source:  corev1alpha1.SourceConfig{Git: &corev1alpha1.Git{URL: "https://github.com/some/repo", Revision: "main"}},
+					Build:   &v1alpha2.ImageBuild{Env: []corev1.EnvVar{{Name: "SOME_VAR", Value: "some-value"}}},
+				},
+			}
+			newImage := importpkg.Image{
+				Name:      "some-image",
+				Namespace: "some-namespace",
+				Tag:       "some-registry.io/some-image",
+				Source:    corev1alpha1.SourceConfig{Git: &corev1alpha1.Git{URL: "https://github.com/some/repo", Revision: "main"}},
+				Builder:   "some-builder",
+			}
+
+			diff, err := importDiffer.DiffImage(oldImage, newImage)
+			require.NoError(t, err)
+			require.Equal(t, "some-diff", diff)
+			diffArg0, diffArg1 := fakeDiffer.Args()
+			require.Equal(t, importpkg.Image{
+				Name:           "some-image",
+				Namespace:      "some-namespace",
+				Tag:            "some-registry.io/some-image",
+				Source:         corev1alpha1.SourceConfig{Git: &corev1alpha1.Git{URL: "https://github.com/some/repo", Revision: "main"}},
+				ClusterBuilder: "default",
+				Env:            []corev1.EnvVar{{Name: "SOME_VAR", Value: "some-value"}},
+			}, diffArg0)
+			require.Equal(t, newImage, diffArg1)
+		})
+
+		it("diffs against nil when old image does not exist", func() {
+			diff, err := importDiffer.DiffImage(nil, importpkg.Image{})
+			require.NoError(t, err)
+			require.Equal(t, "some-diff", diff)
+			diffArg0, _ := fakeDiffer.Args()
+			require.Equal(t, nil, diffArg0)
+		})
+	})
+
+	when("a plan is recorded", func() {
+		var plan *importpkg.ChangePlan
+
+		it.Before(func() {
+			plan = &importpkg.ChangePlan{}
+			importDiffer.Differ = commands.Differ{}
+			importDiffer.Plan = plan
+		})
+
+		it("records the lifecycle image", func() {
+			_, err := importDiffer.DiffLifecycle(fakeKeychain, kpConfig, "some-old-lifecycle", "some-new-lifecycle")
+			require.NoError(t, err)
+
+			require.Equal(t, []importpkg.ResourceChange{
+				{
+					Kind:   "Lifecycle",
+					Action: importpkg.ActionUpdate,
+					Old:    &importpkg.ResourceValues{Image: "some-old-lifecycle"},
+					New:    &importpkg.ResourceValues{Image: "some-new-lifecycle"},
+				},
+			}, plan.Changes)
+		})
+
+		it("records store sources in descriptor order", func() {
+			oldStore := &v1alpha2.ClusterStore{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-store"},
+				Spec: v1alpha2.ClusterStoreSpec{
+					Sources: []corev1alpha1.StoreImage{{Image: "some-buildpackage"}},
+				},
+			}
+			newStore := importpkg.ClusterStore{
+				Name:    "some-store",
+				Sources: []importpkg.Source{{Image: "some-new-buildpackage"}, {Image: "some-buildpackage"}},
+			}
+
+			_, err := importDiffer.DiffClusterStore(fakeKeychain, kpConfig, oldStore, newStore)
+			require.NoError(t, err)
+			_, err = importDiffer.DiffClusterStore(fakeKeychain, kpConfig, nil, newStore)
+			require.NoError(t, err)
+
+			require.Equal(t, []importpkg.ResourceChange{
+				{
+					Kind:   "ClusterStore",
+					Name:   "some-store",
+					Action: importpkg.ActionUpdate,
+					Old:    &importpkg.ResourceValues{Sources: []string{"some-buildpackage"}},
+					New:    &importpkg.ResourceValues{Sources: []string{"some-new-buildpackage", "some-buildpackage"}},
+				},
+				{
+					Kind:   "ClusterStore",
+					Name:   "some-store",
+					Action: importpkg.ActionCreate,
+					New:    &importpkg.ResourceValues{Sources: []string{"some-new-buildpackage", "some-buildpackage"}},
+				},
+			}, plan.Changes)
+		})
+
+		it("records unchanged stacks as noop", func() {
+			oldStack := &v1alpha2.ClusterStack{
+				ObjectMeta: metav1.ObjectMeta{Name: "some-stack"},
+				Spec: v1alpha2.ClusterStackSpec{
+					BuildImage: v1alpha2.ClusterStackSpecImage{Image: "some-build-image"},
+					RunImage:   v1alpha2.ClusterStackSpecImage{Image: "some-run-image"},
+				},
+			}
+			newStack := importpkg.ClusterStack{
+				Name:       "some-stack",
+				BuildImage: importpkg.Source{Image: "some-build-image"},
+				RunImage:   importpkg.Source{Image: "some-run-image"},
+			}
+
+			diff, err := importDiffer.DiffClusterStack(fakeKeychain, kpConfig, oldStack, newStack)
+			require.NoError(t, err)
+			require.Empty(t, diff)
+
+			values := &importpkg.ResourceValues{BuildImage: "some-build-image", RunImage: "some-run-image"}
+			require.Equal(t, []importpkg.ResourceChange{
+				{Kind: "ClusterStack", Name: "some-stack", Action: importpkg.ActionNoop, Old: values, New: values},
+			}, plan.Changes)
+			require.False(t, plan.HasChanges())
+		})
+
+		it("records builder orders and pruned resources", func() {
+			order := []corev1alpha1.OrderEntry{{Group: []corev1alpha1.BuildpackRef{{BuildpackInfo: corev1alpha1.BuildpackInfo{Id: "some-buildpack"}}}}}
+			newBuilder := importpkg.ClusterBuilder{
+				Name:         "some-builder",
+				ClusterStore: "some-store",
+				ClusterStack: "some-stack",
+				Order:        order,
+			}
+
+			_, err := importDiffer.DiffClusterBuilder(nil, newBuilder)
+			require.NoError(t, err)
+			_, err = importDiffer.DiffPruned(importpkg.ClusterStack{Name: "old-stack", BuildImage: importpkg.Source{Image: "old-build"}, RunImage: importpkg.Source{Image: "old-run"}})
+			require.NoError(t, err)
+
+			require.Equal(t, []importpkg.ResourceChange{
+				{
+					Kind:   "ClusterBuilder",
+					Name:   "some-builder",
+					Action: importpkg.ActionCreate,
+					New:    &importpkg.ResourceValues{ClusterStack: "some-stack", ClusterStore: "some-store", Order: order},
+				},
+				{
+					Kind:   "ClusterStack",
+					Name:   "old-stack",
+					Action: importpkg.ActionDelete,
+					Old:    &importpkg.ResourceValues{BuildImage: "old-build", RunImage: "old-run"},
+				},
+			}, plan.Changes)
+			require.True(t, plan.HasChanges())
+		})
+
+		it("records the namespace of builders and images", func() {
+			_, err := importDiffer.DiffBuilder(kpConfig, nil, importpkg.Builder{Name: "some-builder", Namespace: "some-namespace", ClusterStack: "some-stack", ClusterStore: "some-store"})
+			require.NoError(t, err)
+			_, err = importDiffer.DiffImage(nil, importpkg.Image{Name: "some-image", Namespace: "some-namespace", Tag: "some-registry.io/some-image", ClusterBuilder: "default"})
+			require.NoError(t, err)
+
+			require.Equal(t, []importpkg.ResourceChange{
+				{
+					Kind:      "Builder",
+					Namespace: "some-namespace",
+					Name:      "some-builder",
+					Action:    importpkg.ActionCreate,
+					New:       &importpkg.ResourceValues{Tag: "my-cool-repo:builder-some-namespace-some-builder", ClusterStack: "some-stack", ClusterStore: "some-store"},
+				},
+				{
+					Kind:      "Image",
+					Namespace: "some-namespace",
+					Name:      "some-image",
+					Action:    importpkg.ActionCreate,
+					New:       &importpkg.ResourceValues{Tag: "some-registry.io/some-image", Source: &corev1alpha1.SourceConfig{}, ClusterBuilder: "default"},
+				},
+			}, plan.Changes)
+		})
+	})
 }
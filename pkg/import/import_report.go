@@ -0,0 +1,152 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+const LifecycleKind = "Lifecycle"
+
+type ReportAction string
+
+const (
+	ActionCreated   ReportAction = "created"
+	ActionUpdated   ReportAction = "updated"
+	ActionUnchanged ReportAction = "unchanged"
+)
+
+// ImportReport is the outcome of an import: what happened to every resource,
+// the images relocated for it, and where the time was spent.
+type ImportReport struct {
+	Resources         []ResourceReport `json:"resources"`
+	ImagesUploaded    int              `json:"imagesUploaded"`
+	ImagesPresent     int              `json:"imagesPresent"`
+	BytesUploaded     int64            `json:"bytesUploaded"`
+	RelocationSeconds float64          `json:"relocationSeconds"`
+	WaitSeconds       float64          `json:"waitSeconds"`
+}
+
+// ResourceReport is the outcome of importing one resource. The lifecycle is
+// reported with the LifecycleKind.
+type ResourceReport struct {
+	Kind           string       `json:"kind"`
+	Namespace      string       `json:"namespace,omitempty"`
+	Name           string       `json:"name,omitempty"`
+	Action         ReportAction `json:"action"`
+	ImagesUploaded int          `json:"imagesUploaded"`
+	ImagesPresent  int          `json:"imagesPresent"`
+	BytesUploaded  int64        `json:"bytesUploaded"`
+	WaitSeconds    float64      `json:"waitSeconds"`
+}
+
+type reportKey struct {
+	kind, namespace, name string
+}
+
+// importReporter collects the report of an import. Relocations are attributed
+// to the resource that was last started.
+type importReporter struct {
+	mux        sync.Mutex
+	report     ImportReport
+	index      map[reportKey]int
+	current    int
+	relocation time.Duration
+	wait       time.Duration
+}
+
+func newImportReporter() *importReporter {
+	return &importReporter{index: map[reportKey]int{}, current: -1}
+}
+
+func (r *importReporter) start(kind, namespace, name string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	key := reportKey{kind, namespace, name}
+	if i, ok := r.index[key]; ok {
+		r.current = i
+		return
+	}
+
+	r.report.Resources = append(r.report.Resources, ResourceReport{Kind: kind, Namespace: namespace, Name: name})
+	r.current = len(r.report.Resources) - 1
+	r.index[key] = r.current
+}
+
+func (r *importReporter) relocated(outcome registry.RelocationOutcome) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if r.current < 0 {
+		return
+	}
+	resource := &r.report.Resources[r.current]
+	if outcome.Uploaded {
+		resource.ImagesUploaded++
+		resource.BytesUploaded += outcome.Size
+	} else {
+		resource.ImagesPresent++
+	}
+}
+
+func (r *importReporter) saved(kind, namespace, name string, action ReportAction, wait time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	i, ok := r.index[reportKey{kind, namespace, name}]
+	if !ok {
+		return
+	}
+	r.report.Resources[i].Action = action
+	r.report.Resources[i].WaitSeconds = wait.Seconds()
+	r.wait += wait
+}
+
+func (r *importReporter) addRelocationTime(d time.Duration) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.relocation += d
+}
+
+func (r *importReporter) Report() ImportReport {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	report := ImportReport{
+		Resources:         append([]ResourceReport{}, r.report.Resources...),
+		RelocationSeconds: r.relocation.Seconds(),
+		WaitSeconds:       r.wait.Seconds(),
+	}
+	for _, resource := range report.Resources {
+		report.ImagesUploaded += resource.ImagesUploaded
+		report.ImagesPresent += resource.ImagesPresent
+		report.BytesUploaded += resource.BytesUploaded
+	}
+	return report
+}
+
+// reportingRelocator records the outcome of every relocation in the report.
+// Prefetched images are recorded when the resource that needs them asks for
+// them.
+type reportingRelocator struct {
+	relocator registry.Relocator
+	reporter  *importReporter
+}
+
+func (rr reportingRelocator) Relocate(keychain authn.Keychain, image v1.Image, destination string) (string, error) {
+	outcome, err := registry.RelocateWithOutcome(rr.relocator, keychain, image, destination)
+	if err != nil {
+		return outcome.Ref, err
+	}
+
+	rr.reporter.relocated(outcome)
+	return outcome.Ref, nil
+}
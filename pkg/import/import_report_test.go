@@ -0,0 +1,148 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+)
+
+func TestImportReport(t *testing.T) {
+	spec.Run(t, "TestImportReport", testImportReport)
+}
+
+func testImportReport(t *testing.T, when spec.G, it spec.S) {
+	const descriptor = `
+apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+lifecycle:
+  image: new-image.com/lifecycle
+clusterStores:
+- name: default
+  sources:
+  - image: new-image.com/buildpacks/dotnet-core
+clusterStacks:
+- name: base
+  buildImage:
+    image: new-image.com/stacks/base/build
+  runImage:
+    image: new-image.com/stacks/base/run
+clusterBuilders:
+- name: base
+  clusterStack: base
+  clusterStore: default
+  order:
+  - group:
+    - id: dotnet/core
+builders:
+- name: team-builder
+  namespace: team
+  clusterStack: base
+  clusterStore: default
+  order:
+  - group:
+    - id: dotnet/core
+`
+
+	var (
+		kpConfig = config.NewKpConfig("gcr.io/my-cool-repo", corev1.ObjectReference{Namespace: "some-namespace", Name: "some-serviceaccount"})
+
+		k8sClient = k8sfakes.NewSimpleClientset(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "lifecycle-image", Namespace: "kpack"},
+			Data:       map[string]string{"image": "old/image"},
+		})
+		client    = kpackfakes.NewSimpleClientset()
+		relocator = &outcomeRelocator{size: 10}
+		fetcher   = &fakeFetcher{Images: map[string]v1.Image{
+			"new-image.com/lifecycle":              fakes.NewFakeImage("lifecycledigest"),
+			"new-image.com/buildpacks/dotnet-core": fakes.NewFakeLabeledImage("io.buildpacks.buildpackage.metadata", `{"id":"dotnet/core"}`, "dotnetcoredigest"),
+			"new-image.com/stacks/base/run":        fakes.NewFakeLabeledImage("io.buildpacks.stack.id", "some-stack", "runimagedigest"),
+			"new-image.com/stacks/base/build":      fakes.NewFakeLabeledImage("io.buildpacks.stack.id", "some-stack", "buildimagedigest"),
+		}}
+	)
+
+	importDescriptor := func() ImportReport {
+		importer := NewImporter(testLogger{writer: &bytes.Buffer{}}, k8sClient, client, fetcher, relocator, &fakeWaiter{}, &fakeTimestampProvider{ts: time.Now().String()})
+		_, err := importer.ImportDescriptor(context.Background(), authn.NewMultiKeychain(), kpConfig, descriptor)
+		require.NoError(t, err)
+		return importer.Report()
+	}
+
+	resources := func(report ImportReport) []ResourceReport {
+		for i := range report.Resources {
+			report.Resources[i].WaitSeconds = 0
+		}
+		return report.Resources
+	}
+
+	it("reports the action of every resource and the images it uploaded", func() {
+		report := importDescriptor()
+		require.Equal(t, []ResourceReport{
+			{Kind: LifecycleKind, Name: "lifecycle-image", Action: ActionUpdated, ImagesUploaded: 1, BytesUploaded: 10},
+			{Kind: "ClusterStore", Name: "default", Action: ActionCreated, ImagesUploaded: 1, BytesUploaded: 10},
+			{Kind: "ClusterStack", Name: "base", Action: ActionCreated, ImagesUploaded: 2, BytesUploaded: 20},
+			{Kind: "ClusterBuilder", Name: "base", Action: ActionCreated},
+			{Kind: "Builder", Namespace: "team", Name: "team-builder", Action: ActionCreated},
+		}, resources(report))
+		require.Equal(t, 4, report.ImagesUploaded)
+		require.Equal(t, 0, report.ImagesPresent)
+		require.Equal(t, int64(40), report.BytesUploaded)
+	})
+
+	it("reports resources and images that did not change", func() {
+		importDescriptor()
+		relocator.present = true
+
+		report := importDescriptor()
+		require.Equal(t, []ResourceReport{
+			{Kind: LifecycleKind, Name: "lifecycle-image", Action: ActionUnchanged, ImagesPresent: 1},
+			{Kind: "ClusterStore", Name: "default", Action: ActionUnchanged, ImagesPresent: 1},
+			{Kind: "ClusterStack", Name: "base", Action: ActionUnchanged, ImagesPresent: 2},
+			{Kind: "ClusterBuilder", Name: "base", Action: ActionUnchanged},
+			{Kind: "Builder", Namespace: "team", Name: "team-builder", Action: ActionUnchanged},
+		}, resources(report))
+		require.Equal(t, 0, report.ImagesUploaded)
+		require.Equal(t, 4, report.ImagesPresent)
+		require.Equal(t, int64(0), report.BytesUploaded)
+	})
+}
+
+type outcomeRelocator struct {
+	present bool
+	size    int64
+}
+
+func (r *outcomeRelocator) Relocate(keychain authn.Keychain, src v1.Image, destination string) (string, error) {
+	outcome, err := r.RelocateWithOutcome(keychain, src, destination)
+	return outcome.Ref, err
+}
+
+func (r *outcomeRelocator) RelocateWithOutcome(keychain authn.Keychain, src v1.Image, destination string) (registry.RelocationOutcome, error) {
+	digest, err := src.Digest()
+	if err != nil {
+		return registry.RelocationOutcome{}, err
+	}
+
+	return registry.RelocationOutcome{
+		Ref:      fmt.Sprintf("%s@%s", destination, digest),
+		Uploaded: !r.present,
+		Size:     r.size,
+	}, nil
+}
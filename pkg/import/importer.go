@@ -3,14 +3,17 @@ package _import
 import (
 	"context"
 	"fmt"
+	"os"
+	"time"
 
-	"github.com/ghodss/yaml"
 	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
 	corev1alpha1 "github.com/pivotal/kpack/pkg/apis/core/v1alpha1"
 	"github.com/pivotal/kpack/pkg/client/clientset/versioned"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -25,6 +28,11 @@ import (
 	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 )
 
+const (
+	importTimestampKey     = "kpack.io/import-timestamp"
+	lifecycleConfigMapName = "lifecycle-image"
+)
+
 type TimestampProvider interface {
 	GetTimestamp() string
 }
@@ -39,6 +47,8 @@ type Importer struct {
 	k8sClient           kubernetes.Interface
 	printer             Printer
 	imageRelocator      registry.Relocator
+	reportingRelocator  registry.Relocator
+	reporter            *importReporter
 	imageFetcher        registry.Fetcher
 	waiter              commands.ResourceWaiter
 	clusterStoreFactory *clusterstore.Factory
@@ -51,49 +61,36 @@ type relocatedDescriptor struct {
 	clusterStores   []*v1alpha2.ClusterStore
 	clusterStacks   []*v1alpha2.ClusterStack
 	clusterBuilders []*v1alpha2.ClusterBuilder
+	builders        []*v1alpha2.Builder
+	images          []*v1alpha2.Image
 }
 
 func NewImporter(printer Printer, k8sClient kubernetes.Interface, client versioned.Interface, fetcher registry.Fetcher, relocator registry.Relocator, waiter commands.ResourceWaiter, timestampProvider TimestampProvider) *Importer {
+	reporter := newImportReporter()
+	reporting := reportingRelocator{relocator: relocator, reporter: reporter}
+	fetcher = registry.NewCachingFetcher(fetcher)
 	return &Importer{
 		imageRelocator:      relocator,
+		reportingRelocator:  reporting,
+		reporter:            reporter,
 		client:              client,
 		k8sClient:           k8sClient,
 		printer:             printer,
 		waiter:              waiter,
 		imageFetcher:        fetcher,
 		timestampProvider:   timestampProvider,
-		clusterStackFactory: clusterstack.NewFactory(printer, relocator, fetcher),
-		clusterStoreFactory: clusterstore.NewFactory(printer, relocator, fetcher),
+		clusterStackFactory: clusterstack.NewFactory(printer, reporting, fetcher),
+		clusterStoreFactory: clusterstore.NewFactory(printer, reporting, fetcher),
 	}
 }
 
-func (i *Importer) ReadDescriptor(rawDescriptor string) (DependencyDescriptor, error) {
-	var api API
-	if err := yaml.Unmarshal([]byte(rawDescriptor), &api); err != nil {
-		return DependencyDescriptor{}, err
-	}
-
-	var descriptor DependencyDescriptor
-	switch api.Version {
-	case APIVersionV1:
-		var d1 DependencyDescriptorV1
-		if err := yaml.Unmarshal([]byte(rawDescriptor), &d1); err != nil {
-			return DependencyDescriptor{}, err
-		}
-		descriptor = d1.ToNextVersion()
-	case CurrentAPIVersion:
-		if err := yaml.Unmarshal([]byte(rawDescriptor), &descriptor); err != nil {
-			return DependencyDescriptor{}, err
-		}
-	default:
-		return DependencyDescriptor{}, errors.Errorf("did not find expected apiVersion, must be one of: %s", []string{APIVersionV1, CurrentAPIVersion})
-	}
-
-	if err := descriptor.Validate(); err != nil {
-		return DependencyDescriptor{}, err
-	}
+// Report returns the report of the descriptors imported so far.
+func (i *Importer) Report() ImportReport {
+	return i.reporter.Report()
+}
 
-	return descriptor, nil
+func (i *Importer) ReadDescriptor(rawDescriptor string) (DependencyDescriptor, error) {
+	return ReadDescriptor(rawDescriptor)
 }
 
 func (i *Importer) ImportDescriptor(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, rawDescriptor string) ([]runtime.Object, error) {
@@ -102,22 +99,37 @@ func (i *Importer) ImportDescriptor(ctx context.Context, keychain authn.Keychain
 		return nil, err
 	}
 
+	start := time.Now()
+	if err := i.prefetch(keychain, kpConfig, descriptor); err != nil {
+		return nil, err
+	}
+
 	rDescriptor, objects, err := i.relocateDescriptor(ctx, keychain, kpConfig, i.timestampProvider.GetTimestamp(), descriptor)
 	if err != nil {
 		return nil, err
 	}
+	i.reporter.addRelocationTime(time.Since(start))
+
+	r := &rollback{}
+	if err := i.saveDescriptor(ctx, r, rDescriptor); err != nil {
+		return nil, i.rollBack(r, err)
+	}
 
+	return objects, nil
+}
+
+func (i *Importer) saveDescriptor(ctx context.Context, r *rollback, rDescriptor relocatedDescriptor) error {
 	if rDescriptor.lifecycle != nil {
-		if err := i.patchLifecycleConfigMap(ctx, rDescriptor.lifecycle); err != nil {
-			return nil, err
+		if err := i.patchLifecycleConfigMap(ctx, r, rDescriptor.lifecycle); err != nil {
+			return err
 		}
 	}
 
 	storeToGeneration := map[string]int64{}
 	for _, store := range rDescriptor.clusterStores {
-		gen, err := i.saveClusterStore(ctx, store)
+		gen, err := i.saveClusterStore(ctx, r, store)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		storeToGeneration[store.Name] = gen
@@ -125,21 +137,33 @@ func (i *Importer) ImportDescriptor(ctx context.Context, keychain authn.Keychain
 
 	stackToGeneration := map[string]int64{}
 	for _, stack := range rDescriptor.clusterStacks {
-		gen, err := i.saveClusterStack(ctx, stack)
+		gen, err := i.saveClusterStack(ctx, r, stack)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		stackToGeneration[stack.Name] = gen
 	}
 
 	for _, builder := range rDescriptor.clusterBuilders {
-		if err := i.saveClusterBuilder(ctx, storeToGeneration, stackToGeneration, builder); err != nil {
-			return nil, err
+		if err := i.saveClusterBuilder(ctx, r, storeToGeneration, stackToGeneration, builder); err != nil {
+			return err
 		}
 	}
 
-	return objects, nil
+	for _, builder := range rDescriptor.builders {
+		if err := i.saveBuilder(ctx, r, storeToGeneration, stackToGeneration, builder); err != nil {
+			return err
+		}
+	}
+
+	for _, image := range rDescriptor.images {
+		if err := i.saveImage(ctx, r, image); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (i *Importer) ImportDescriptorDryRun(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, rawDescriptor string) ([]runtime.Object, error) {
@@ -148,6 +172,10 @@ func (i *Importer) ImportDescriptorDryRun(ctx context.Context, keychain authn.Ke
 		return nil, err
 	}
 
+	if err := i.prefetch(keychain, kpConfig, descriptor); err != nil {
+		return nil, err
+	}
+
 	_, objects, err := i.relocateDescriptor(ctx, keychain, kpConfig, i.timestampProvider.GetTimestamp(), descriptor)
 	if err != nil {
 		return nil, err
@@ -156,6 +184,63 @@ func (i *Importer) ImportDescriptorDryRun(ctx context.Context, keychain authn.Ke
 	return objects, nil
 }
 
+// prefetch hands every remote image in the descriptor to the relocator up front
+// when it is able to fetch and relocate them concurrently. The fetched images
+// are cached, so relocating the descriptor does not fetch them again.
+func (i *Importer) prefetch(keychain authn.Keychain, kpConfig config.KpConfig, descriptor DependencyDescriptor) error {
+	prefetcher, ok := i.imageRelocator.(registry.Prefetcher)
+	if !ok {
+		return nil
+	}
+
+	if _, err := kpConfig.DefaultRepository(); err != nil {
+		return errors.Wrap(err, "failed to get default repository")
+	}
+
+	type prefetchImage struct {
+		ref    string
+		kind   config.ImageKind
+		values config.RepositoryValues
+	}
+
+	var images []prefetchImage
+	if descriptor.HasLifecycleImage() {
+		images = append(images, prefetchImage{ref: descriptor.GetLifecycleImage(), kind: config.LifecycleImage})
+	}
+	for _, store := range descriptor.ClusterStores {
+		for _, source := range store.Sources {
+			images = append(images, prefetchImage{ref: source.Image, kind: config.BuildpackageImage})
+		}
+	}
+	for _, stack := range descriptor.GetClusterStacks() {
+		values := config.RepositoryValues{Name: stack.Name}
+		images = append(images,
+			prefetchImage{ref: stack.BuildImage.Image, kind: config.BuildImage, values: values},
+			prefetchImage{ref: stack.RunImage.Image, kind: config.RunImage, values: values},
+		)
+	}
+
+	var jobs []registry.RelocationJob
+	for _, img := range images {
+		if _, err := os.Stat(img.ref); err == nil {
+			continue
+		}
+
+		img := img
+		jobs = append(jobs, registry.RelocationJob{Fetch: func() (v1.Image, string, error) {
+			image, err := i.imageFetcher.Fetch(keychain, img.ref)
+			if err != nil {
+				return nil, "", err
+			}
+
+			repository, err := relocationRepository(image, kpConfig, img.kind, img.values)
+			return image, repository, err
+		}})
+	}
+
+	return prefetcher.Prefetch(keychain, jobs)
+}
+
 func (i *Importer) relocateDescriptor(ctx context.Context, keychain authn.Keychain, kpConfig config.KpConfig, ts string, descriptor DependencyDescriptor) (relocatedDescriptor, []runtime.Object, error) {
 	var (
 		updatedLifecycle *corev1.ConfigMap
@@ -177,7 +262,7 @@ func (i *Importer) relocateDescriptor(ctx context.Context, keychain authn.Keycha
 		if err != nil {
 			return relocatedDescriptor{}, nil, err
 		}
-		rStore.Annotations = k8s.MergeAnnotations(rStore.Annotations, map[string]string{"kpack.io/import-timestamp": ts})
+		rStore.Annotations = k8s.MergeAnnotations(rStore.Annotations, map[string]string{importTimestampKey: ts})
 
 		clusterstores = append(clusterstores, rStore)
 		objs = append(objs, rStore)
@@ -189,7 +274,7 @@ func (i *Importer) relocateDescriptor(ctx context.Context, keychain authn.Keycha
 		if err != nil {
 			return relocatedDescriptor{}, nil, err
 		}
-		rStack.Annotations = k8s.MergeAnnotations(rStack.Annotations, map[string]string{"kpack.io/import-timestamp": ts})
+		rStack.Annotations = k8s.MergeAnnotations(rStack.Annotations, map[string]string{importTimestampKey: ts})
 
 		clusterstacks = append(clusterstacks, rStack)
 		objs = append(objs, rStack)
@@ -201,17 +286,43 @@ func (i *Importer) relocateDescriptor(ctx context.Context, keychain authn.Keycha
 		if err != nil {
 			return relocatedDescriptor{}, nil, err
 		}
-		rBuilder.Annotations = k8s.MergeAnnotations(rBuilder.Annotations, map[string]string{"kpack.io/import-timestamp": ts})
+		rBuilder.Annotations = k8s.MergeAnnotations(rBuilder.Annotations, map[string]string{importTimestampKey: ts})
 
 		clusterBuilders = append(clusterBuilders, rBuilder)
 		objs = append(objs, rBuilder)
 	}
 
+	builders := make([]*v1alpha2.Builder, 0)
+	for _, builder := range descriptor.Builders {
+		rBuilder, err := i.constructBuilder(kpConfig, builder)
+		if err != nil {
+			return relocatedDescriptor{}, nil, err
+		}
+		rBuilder.Annotations = k8s.MergeAnnotations(rBuilder.Annotations, map[string]string{importTimestampKey: ts})
+
+		builders = append(builders, rBuilder)
+		objs = append(objs, rBuilder)
+	}
+
+	images := make([]*v1alpha2.Image, 0)
+	for _, image := range descriptor.Images {
+		rImage, err := i.constructImage(image)
+		if err != nil {
+			return relocatedDescriptor{}, nil, err
+		}
+		rImage.Annotations = k8s.MergeAnnotations(rImage.Annotations, map[string]string{importTimestampKey: ts})
+
+		images = append(images, rImage)
+		objs = append(objs, rImage)
+	}
+
 	return relocatedDescriptor{
 		lifecycle:       updatedLifecycle,
 		clusterStores:   clusterstores,
 		clusterStacks:   clusterstacks,
 		clusterBuilders: clusterBuilders,
+		builders:        builders,
+		images:          images,
 	}, objs, nil
 }
 
@@ -219,30 +330,31 @@ func (i *Importer) relocateLifecycle(ctx context.Context, keychain authn.Keychai
 	if err := i.printer.PrintStatus("Importing Lifecycle..."); err != nil {
 		return nil, err
 	}
+	i.reporter.start(LifecycleKind, "", lifecycleConfigMapName)
 
 	lifecycleImage, err := i.imageFetcher.Fetch(keychain, lifecyle)
 	if err != nil {
 		return nil, err
 	}
 
-	defaultRepo, err := kpConfig.DefaultRepository()
+	lifecycleRepo, err := kpConfig.ImageRepository(config.LifecycleImage, config.RepositoryValues{})
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to get default repository")
+		return nil, errors.Wrap(err, "failed to get lifecycle repository")
 	}
 
-	relocatedLifecycle, err := i.imageRelocator.Relocate(keychain, lifecycleImage, defaultRepo)
+	relocatedLifecycle, err := i.reportingRelocator.Relocate(keychain, lifecycleImage, lifecycleRepo)
 	if err != nil {
 		return nil, err
 	}
 
-	existingLifecycleConfig, err := i.k8sClient.CoreV1().ConfigMaps("kpack").Get(ctx, "lifecycle-image", metav1.GetOptions{})
+	existingLifecycleConfig, err := i.k8sClient.CoreV1().ConfigMaps("kpack").Get(ctx, lifecycleConfigMapName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 
 	newConfigMap := existingLifecycleConfig.DeepCopy()
 
-	newConfigMap.SetAnnotations(map[string]string{"kpack.io/import-timestamp": ts})
+	newConfigMap.SetAnnotations(map[string]string{importTimestampKey: ts})
 	newConfigMap.Data["image"] = relocatedLifecycle
 	return newConfigMap, nil
 }
@@ -251,6 +363,7 @@ func (i *Importer) constructClusterStore(ctx context.Context, keychain authn.Key
 	if err := i.printer.PrintStatus("Importing ClusterStore '%s'...", store.Name); err != nil {
 		return nil, err
 	}
+	i.reporter.start(v1alpha2.ClusterStoreKind, "", store.Name)
 
 	existingStore, err := i.client.KpackV1alpha2().ClusterStores().Get(ctx, store.Name, metav1.GetOptions{})
 	if err != nil && !k8serrors.IsNotFound(err) {
@@ -275,6 +388,7 @@ func (i *Importer) constructClusterStack(keychain authn.Keychain, kpConfig confi
 	if err := i.printer.PrintStatus("Importing ClusterStack '%s'...", stack.Name); err != nil {
 		return nil, err
 	}
+	i.reporter.start(v1alpha2.ClusterStackKind, "", stack.Name)
 
 	newStack, err := i.clusterStackFactory.MakeStack(keychain, stack.Name, stack.BuildImage.Image, stack.RunImage.Image, kpConfig)
 	if err != nil {
@@ -288,6 +402,7 @@ func (i *Importer) constructClusterBuilder(kpConfig config.KpConfig, builder Clu
 	if err := i.printer.PrintStatus("Importing ClusterBuilder '%s'...", builder.Name); err != nil {
 		return nil, err
 	}
+	i.reporter.start(v1alpha2.ClusterBuilderKind, "", builder.Name)
 
 	defaultRepo, err := kpConfig.DefaultRepository()
 	if err != nil {
@@ -327,7 +442,7 @@ func (i *Importer) constructClusterBuilder(kpConfig config.KpConfig, builder Clu
 	return newCB, nil
 }
 
-func (i *Importer) patchLifecycleConfigMap(ctx context.Context, updatedLifecycle *corev1.ConfigMap) error {
+func (i *Importer) patchLifecycleConfigMap(ctx context.Context, r *rollback, updatedLifecycle *corev1.ConfigMap) error {
 	existingLifecycle, err := i.k8sClient.CoreV1().ConfigMaps("kpack").Get(ctx, updatedLifecycle.Name, metav1.GetOptions{})
 	if err != nil {
 		return err
@@ -339,25 +454,35 @@ func (i *Importer) patchLifecycleConfigMap(ctx context.Context, updatedLifecycle
 	}
 
 	_, err = i.k8sClient.CoreV1().ConfigMaps("kpack").Patch(ctx, updatedLifecycle.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
 
-	return err
+	i.recordLifecycleConfigMap(r, existingLifecycle)
+	i.reporter.saved(LifecycleKind, "", updatedLifecycle.Name, updateAction(existingLifecycle.Data["image"] == updatedLifecycle.Data["image"]), 0)
+	return nil
 }
 
-func (i *Importer) saveClusterStore(ctx context.Context, relocatedStore *v1alpha2.ClusterStore) (int64, error) {
+func (i *Importer) saveClusterStore(ctx context.Context, r *rollback, relocatedStore *v1alpha2.ClusterStore) (int64, error) {
 	existingStore, err := i.client.KpackV1alpha2().ClusterStores().Get(ctx, relocatedStore.Name, metav1.GetOptions{})
 	if err != nil && !k8serrors.IsNotFound(err) {
 		return 0, err
 	}
 
-	var store *v1alpha2.ClusterStore
+	var (
+		store  *v1alpha2.ClusterStore
+		action = ActionCreated
+	)
 	if k8serrors.IsNotFound(err) {
 		store, err = i.client.KpackV1alpha2().ClusterStores().Create(ctx, relocatedStore, metav1.CreateOptions{})
 		if err != nil {
 			return 0, err
 		}
+		i.recordClusterStore(r, relocatedStore.Name, nil)
 	} else {
 		updateStore := existingStore.DeepCopy()
 		updateStore.Spec.Sources = createBuildpackageSuperset(updateStore, relocatedStore)
+		action = updateAction(equality.Semantic.DeepEqual(existingStore.Spec, updateStore.Spec))
 		updateStore.Annotations = k8s.MergeAnnotations(updateStore.Annotations, relocatedStore.Annotations)
 		patch, err := k8s.CreatePatch(existingStore, updateStore)
 		if err != nil {
@@ -367,30 +492,38 @@ func (i *Importer) saveClusterStore(ctx context.Context, relocatedStore *v1alpha
 		if err != nil {
 			return 0, err
 		}
+		i.recordClusterStore(r, existingStore.Name, existingStore)
 	}
 
+	start := time.Now()
 	if err := i.waiter.Wait(ctx, store); err != nil {
 		return 0, err
 	}
+	i.reporter.saved(v1alpha2.ClusterStoreKind, "", relocatedStore.Name, action, time.Since(start))
 
 	return store.Generation, nil
 }
 
-func (i *Importer) saveClusterStack(ctx context.Context, relocatedStack *v1alpha2.ClusterStack) (int64, error) {
+func (i *Importer) saveClusterStack(ctx context.Context, r *rollback, relocatedStack *v1alpha2.ClusterStack) (int64, error) {
 	exstingStack, err := i.client.KpackV1alpha2().ClusterStacks().Get(ctx, relocatedStack.Name, metav1.GetOptions{})
 	if err != nil && !k8serrors.IsNotFound(err) {
 		return 0, err
 	}
 
-	var stack *v1alpha2.ClusterStack
+	var (
+		stack  *v1alpha2.ClusterStack
+		action = ActionCreated
+	)
 	if k8serrors.IsNotFound(err) {
 		stack, err = i.client.KpackV1alpha2().ClusterStacks().Create(ctx, relocatedStack, metav1.CreateOptions{})
 		if err != nil {
 			return 0, err
 		}
+		i.recordClusterStack(r, relocatedStack.Name, nil)
 	} else {
 		updateStack := exstingStack.DeepCopy()
 		updateStack.Spec = relocatedStack.Spec
+		action = updateAction(equality.Semantic.DeepEqual(exstingStack.Spec, updateStack.Spec))
 		updateStack.Annotations = k8s.MergeAnnotations(updateStack.Annotations, relocatedStack.Annotations)
 		patch, err := k8s.CreatePatch(exstingStack, updateStack)
 		if err != nil {
@@ -400,29 +533,37 @@ func (i *Importer) saveClusterStack(ctx context.Context, relocatedStack *v1alpha
 		if err != nil {
 			return 0, err
 		}
+		i.recordClusterStack(r, exstingStack.Name, exstingStack)
 	}
+	start := time.Now()
 	if err := i.waiter.Wait(ctx, stack); err != nil {
 		return 0, err
 	}
+	i.reporter.saved(v1alpha2.ClusterStackKind, "", relocatedStack.Name, action, time.Since(start))
 
 	return stack.Generation, nil
 }
 
-func (i *Importer) saveClusterBuilder(ctx context.Context, storeToGeneration, stackToGeneration map[string]int64, relocatedBuilder *v1alpha2.ClusterBuilder) error {
+func (i *Importer) saveClusterBuilder(ctx context.Context, r *rollback, storeToGeneration, stackToGeneration map[string]int64, relocatedBuilder *v1alpha2.ClusterBuilder) error {
 	existingBuilder, err := i.client.KpackV1alpha2().ClusterBuilders().Get(ctx, relocatedBuilder.Name, metav1.GetOptions{})
 	if err != nil && !k8serrors.IsNotFound(err) {
 		return err
 	}
 
-	var builder *v1alpha2.ClusterBuilder
+	var (
+		builder *v1alpha2.ClusterBuilder
+		action  = ActionCreated
+	)
 	if k8serrors.IsNotFound(err) {
 		builder, err = i.client.KpackV1alpha2().ClusterBuilders().Create(ctx, relocatedBuilder, metav1.CreateOptions{})
 		if err != nil {
 			return err
 		}
+		i.recordClusterBuilder(r, relocatedBuilder.Name, nil)
 	} else {
 		updateBuilder := existingBuilder.DeepCopy()
 		updateBuilder.Spec = relocatedBuilder.Spec
+		action = updateAction(equality.Semantic.DeepEqual(existingBuilder.Spec, updateBuilder.Spec))
 		updateBuilder.Annotations = k8s.MergeAnnotations(updateBuilder.Annotations, relocatedBuilder.Annotations)
 		patch, err := k8s.CreatePatch(existingBuilder, updateBuilder)
 		if err != nil {
@@ -432,9 +573,23 @@ func (i *Importer) saveClusterBuilder(ctx context.Context, storeToGeneration, st
 		if err != nil {
 			return err
 		}
+		i.recordClusterBuilder(r, existingBuilder.Name, existingBuilder)
 	}
 
-	return i.waiter.Wait(ctx, builder, builderHasResolved(storeToGeneration[relocatedBuilder.Spec.Store.Name], stackToGeneration[relocatedBuilder.Spec.Stack.Name]))
+	start := time.Now()
+	if err := i.waiter.Wait(ctx, builder, builderHasResolved(storeToGeneration[relocatedBuilder.Spec.Store.Name], stackToGeneration[relocatedBuilder.Spec.Stack.Name])); err != nil {
+		return err
+	}
+	i.reporter.saved(v1alpha2.ClusterBuilderKind, "", relocatedBuilder.Name, action, time.Since(start))
+	return nil
+}
+
+// updateAction is the action of updating a resource that already exists.
+func updateAction(unchanged bool) ReportAction {
+	if unchanged {
+		return ActionUnchanged
+	}
+	return ActionUpdated
 }
 
 func buildpackagesForSource(sources []Source) []string {
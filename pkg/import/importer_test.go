@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8sfakes "k8s.io/client-go/kubernetes/fake"
@@ -90,7 +91,9 @@ func testImporter(t *testing.T, when spec.G, it spec.S) {
 						Name:      "some-serviceaccount",
 					},
 				},
-			}, kubectlAnnotation, timestampAnnotation)
+			}, upstreamAnnotation(map[string]string{
+				fmt.Sprintf("gcr.io/my-cool-repo@sha256:%s", dotnetCoreDigest): "new-image.com/buildpacks/dotnet-core",
+			}), kubectlAnnotation, timestampAnnotation)
 			expectedClusterStack = annotate(t, &v1alpha2.ClusterStack{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "ClusterStack",
@@ -112,7 +115,10 @@ func testImporter(t *testing.T, when spec.G, it spec.S) {
 						Name:      "some-serviceaccount",
 					},
 				},
-			}, timestampAnnotation)
+			}, upstreamAnnotation(map[string]string{
+				fmt.Sprintf("gcr.io/my-cool-repo@sha256:%s", buildImageDigest): "new-image.com/stacks/base/build",
+				fmt.Sprintf("gcr.io/my-cool-repo@sha256:%s", runImageDigest):   "new-image.com/stacks/base/run",
+			}), timestampAnnotation)
 			expectedDefaultClusterStack = annotate(t, &v1alpha2.ClusterStack{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "ClusterStack",
@@ -134,7 +140,10 @@ func testImporter(t *testing.T, when spec.G, it spec.S) {
 						Name:      "some-serviceaccount",
 					},
 				},
-			}, timestampAnnotation)
+			}, upstreamAnnotation(map[string]string{
+				fmt.Sprintf("gcr.io/my-cool-repo@sha256:%s", buildImageDigest): "new-image.com/stacks/base/build",
+				fmt.Sprintf("gcr.io/my-cool-repo@sha256:%s", runImageDigest):   "new-image.com/stacks/base/run",
+			}), timestampAnnotation)
 			expectedClusterBuilder = annotate(t, &v1alpha2.ClusterBuilder{
 				TypeMeta: metav1.TypeMeta{
 					Kind:       "ClusterBuilder",
@@ -543,8 +552,8 @@ clusterBuilders:
     - id: tanzu-buildpacks/nodejs
 `,
 					ExpectPatches: []string{
-						`{"spec":{"sources":[{"image":"gcr.io/my-cool-repo@sha256:dotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:newdotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:nodejsdigest"}]}}`,
-						`{"spec":{"buildImage":{"image":"gcr.io/my-cool-repo@sha256:newbuildimagedigest"},"runImage":{"image":"gcr.io/my-cool-repo@sha256:newrunimagedigest"}}}`,
+						`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"gcr.io/my-cool-repo@sha256:newdotnetcoredigest\":\"new-image.com/buildpacks/dotnet-core\",\"gcr.io/my-cool-repo@sha256:nodejsdigest\":\"new-image.com/buildpacks/nodejs\"}"}},"spec":{"sources":[{"image":"gcr.io/my-cool-repo@sha256:dotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:newdotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:nodejsdigest"}]}}`,
+						`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"gcr.io/my-cool-repo@sha256:newbuildimagedigest\":\"new-image.com/stacks/base/build\",\"gcr.io/my-cool-repo@sha256:newrunimagedigest\":\"new-image.com/stacks/base/run\"}"}},"spec":{"buildImage":{"image":"gcr.io/my-cool-repo@sha256:newbuildimagedigest"},"runImage":{"image":"gcr.io/my-cool-repo@sha256:newrunimagedigest"}}}`,
 						`{"metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"base\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"gcr.io/my-cool-repo:clusterbuilder-base\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"base\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"default\"},\"order\":[{\"group\":[{\"id\":\"tanzu-buildpacks/dotnet-core\"}]},{\"group\":[{\"id\":\"tanzu-buildpacks/nodejs\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}},"spec":{"order":[{"group":[{"id":"tanzu-buildpacks/dotnet-core"}]},{"group":[{"id":"tanzu-buildpacks/nodejs"}]}],"tag":"gcr.io/my-cool-repo:clusterbuilder-base"}}`,
 						`{"metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"default\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"gcr.io/my-cool-repo:clusterbuilder-default\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"base\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"default\"},\"order\":[{\"group\":[{\"id\":\"tanzu-buildpacks/dotnet-core\"}]},{\"group\":[{\"id\":\"tanzu-buildpacks/nodejs\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}},"spec":{"order":[{"group":[{"id":"tanzu-buildpacks/dotnet-core"}]},{"group":[{"id":"tanzu-buildpacks/nodejs"}]}],"tag":"gcr.io/my-cool-repo:clusterbuilder-default"}}`,
 						`{"data":{"image":"gcr.io/my-cool-repo@sha256:newlifecycledigest"},"metadata":{"annotations":{"kpack.io/import-timestamp":"0001-01-01 00:00:00 +0000 UTC"}}}`,
@@ -604,10 +613,10 @@ clusterBuilders:
     - id: tanzu-buildpacks/nodejs
 `,
 					ExpectPatches: []string{
-						`{"spec":{"buildImage":{"image":"gcr.io/my-cool-repo@sha256:newbuildimagedigest"},"runImage":{"image":"gcr.io/my-cool-repo@sha256:newrunimagedigest"}}}`,
+						`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"gcr.io/my-cool-repo@sha256:newbuildimagedigest\":\"new-image.com/stacks/base/build\",\"gcr.io/my-cool-repo@sha256:newrunimagedigest\":\"new-image.com/stacks/base/run\"}"}},"spec":{"buildImage":{"image":"gcr.io/my-cool-repo@sha256:newbuildimagedigest"},"runImage":{"image":"gcr.io/my-cool-repo@sha256:newrunimagedigest"}}}`,
 						`{"metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"base\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"gcr.io/my-cool-repo:clusterbuilder-base\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"base\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"default\"},\"order\":[{\"group\":[{\"id\":\"tanzu-buildpacks/dotnet-core\"}]},{\"group\":[{\"id\":\"tanzu-buildpacks/nodejs\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}},"spec":{"order":[{"group":[{"id":"tanzu-buildpacks/dotnet-core"}]},{"group":[{"id":"tanzu-buildpacks/nodejs"}]}],"tag":"gcr.io/my-cool-repo:clusterbuilder-base"}}`,
 						`{"metadata":{"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"ClusterBuilder\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"default\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"gcr.io/my-cool-repo:clusterbuilder-default\",\"stack\":{\"kind\":\"ClusterStack\",\"name\":\"base\"},\"store\":{\"kind\":\"ClusterStore\",\"name\":\"default\"},\"order\":[{\"group\":[{\"id\":\"tanzu-buildpacks/dotnet-core\"}]},{\"group\":[{\"id\":\"tanzu-buildpacks/nodejs\"}]}],\"serviceAccountRef\":{\"namespace\":\"some-namespace\",\"name\":\"some-serviceaccount\"}},\"status\":{\"stack\":{}}}"}},"spec":{"order":[{"group":[{"id":"tanzu-buildpacks/dotnet-core"}]},{"group":[{"id":"tanzu-buildpacks/nodejs"}]}],"tag":"gcr.io/my-cool-repo:clusterbuilder-default"}}`,
-						`{"spec":{"sources":[{"image":"gcr.io/my-cool-repo@sha256:dotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:newdotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:nodejsdigest"}]}}`,
+						`{"metadata":{"annotations":{"kpack.io/upstream-images":"{\"gcr.io/my-cool-repo@sha256:newdotnetcoredigest\":\"new-image.com/buildpacks/dotnet-core\",\"gcr.io/my-cool-repo@sha256:nodejsdigest\":\"new-image.com/buildpacks/nodejs\"}"}},"spec":{"sources":[{"image":"gcr.io/my-cool-repo@sha256:dotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:newdotnetcoredigest"},{"image":"gcr.io/my-cool-repo@sha256:nodejsdigest"}]}}`,
 					},
 				}.TestImporter(t)
 			})
@@ -655,6 +664,193 @@ clusterBuilders:
 		})
 	})
 
+	when("saving a resource fails", func() {
+		it("rolls back the changes made so far", func() {
+			existingStack := &v1alpha2.ClusterStack{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "base",
+					Annotations: map[string]string{"kpack.io/import-timestamp": "old-timestamp"},
+				},
+				Spec: v1alpha2.ClusterStackSpec{
+					Id:         stackId,
+					BuildImage: v1alpha2.ClusterStackSpecImage{Image: "old/build"},
+					RunImage:   v1alpha2.ClusterStackSpecImage{Image: "old/run"},
+				},
+			}
+			lifecycleConfig := existingLifecycle.DeepCopy()
+			lifecycleConfig.Annotations = map[string]string{"kpack.io/import-timestamp": "old-timestamp"}
+
+			listers := kpacktesthelpers.NewListers([]runtime.Object{lifecycleConfig, existingStack})
+			client := kpackfakes.NewSimpleClientset(listers.BuildServiceObjects()...)
+			k8sClient := k8sfakes.NewSimpleClientset(listers.GetKubeObjects()...)
+			client.PrependReactor("create", "clusterbuilders", func(action clientgotesting.Action) (bool, runtime.Object, error) {
+				return true, nil, errors.New("some-create-error")
+			})
+
+			buffer := &bytes.Buffer{}
+			importer := NewImporter(testLogger{writer: buffer}, k8sClient, client, &fakeFetcher{Images: map[string]v1.Image{
+				"new-image.com/lifecycle":              fakes.NewFakeImage(lifecycleDigest),
+				"new-image.com/buildpacks/dotnet-core": fakes.NewFakeLabeledImage("io.buildpacks.buildpackage.metadata", fmt.Sprintf("{\"id\":%q}", dotnetCoreId), dotnetCoreDigest),
+				"new-image.com/stacks/base/run":        fakes.NewFakeLabeledImage("io.buildpacks.stack.id", stackId, runImageDigest),
+				"new-image.com/stacks/base/build":      fakes.NewFakeLabeledImage("io.buildpacks.stack.id", stackId, buildImageDigest),
+			}}, &fakeRelocator{}, &fakeWaiter{}, &fakeTimestampProvider{ts: time.Time{}.String()})
+
+			_, err := importer.ImportDescriptor(context.Background(), authn.NewMultiKeychain(), kpConfig, `
+apiVersion: kp.kpack.io/v1alpha3
+kind: DependencyDescriptor
+lifecycle:
+  image: new-image.com/lifecycle
+clusterStores:
+- name: default
+  sources:
+  - image: new-image.com/buildpacks/dotnet-core
+clusterStacks:
+- name: base
+  buildImage:
+    image: new-image.com/stacks/base/build
+  runImage:
+    image: new-image.com/stacks/base/run
+clusterBuilders:
+- name: base
+  clusterStack: base
+  clusterStore: default
+  order:
+  - group:
+    - id: tanzu-buildpacks/dotnet-core
+`)
+			require.EqualError(t, err, "import rolled back: some-create-error")
+			require.Contains(t, buffer.String(), "Import failed, rolling back...")
+
+			_, err = client.KpackV1alpha2().ClusterStores().Get(context.Background(), "default", metav1.GetOptions{})
+			require.True(t, k8serrors.IsNotFound(err))
+
+			stack, err := client.KpackV1alpha2().ClusterStacks().Get(context.Background(), "base", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, existingStack.Spec, stack.Spec)
+			require.Equal(t, existingStack.Annotations, stack.Annotations)
+
+			lifecycle, err := k8sClient.CoreV1().ConfigMaps("kpack").Get(context.Background(), "lifecycle-image", metav1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, "old/image", lifecycle.Data["image"])
+			require.Equal(t, lifecycleConfig.Annotations, lifecycle.Annotations)
+		})
+	})
+
+	when("importing namespaced builders and images", func() {
+		const descriptor = `
+apiVersion: kp.kpack.io/v1alpha4
+kind: DependencyDescriptor
+builders:
+- name: team-builder
+  namespace: team
+  clusterStack: base
+  clusterStore: default
+  order:
+  - group:
+    - id: tanzu-buildpacks/dotnet-core
+images:
+- name: bootstrap
+  namespace: team
+  tag: gcr.io/team/bootstrap
+  source:
+    git:
+      url: https://github.com/team/bootstrap
+      revision: main
+  builder: team-builder
+  env:
+  - name: BP_DEBUG
+    value: "true"
+`
+
+		var expectedBuilder runtime.Object
+
+		it.Before(func() {
+			expectedBuilder = annotate(t, &v1alpha2.Builder{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Builder",
+					APIVersion: "kpack.io/v1alpha2",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "team-builder",
+					Namespace: "team",
+				},
+				Spec: v1alpha2.NamespacedBuilderSpec{
+					BuilderSpec: v1alpha2.BuilderSpec{
+						Tag:   "gcr.io/my-cool-repo:builder-team-team-builder",
+						Stack: corev1.ObjectReference{Kind: "ClusterStack", Name: "base"},
+						Store: corev1.ObjectReference{Kind: "ClusterStore", Name: "default"},
+						Order: []corev1alpha1.OrderEntry{
+							{Group: []corev1alpha1.BuildpackRef{{BuildpackInfo: corev1alpha1.BuildpackInfo{Id: "tanzu-buildpacks/dotnet-core"}}}},
+						},
+					},
+					ServiceAccountName: "default",
+				},
+			}, kubectlAnnotation, timestampAnnotation)
+		})
+
+		it("creates builders and images on a new cluster", func() {
+			expectedImage := annotate(t, &v1alpha2.Image{
+				TypeMeta: metav1.TypeMeta{
+					Kind:       "Image",
+					APIVersion: "kpack.io/v1alpha2",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "bootstrap",
+					Namespace: "team",
+				},
+				Spec: v1alpha2.ImageSpec{
+					Tag:                "gcr.io/team/bootstrap",
+					Builder:            corev1.ObjectReference{Kind: "Builder", Namespace: "team", Name: "team-builder"},
+					ServiceAccountName: "default",
+					Source: corev1alpha1.SourceConfig{
+						Git: &corev1alpha1.Git{URL: "https://github.com/team/bootstrap", Revision: "main"},
+					},
+					Build: &v1alpha2.ImageBuild{
+						Env: []corev1.EnvVar{{Name: "BP_DEBUG", Value: "true"}},
+					},
+				},
+			}, kubectlAnnotation, timestampAnnotation)
+
+			TestImport{
+				KpConfig:             kpConfig,
+				DependencyDescriptor: descriptor,
+				ExpectCreates: []runtime.Object{
+					expectedBuilder,
+					expectedImage,
+				},
+			}.TestImporter(t)
+		})
+
+		it("only updates the fields the descriptor sets on existing images", func() {
+			existingImage := &v1alpha2.Image{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "bootstrap",
+					Namespace: "team",
+				},
+				Spec: v1alpha2.ImageSpec{
+					Tag:                "gcr.io/team/bootstrap",
+					Builder:            corev1.ObjectReference{Kind: "ClusterBuilder", Name: "default"},
+					ServiceAccountName: "team-service-account",
+					Source: corev1alpha1.SourceConfig{
+						Git: &corev1alpha1.Git{URL: "https://github.com/team/bootstrap", Revision: "main"},
+					},
+				},
+			}
+
+			TestImport{
+				Objects:              []runtime.Object{existingImage},
+				KpConfig:             kpConfig,
+				DependencyDescriptor: descriptor,
+				ExpectCreates: []runtime.Object{
+					expectedBuilder,
+				},
+				ExpectPatches: []string{
+					`{"metadata":{"annotations":{"kpack.io/import-timestamp":"0001-01-01 00:00:00 +0000 UTC","kubectl.kubernetes.io/last-applied-configuration":"{\"kind\":\"Image\",\"apiVersion\":\"kpack.io/v1alpha2\",\"metadata\":{\"name\":\"bootstrap\",\"namespace\":\"team\",\"creationTimestamp\":null},\"spec\":{\"tag\":\"gcr.io/team/bootstrap\",\"builder\":{\"kind\":\"Builder\",\"namespace\":\"team\",\"name\":\"team-builder\"},\"serviceAccountName\":\"default\",\"source\":{\"git\":{\"url\":\"https://github.com/team/bootstrap\",\"revision\":\"main\"}},\"build\":{\"env\":[{\"name\":\"BP_DEBUG\",\"value\":\"true\"}],\"resources\":{}}},\"status\":{}}"}},"spec":{"build":{"env":[{"name":"BP_DEBUG","value":"true"}],"resources":{}},"builder":{"kind":"Builder","name":"team-builder","namespace":"team"}}}`,
+				},
+			}.TestImporter(t)
+		})
+	})
+
 	when("importing with the dry run", func() {
 		it("uploads does not create or update any resources", func() {
 			TestImport{
@@ -714,6 +910,14 @@ func kubectlAnnotation(t *testing.T, object k8s.Annotatable) k8s.Annotatable {
 	return object
 }
 
+func upstreamAnnotation(images map[string]string) func(t *testing.T, object k8s.Annotatable) k8s.Annotatable {
+	return func(t *testing.T, object k8s.Annotatable) k8s.Annotatable {
+		require.NoError(t, k8s.SetUpstreamImages(object, images))
+
+		return object
+	}
+}
+
 func timestampAnnotation(t *testing.T, object k8s.Annotatable) k8s.Annotatable {
 	annotations := k8s.MergeAnnotations(object.GetAnnotations(), map[string]string{
 		"kpack.io/import-timestamp": time.Time{}.String(),
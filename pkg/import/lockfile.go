@@ -0,0 +1,265 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+const (
+	LockfileAPIVersion = "kp.kpack.io/v1alpha1"
+	LockfileKind       = "DependencyLockfile"
+)
+
+// Lockfile pins every image a dependency descriptor references to the digest
+// it resolved to and the image it was relocated to.
+type Lockfile struct {
+	APIVersion string        `yaml:"apiVersion" json:"apiVersion"`
+	Kind       string        `yaml:"kind" json:"kind"`
+	Images     []LockedImage `yaml:"images" json:"images"`
+}
+
+type LockedImage struct {
+	Source    string `yaml:"source" json:"source"`
+	Digest    string `yaml:"digest" json:"digest"`
+	Relocated string `yaml:"relocated,omitempty" json:"relocated,omitempty"`
+}
+
+// DefaultLockfilePath returns the lockfile path next to a descriptor, such as
+// dependencies.lock.yaml for dependencies.yaml.
+func DefaultLockfilePath(descriptorPath string) string {
+	ext := filepath.Ext(descriptorPath)
+	return strings.TrimSuffix(descriptorPath, ext) + ".lock" + ext
+}
+
+func ReadLockfile(path string) (Lockfile, error) {
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Lockfile{}, errors.Errorf("lockfile '%s' does not exist", path)
+	} else if err != nil {
+		return Lockfile{}, err
+	}
+
+	var lockfile Lockfile
+	if err := yaml.Unmarshal(buf, &lockfile); err != nil {
+		return Lockfile{}, errors.Wrapf(err, "invalid lockfile '%s'", path)
+	}
+
+	if lockfile.APIVersion != LockfileAPIVersion {
+		return Lockfile{}, errors.Errorf("invalid lockfile '%s': did not find expected apiVersion, must be one of: %s", path, []string{LockfileAPIVersion})
+	}
+	return lockfile, nil
+}
+
+func (l Lockfile) Write(path string) error {
+	buf, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf, 0644)
+}
+
+// Check returns an error unless the lockfile pins exactly the images of the
+// descriptor.
+func (l Lockfile) Check(descriptor DependencyDescriptor) error {
+	locked := map[string]struct{}{}
+	for _, image := range l.Images {
+		locked[image.Source] = struct{}{}
+	}
+
+	var missing []string
+	for _, image := range descriptor.ImageRefs() {
+		if _, ok := locked[image]; !ok {
+			missing = append(missing, image)
+		}
+		delete(locked, image)
+	}
+
+	if len(missing) > 0 {
+		return errors.Errorf("lockfile is stale: %s not locked", quoteAll(missing))
+	}
+
+	if len(locked) > 0 {
+		var extra []string
+		for image := range locked {
+			extra = append(extra, image)
+		}
+		sort.Strings(extra)
+		return errors.Errorf("lockfile is stale: %s not in the descriptor", quoteAll(extra))
+	}
+
+	return nil
+}
+
+func (l Lockfile) lookup(source string) (LockedImage, bool) {
+	for _, image := range l.Images {
+		if image.Source == source {
+			return image, true
+		}
+	}
+	return LockedImage{}, false
+}
+
+func quoteAll(images []string) string {
+	quoted := make([]string, len(images))
+	for i, image := range images {
+		quoted[i] = fmt.Sprintf("'%s'", image)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// LockRecorder records the digest of every image fetched through Fetcher and
+// the image every digest is relocated to through Relocator.
+type LockRecorder struct {
+	fetcher   registry.Fetcher
+	relocator registry.Relocator
+
+	mux       sync.Mutex
+	digests   map[string]string
+	relocated map[string]string
+}
+
+func NewLockRecorder(fetcher registry.Fetcher, relocator registry.Relocator) *LockRecorder {
+	return &LockRecorder{
+		fetcher:   fetcher,
+		relocator: relocator,
+		digests:   map[string]string{},
+		relocated: map[string]string{},
+	}
+}
+
+func (r *LockRecorder) Fetcher() registry.Fetcher {
+	return recordingFetcher{r}
+}
+
+func (r *LockRecorder) Relocator() registry.Relocator {
+	return &recordingRelocator{r}
+}
+
+// Lockfile returns the lockfile for the images of descriptor. Every image must
+// have been fetched.
+func (r *LockRecorder) Lockfile(descriptor DependencyDescriptor) (Lockfile, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	lockfile := Lockfile{APIVersion: LockfileAPIVersion, Kind: LockfileKind, Images: []LockedImage{}}
+	for _, image := range descriptor.ImageRefs() {
+		digest, ok := r.digests[image]
+		if !ok {
+			return Lockfile{}, errors.Errorf("image '%s' was not fetched", image)
+		}
+		lockfile.Images = append(lockfile.Images, LockedImage{
+			Source:    image,
+			Digest:    digest,
+			Relocated: r.relocated[digest],
+		})
+	}
+	return lockfile, nil
+}
+
+type recordingFetcher struct {
+	r *LockRecorder
+}
+
+func (f recordingFetcher) Fetch(keychain authn.Keychain, src string) (v1.Image, error) {
+	image, err := f.r.fetcher.Fetch(keychain, src)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := image.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	f.r.mux.Lock()
+	f.r.digests[src] = digest.String()
+	f.r.mux.Unlock()
+	return image, nil
+}
+
+type recordingRelocator struct {
+	r *LockRecorder
+}
+
+func (rr *recordingRelocator) Relocate(keychain authn.Keychain, image v1.Image, destination string) (string, error) {
+	outcome, err := rr.RelocateWithOutcome(keychain, image, destination)
+	return outcome.Ref, err
+}
+
+func (rr *recordingRelocator) RelocateWithOutcome(keychain authn.Keychain, image v1.Image, destination string) (registry.RelocationOutcome, error) {
+	outcome, err := registry.RelocateWithOutcome(rr.r.relocator, keychain, image, destination)
+	if err != nil {
+		return outcome, err
+	}
+
+	digest, err := image.Digest()
+	if err != nil {
+		return outcome, err
+	}
+
+	rr.r.mux.Lock()
+	rr.r.relocated[digest.String()] = outcome.Ref
+	rr.r.mux.Unlock()
+	return outcome, nil
+}
+
+func (rr *recordingRelocator) Prefetch(keychain authn.Keychain, jobs []registry.RelocationJob) error {
+	if prefetcher, ok := rr.r.relocator.(registry.Prefetcher); ok {
+		return prefetcher.Prefetch(keychain, jobs)
+	}
+	return nil
+}
+
+// LockedFetcher fetches every image at the digest the lockfile pins it to and
+// fails for images the lockfile does not pin.
+type LockedFetcher struct {
+	Fetcher  registry.Fetcher
+	Lockfile Lockfile
+}
+
+func (f LockedFetcher) Fetch(keychain authn.Keychain, src string) (v1.Image, error) {
+	locked, ok := f.Lockfile.lookup(src)
+	if !ok {
+		return nil, errors.Errorf("lockfile is stale: '%s' not locked", src)
+	}
+
+	pinned := src
+	if _, err := os.Stat(src); err != nil {
+		ref, err := name.ParseReference(src, name.WeakValidation)
+		if err != nil {
+			return nil, err
+		}
+		pinned = ref.Context().Digest(locked.Digest).String()
+	}
+
+	image, err := f.Fetcher.Fetch(keychain, pinned)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := image.Digest()
+	if err != nil {
+		return nil, err
+	}
+
+	if digest.String() != locked.Digest {
+		return nil, errors.Errorf("lockfile is stale: '%s' resolved to '%s', locked to '%s'", src, digest, locked.Digest)
+	}
+	return image, nil
+}
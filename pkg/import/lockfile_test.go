@@ -0,0 +1,132 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+)
+
+func TestLockfile(t *testing.T) {
+	spec.Run(t, "TestLockfile", testLockfile)
+}
+
+func testLockfile(t *testing.T, when spec.G, it spec.S) {
+	descriptor := importpkg.DependencyDescriptor{
+		Lifecycle: importpkg.Lifecycle{Image: "some-registry.io/repo/lifecycle"},
+		ClusterStores: []importpkg.ClusterStore{
+			{Name: "store", Sources: []importpkg.Source{{Image: "some-registry.io/repo/buildpack"}}},
+		},
+	}
+
+	it("defaults the path next to the descriptor", func() {
+		require.Equal(t, "some/dir/deps.lock.yaml", importpkg.DefaultLockfilePath("some/dir/deps.yaml"))
+		require.Equal(t, "deps.lock", importpkg.DefaultLockfilePath("deps"))
+	})
+
+	it("writes and reads lockfiles", func() {
+		dir, err := ioutil.TempDir("", "lockfile")
+		require.NoError(t, err)
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "deps.lock.yaml")
+		lockfile := importpkg.Lockfile{
+			APIVersion: importpkg.LockfileAPIVersion,
+			Kind:       importpkg.LockfileKind,
+			Images: []importpkg.LockedImage{
+				{Source: "some-registry.io/repo/lifecycle", Digest: "sha256:lifecycle"},
+			},
+		}
+		require.NoError(t, lockfile.Write(path))
+
+		read, err := importpkg.ReadLockfile(path)
+		require.NoError(t, err)
+		require.Equal(t, lockfile, read)
+
+		_, err = importpkg.ReadLockfile(filepath.Join(dir, "missing.lock.yaml"))
+		require.EqualError(t, err, "lockfile '"+filepath.Join(dir, "missing.lock.yaml")+"' does not exist")
+	})
+
+	it("checks that the lockfile pins exactly the images of the descriptor", func() {
+		lockfile := importpkg.Lockfile{Images: []importpkg.LockedImage{
+			{Source: "some-registry.io/repo/lifecycle", Digest: "sha256:lifecycle"},
+			{Source: "some-registry.io/repo/buildpack", Digest: "sha256:buildpack"},
+		}}
+		require.NoError(t, lockfile.Check(descriptor))
+
+		lockfile.Images = lockfile.Images[:1]
+		require.EqualError(t, lockfile.Check(descriptor), "lockfile is stale: 'some-registry.io/repo/buildpack' not locked")
+
+		lockfile.Images = append(lockfile.Images,
+			importpkg.LockedImage{Source: "some-registry.io/repo/buildpack", Digest: "sha256:buildpack"},
+			importpkg.LockedImage{Source: "some-registry.io/repo/removed", Digest: "sha256:removed"},
+		)
+		require.EqualError(t, lockfile.Check(descriptor), "lockfile is stale: 'some-registry.io/repo/removed' not in the descriptor")
+	})
+
+	it("records the fetched digests and relocated images", func() {
+		fetcher := &registryfakes.Fetcher{}
+		fetcher.AddImage("some-registry.io/repo/lifecycle", registryfakes.NewFakeImage("lifecycle"))
+		fetcher.AddImage("some-registry.io/repo/buildpack", registryfakes.NewFakeImage("buildpack"))
+
+		recorder := importpkg.NewLockRecorder(fetcher, &registryfakes.Relocator{})
+
+		image, err := recorder.Fetcher().Fetch(authn.DefaultKeychain, "some-registry.io/repo/lifecycle")
+		require.NoError(t, err)
+		_, err = recorder.Relocator().Relocate(authn.DefaultKeychain, image, "default-registry.io/repo/lifecycle")
+		require.NoError(t, err)
+
+		_, err = recorder.Lockfile(descriptor)
+		require.EqualError(t, err, "image 'some-registry.io/repo/buildpack' was not fetched")
+
+		_, err = recorder.Fetcher().Fetch(authn.DefaultKeychain, "some-registry.io/repo/buildpack")
+		require.NoError(t, err)
+
+		lockfile, err := recorder.Lockfile(descriptor)
+		require.NoError(t, err)
+		require.Equal(t, importpkg.Lockfile{
+			APIVersion: importpkg.LockfileAPIVersion,
+			Kind:       importpkg.LockfileKind,
+			Images: []importpkg.LockedImage{
+				{Source: "some-registry.io/repo/lifecycle", Digest: "sha256:lifecycle", Relocated: "default-registry.io/repo/lifecycle@sha256:lifecycle"},
+				{Source: "some-registry.io/repo/buildpack", Digest: "sha256:buildpack"},
+			},
+		}, lockfile)
+	})
+
+	it("fetches the locked digests", func() {
+		fetcher := &registryfakes.Fetcher{}
+		fetcher.AddImage("some-registry.io/repo/lifecycle@sha256:lifecycle", registryfakes.NewFakeImage("lifecycle"))
+		fetcher.AddImage("some-registry.io/repo/buildpack@sha256:buildpack", registryfakes.NewFakeImage("moved"))
+
+		lockedFetcher := importpkg.LockedFetcher{
+			Fetcher: fetcher,
+			Lockfile: importpkg.Lockfile{Images: []importpkg.LockedImage{
+				{Source: "some-registry.io/repo/lifecycle", Digest: "sha256:lifecycle"},
+				{Source: "some-registry.io/repo/buildpack", Digest: "sha256:buildpack"},
+			}},
+		}
+
+		image, err := lockedFetcher.Fetch(authn.DefaultKeychain, "some-registry.io/repo/lifecycle")
+		require.NoError(t, err)
+		digest, err := image.Digest()
+		require.NoError(t, err)
+		require.Equal(t, "sha256:lifecycle", digest.String())
+
+		_, err = lockedFetcher.Fetch(authn.DefaultKeychain, "some-registry.io/repo/buildpack")
+		require.EqualError(t, err, "lockfile is stale: 'some-registry.io/repo/buildpack' resolved to 'sha256:moved', locked to 'sha256:buildpack'")
+
+		_, err = lockedFetcher.Fetch(authn.DefaultKeychain, "some-registry.io/repo/unlocked")
+		require.EqualError(t, err, "lockfile is stale: 'some-registry.io/repo/unlocked' not locked")
+	})
+}
@@ -0,0 +1,242 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/config"
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+)
+
+const defaultServiceAccount = "default"
+
+func (i *Importer) constructBuilder(kpConfig config.KpConfig, builder Builder) (*v1alpha2.Builder, error) {
+	if err := i.printer.PrintStatus("Importing Builder '%s/%s'...", builder.Namespace, builder.Name); err != nil {
+		return nil, err
+	}
+	i.reporter.start(v1alpha2.BuilderKind, builder.Namespace, builder.Name)
+
+	tag, err := builderTag(kpConfig, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	newBuilder := &v1alpha2.Builder{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha2.BuilderKind,
+			APIVersion: "kpack.io/v1alpha2",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        builder.Name,
+			Namespace:   builder.Namespace,
+			Annotations: map[string]string{},
+		},
+		Spec: v1alpha2.NamespacedBuilderSpec{
+			BuilderSpec: v1alpha2.BuilderSpec{
+				Tag: tag,
+				Stack: corev1.ObjectReference{
+					Name: builder.ClusterStack,
+					Kind: v1alpha2.ClusterStackKind,
+				},
+				Store: corev1.ObjectReference{
+					Name: builder.ClusterStore,
+					Kind: v1alpha2.ClusterStoreKind,
+				},
+				Order: builder.Order,
+			},
+			ServiceAccountName: defaultServiceAccount,
+		},
+	}
+
+	if err := k8s.SetLastAppliedCfg(newBuilder); err != nil {
+		return nil, err
+	}
+
+	return newBuilder, nil
+}
+
+// builderTag returns the tag of builder, which defaults to one in the default
+// repository.
+func builderTag(kpConfig config.KpConfig, builder Builder) (string, error) {
+	if builder.Tag != "" {
+		return builder.Tag, nil
+	}
+
+	defaultRepo, err := kpConfig.DefaultRepository()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get default repository")
+	}
+	return fmt.Sprintf("%s:builder-%s-%s", defaultRepo, builder.Namespace, builder.Name), nil
+}
+
+func (i *Importer) constructImage(image Image) (*v1alpha2.Image, error) {
+	if err := i.printer.PrintStatus("Importing Image '%s/%s'...", image.Namespace, image.Name); err != nil {
+		return nil, err
+	}
+	i.reporter.start(v1alpha2.ImageKind, image.Namespace, image.Name)
+
+	newImage := &v1alpha2.Image{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       v1alpha2.ImageKind,
+			APIVersion: "kpack.io/v1alpha2",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        image.Name,
+			Namespace:   image.Namespace,
+			Annotations: map[string]string{},
+		},
+		Spec: v1alpha2.ImageSpec{
+			Tag:                image.Tag,
+			Builder:            imageBuilderRef(image),
+			ServiceAccountName: defaultServiceAccount,
+			Source:             image.Source,
+			Build: &v1alpha2.ImageBuild{
+				Env: image.Env,
+			},
+		},
+	}
+
+	if err := k8s.SetLastAppliedCfg(newImage); err != nil {
+		return nil, err
+	}
+
+	return newImage, nil
+}
+
+func imageBuilderRef(image Image) corev1.ObjectReference {
+	if image.Builder != "" {
+		return corev1.ObjectReference{
+			Kind:      v1alpha2.BuilderKind,
+			Namespace: image.Namespace,
+			Name:      image.Builder,
+		}
+	}
+
+	return corev1.ObjectReference{
+		Kind: v1alpha2.ClusterBuilderKind,
+		Name: image.ClusterBuilder,
+	}
+}
+
+func (i *Importer) saveBuilder(ctx context.Context, r *rollback, storeToGeneration, stackToGeneration map[string]int64, relocatedBuilder *v1alpha2.Builder) error {
+	existingBuilder, err := i.client.KpackV1alpha2().Builders(relocatedBuilder.Namespace).Get(ctx, relocatedBuilder.Name, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	var (
+		builder *v1alpha2.Builder
+		action  = ActionCreated
+	)
+	if k8serrors.IsNotFound(err) {
+		builder, err = i.client.KpackV1alpha2().Builders(relocatedBuilder.Namespace).Create(ctx, relocatedBuilder, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		i.recordBuilder(r, relocatedBuilder.Namespace, relocatedBuilder.Name, nil)
+	} else {
+		updateBuilder := existingBuilder.DeepCopy()
+		updateBuilder.Spec.BuilderSpec = relocatedBuilder.Spec.BuilderSpec
+		action = updateAction(equality.Semantic.DeepEqual(existingBuilder.Spec, updateBuilder.Spec))
+		updateBuilder.Annotations = k8s.MergeAnnotations(updateBuilder.Annotations, relocatedBuilder.Annotations)
+		patch, err := k8s.CreatePatch(existingBuilder, updateBuilder)
+		if err != nil {
+			return err
+		}
+		builder, err = i.client.KpackV1alpha2().Builders(updateBuilder.Namespace).Patch(ctx, updateBuilder.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		if err != nil {
+			return err
+		}
+		i.recordBuilder(r, existingBuilder.Namespace, existingBuilder.Name, existingBuilder)
+	}
+
+	start := time.Now()
+	if err := i.waiter.Wait(ctx, builder, builderHasResolved(storeToGeneration[relocatedBuilder.Spec.Store.Name], stackToGeneration[relocatedBuilder.Spec.Stack.Name])); err != nil {
+		return err
+	}
+	i.reporter.saved(v1alpha2.BuilderKind, relocatedBuilder.Namespace, relocatedBuilder.Name, action, time.Since(start))
+	return nil
+}
+
+// saveImage creates or updates an Image without waiting for it to build.
+// Only the fields the descriptor sets are updated on an existing Image.
+func (i *Importer) saveImage(ctx context.Context, r *rollback, relocatedImage *v1alpha2.Image) error {
+	existingImage, err := i.client.KpackV1alpha2().Images(relocatedImage.Namespace).Get(ctx, relocatedImage.Name, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return err
+	}
+
+	if k8serrors.IsNotFound(err) {
+		_, err = i.client.KpackV1alpha2().Images(relocatedImage.Namespace).Create(ctx, relocatedImage, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		i.recordImage(r, relocatedImage.Namespace, relocatedImage.Name, nil)
+		i.reporter.saved(v1alpha2.ImageKind, relocatedImage.Namespace, relocatedImage.Name, ActionCreated, 0)
+		return nil
+	}
+
+	updateImage := existingImage.DeepCopy()
+	updateImage.Spec.Tag = relocatedImage.Spec.Tag
+	updateImage.Spec.Builder = relocatedImage.Spec.Builder
+	updateImage.Spec.Source = relocatedImage.Spec.Source
+	if updateImage.Spec.Build == nil {
+		updateImage.Spec.Build = &v1alpha2.ImageBuild{}
+	}
+	updateImage.Spec.Build.Env = relocatedImage.Spec.Build.Env
+	updateImage.Annotations = k8s.MergeAnnotations(updateImage.Annotations, relocatedImage.Annotations)
+	patch, err := k8s.CreatePatch(existingImage, updateImage)
+	if err != nil {
+		return err
+	}
+	_, err = i.client.KpackV1alpha2().Images(updateImage.Namespace).Patch(ctx, updateImage.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return err
+	}
+	i.recordImage(r, existingImage.Namespace, existingImage.Name, existingImage)
+	i.reporter.saved(v1alpha2.ImageKind, existingImage.Namespace, existingImage.Name, updateAction(equality.Semantic.DeepEqual(existingImage.Spec, updateImage.Spec)), 0)
+	return nil
+}
+
+func toDescriptorBuilder(builder v1alpha2.Builder) Builder {
+	return Builder{
+		Name:         builder.Name,
+		Namespace:    builder.Namespace,
+		Tag:          builder.Spec.Tag,
+		ClusterStack: builder.Spec.Stack.Name,
+		ClusterStore: builder.Spec.Store.Name,
+		Order:        builder.Spec.Order,
+	}
+}
+
+func toDescriptorImage(image v1alpha2.Image) Image {
+	img := Image{
+		Name:      image.Name,
+		Namespace: image.Namespace,
+		Tag:       image.Spec.Tag,
+		Source:    image.Spec.Source,
+	}
+
+	if image.Spec.Builder.Kind == v1alpha2.BuilderKind {
+		img.Builder = image.Spec.Builder.Name
+	} else {
+		img.ClusterBuilder = image.Spec.Builder.Name
+	}
+
+	if image.Spec.Build != nil {
+		img.Env = image.Spec.Build.Env
+	}
+	return img
+}
@@ -0,0 +1,211 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Prunable holds the resources previously created by kp import that are no
+// longer listed in the dependency descriptor.
+type Prunable struct {
+	ClusterStores   []v1alpha2.ClusterStore
+	ClusterStacks   []v1alpha2.ClusterStack
+	ClusterBuilders []v1alpha2.ClusterBuilder
+}
+
+func (p Prunable) IsEmpty() bool {
+	return len(p.ClusterStores) == 0 && len(p.ClusterStacks) == 0 && len(p.ClusterBuilders) == 0
+}
+
+// FindPrunable returns the imported resources missing from the descriptor.
+// Unless force is set, it fails when any of them is still referenced by an
+// Image, a Builder, or a ClusterBuilder that will remain after the import.
+func (i *Importer) FindPrunable(ctx context.Context, descriptor DependencyDescriptor, force bool) (Prunable, error) {
+	var prunable Prunable
+
+	stores, err := i.client.KpackV1alpha2().ClusterStores().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return Prunable{}, err
+	}
+	storeNames := map[string]bool{}
+	for _, s := range descriptor.ClusterStores {
+		storeNames[s.Name] = true
+	}
+	for _, s := range stores.Items {
+		if isImported(s.ObjectMeta) && !storeNames[s.Name] {
+			prunable.ClusterStores = append(prunable.ClusterStores, s)
+		}
+	}
+
+	stacks, err := i.client.KpackV1alpha2().ClusterStacks().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return Prunable{}, err
+	}
+	stackNames := map[string]bool{}
+	for _, s := range descriptor.GetClusterStacks() {
+		stackNames[s.Name] = true
+	}
+	for _, s := range stacks.Items {
+		if isImported(s.ObjectMeta) && !stackNames[s.Name] {
+			prunable.ClusterStacks = append(prunable.ClusterStacks, s)
+		}
+	}
+
+	builders, err := i.client.KpackV1alpha2().ClusterBuilders().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return Prunable{}, err
+	}
+	desiredBuilders := map[string]ClusterBuilder{}
+	for _, b := range descriptor.GetClusterBuilders() {
+		desiredBuilders[b.Name] = b
+	}
+	var remainingBuilders []ClusterBuilder
+	for _, b := range builders.Items {
+		if desired, ok := desiredBuilders[b.Name]; ok {
+			remainingBuilders = append(remainingBuilders, desired)
+			delete(desiredBuilders, b.Name)
+		} else if isImported(b.ObjectMeta) {
+			prunable.ClusterBuilders = append(prunable.ClusterBuilders, b)
+		} else {
+			remainingBuilders = append(remainingBuilders, toDescriptorClusterBuilder(b))
+		}
+	}
+	for _, b := range desiredBuilders {
+		remainingBuilders = append(remainingBuilders, b)
+	}
+
+	if force || prunable.IsEmpty() {
+		return prunable, nil
+	}
+
+	references, err := i.prunableReferences(ctx, prunable, remainingBuilders)
+	if err != nil {
+		return Prunable{}, err
+	}
+
+	if len(references) > 0 {
+		return Prunable{}, errors.Errorf("cannot prune resources that are still in use, use --force-prune to prune them anyway:\n\t%s", strings.Join(references, "\n\t"))
+	}
+
+	return prunable, nil
+}
+
+// Prune deletes the prunable resources, ClusterBuilders first so nothing is
+// left pointing at a deleted ClusterStore or ClusterStack.
+func (i *Importer) Prune(ctx context.Context, prunable Prunable) error {
+	return i.prune(ctx, prunable, false)
+}
+
+func (i *Importer) PruneDryRun(ctx context.Context, prunable Prunable) error {
+	return i.prune(ctx, prunable, true)
+}
+
+func (i *Importer) prune(ctx context.Context, prunable Prunable, dryRun bool) error {
+	for _, b := range prunable.ClusterBuilders {
+		if err := i.printer.PrintStatus("Pruning ClusterBuilder '%s'...", b.Name); err != nil {
+			return err
+		}
+		if dryRun {
+			continue
+		}
+		err := i.client.KpackV1alpha2().ClusterBuilders().Delete(ctx, b.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	for _, s := range prunable.ClusterStacks {
+		if err := i.printer.PrintStatus("Pruning ClusterStack '%s'...", s.Name); err != nil {
+			return err
+		}
+		if dryRun {
+			continue
+		}
+		err := i.client.KpackV1alpha2().ClusterStacks().Delete(ctx, s.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	for _, s := range prunable.ClusterStores {
+		if err := i.printer.PrintStatus("Pruning ClusterStore '%s'...", s.Name); err != nil {
+			return err
+		}
+		if dryRun {
+			continue
+		}
+		err := i.client.KpackV1alpha2().ClusterStores().Delete(ctx, s.Name, metav1.DeleteOptions{})
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (i *Importer) prunableReferences(ctx context.Context, prunable Prunable, remainingBuilders []ClusterBuilder) ([]string, error) {
+	var references []string
+
+	prunedStores := map[string]bool{}
+	for _, s := range prunable.ClusterStores {
+		prunedStores[s.Name] = true
+	}
+	prunedStacks := map[string]bool{}
+	for _, s := range prunable.ClusterStacks {
+		prunedStacks[s.Name] = true
+	}
+	prunedBuilders := map[string]bool{}
+	for _, b := range prunable.ClusterBuilders {
+		prunedBuilders[b.Name] = true
+	}
+
+	for _, b := range remainingBuilders {
+		if prunedStores[b.ClusterStore] {
+			references = append(references, fmt.Sprintf("ClusterStore '%s' is used by ClusterBuilder '%s'", b.ClusterStore, b.Name))
+		}
+		if prunedStacks[b.ClusterStack] {
+			references = append(references, fmt.Sprintf("ClusterStack '%s' is used by ClusterBuilder '%s'", b.ClusterStack, b.Name))
+		}
+	}
+
+	builders, err := i.client.KpackV1alpha2().Builders("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range builders.Items {
+		if b.Spec.Store.Kind == v1alpha2.ClusterStoreKind && prunedStores[b.Spec.Store.Name] {
+			references = append(references, fmt.Sprintf("ClusterStore '%s' is used by Builder '%s/%s'", b.Spec.Store.Name, b.Namespace, b.Name))
+		}
+		if b.Spec.Stack.Kind == v1alpha2.ClusterStackKind && prunedStacks[b.Spec.Stack.Name] {
+			references = append(references, fmt.Sprintf("ClusterStack '%s' is used by Builder '%s/%s'", b.Spec.Stack.Name, b.Namespace, b.Name))
+		}
+	}
+
+	images, err := i.client.KpackV1alpha2().Images("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, img := range images.Items {
+		if img.Spec.Builder.Kind == v1alpha2.ClusterBuilderKind && prunedBuilders[img.Spec.Builder.Name] {
+			references = append(references, fmt.Sprintf("ClusterBuilder '%s' is used by Image '%s/%s'", img.Spec.Builder.Name, img.Namespace, img.Name))
+		}
+	}
+
+	sort.Strings(references)
+	return references, nil
+}
+
+func isImported(meta metav1.ObjectMeta) bool {
+	_, ok := meta.Annotations[importTimestampKey]
+	return ok
+}
@@ -0,0 +1,165 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	kpackfakes "github.com/pivotal/kpack/pkg/client/clientset/versioned/fake"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfakes "k8s.io/client-go/kubernetes/fake"
+
+	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+)
+
+func TestPruner(t *testing.T) {
+	spec.Run(t, "TestPruner", testPruner)
+}
+
+func testPruner(t *testing.T, when spec.G, it spec.S) {
+	importedMeta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{"kpack.io/import-timestamp": "some-timestamp"},
+		}
+	}
+
+	var (
+		keptStore      = &v1alpha2.ClusterStore{ObjectMeta: importedMeta("kept-store")}
+		prunedStore    = &v1alpha2.ClusterStore{ObjectMeta: importedMeta("pruned-store")}
+		unmanagedStore = &v1alpha2.ClusterStore{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged-store"}}
+		prunedStack    = &v1alpha2.ClusterStack{ObjectMeta: importedMeta("pruned-stack")}
+		prunedBuilder  = &v1alpha2.ClusterBuilder{
+			ObjectMeta: importedMeta("pruned-builder"),
+			Spec: v1alpha2.ClusterBuilderSpec{
+				BuilderSpec: v1alpha2.BuilderSpec{
+					Stack: corev1.ObjectReference{Kind: v1alpha2.ClusterStackKind, Name: "pruned-stack"},
+					Store: corev1.ObjectReference{Kind: v1alpha2.ClusterStoreKind, Name: "pruned-store"},
+				},
+			},
+		}
+
+		descriptor = importpkg.DependencyDescriptor{
+			ClusterStores: []importpkg.ClusterStore{{Name: "kept-store"}},
+		}
+
+		kpackClient *kpackfakes.Clientset
+		out         *bytes.Buffer
+		importer    *importpkg.Importer
+	)
+
+	setup := func(objects ...runtime.Object) {
+		kpackClient = kpackfakes.NewSimpleClientset(objects...)
+		out = &bytes.Buffer{}
+		importer = importpkg.NewImporter(fakePrinter{out: out}, k8sfakes.NewSimpleClientset(), kpackClient, &fakes.Fetcher{}, &fakes.Relocator{}, nil, nil)
+	}
+
+	it("finds imported resources that are not in the descriptor", func() {
+		setup(keptStore, prunedStore, unmanagedStore, prunedStack, prunedBuilder)
+
+		prunable, err := importer.FindPrunable(context.Background(), descriptor, false)
+		require.NoError(t, err)
+
+		require.Len(t, prunable.ClusterStores, 1)
+		require.Equal(t, "pruned-store", prunable.ClusterStores[0].Name)
+		require.Len(t, prunable.ClusterStacks, 1)
+		require.Equal(t, "pruned-stack", prunable.ClusterStacks[0].Name)
+		require.Len(t, prunable.ClusterBuilders, 1)
+		require.Equal(t, "pruned-builder", prunable.ClusterBuilders[0].Name)
+	})
+
+	it("refuses to prune resources that are still in use", func() {
+		image := &v1alpha2.Image{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-image", Namespace: "some-namespace"},
+			Spec: v1alpha2.ImageSpec{
+				Builder: corev1.ObjectReference{Kind: v1alpha2.ClusterBuilderKind, Name: "pruned-builder"},
+			},
+		}
+		builder := &v1alpha2.Builder{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-builder", Namespace: "some-namespace"},
+			Spec: v1alpha2.NamespacedBuilderSpec{
+				BuilderSpec: v1alpha2.BuilderSpec{
+					Stack: corev1.ObjectReference{Kind: v1alpha2.ClusterStackKind, Name: "pruned-stack"},
+				},
+			},
+		}
+		setup(prunedStore, prunedStack, prunedBuilder, image, builder)
+
+		_, err := importer.FindPrunable(context.Background(), descriptor, false)
+		require.EqualError(t, err, `cannot prune resources that are still in use, use --force-prune to prune them anyway:
+	ClusterBuilder 'pruned-builder' is used by Image 'some-namespace/some-image'
+	ClusterStack 'pruned-stack' is used by Builder 'some-namespace/some-builder'`)
+
+		prunable, err := importer.FindPrunable(context.Background(), descriptor, true)
+		require.NoError(t, err)
+		require.False(t, prunable.IsEmpty())
+	})
+
+	it("refuses to prune stores used by clusterbuilders in the descriptor", func() {
+		setup(prunedStore)
+
+		_, err := importer.FindPrunable(context.Background(), importpkg.DependencyDescriptor{
+			ClusterBuilders: []importpkg.ClusterBuilder{{Name: "new-builder", ClusterStore: "pruned-store"}},
+		}, false)
+		require.EqualError(t, err, `cannot prune resources that are still in use, use --force-prune to prune them anyway:
+	ClusterStore 'pruned-store' is used by ClusterBuilder 'new-builder'`)
+	})
+
+	it("deletes clusterbuilders before stacks and stores", func() {
+		setup(prunedStore, prunedStack, prunedBuilder)
+
+		prunable, err := importer.FindPrunable(context.Background(), descriptor, false)
+		require.NoError(t, err)
+
+		require.NoError(t, importer.Prune(context.Background(), prunable))
+		require.Equal(t, `Pruning ClusterBuilder 'pruned-builder'...
+Pruning ClusterStack 'pruned-stack'...
+Pruning ClusterStore 'pruned-store'...
+`, out.String())
+
+		var deleted []string
+		for _, action := range kpackClient.Actions() {
+			if action.GetVerb() == "delete" {
+				deleted = append(deleted, action.GetResource().Resource)
+			}
+		}
+		require.Equal(t, []string{"clusterbuilders", "clusterstacks", "clusterstores"}, deleted)
+	})
+
+	it("does not delete anything on a dry run", func() {
+		setup(prunedStore)
+
+		prunable, err := importer.FindPrunable(context.Background(), descriptor, false)
+		require.NoError(t, err)
+
+		require.NoError(t, importer.PruneDryRun(context.Background(), prunable))
+		require.Equal(t, "Pruning ClusterStore 'pruned-store'...\n", out.String())
+
+		_, err = kpackClient.KpackV1alpha2().ClusterStores().Get(context.Background(), "pruned-store", metav1.GetOptions{})
+		require.NoError(t, err)
+	})
+}
+
+type fakePrinter struct {
+	out *bytes.Buffer
+}
+
+func (f fakePrinter) Printlnf(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(f.out, format+"\n", args...)
+	return err
+}
+
+func (f fakePrinter) PrintStatus(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(f.out, format+"\n", args...)
+	return err
+}
@@ -5,7 +5,9 @@ import (
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 
+	"github.com/vmware-tanzu/kpack-cli/pkg/buildpackage"
 	"github.com/vmware-tanzu/kpack-cli/pkg/config"
 	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 )
@@ -18,18 +20,18 @@ func NewDefaultRelocatedImageProvider(fetcher registry.Fetcher) *DefaultRelocate
 	return &DefaultRelocatedImageProvider{fetcher: fetcher}
 }
 
-func (r *DefaultRelocatedImageProvider) RelocatedImage(keychain authn.Keychain, kpConfig config.KpConfig, srcImage string) (string, error) {
-	relocationRepo, err := kpConfig.DefaultRepository()
+func (r *DefaultRelocatedImageProvider) RelocatedImage(keychain authn.Keychain, kpConfig config.KpConfig, kind config.ImageKind, values config.RepositoryValues, srcImage string) (string, error) {
+	img, err := r.fetcher.Fetch(keychain, srcImage)
 	if err != nil {
 		return "", err
 	}
 
-	repository, err := name.NewRepository(relocationRepo)
+	relocationRepo, err := relocationRepository(img, kpConfig, kind, values)
 	if err != nil {
 		return "", err
 	}
 
-	img, err := r.fetcher.Fetch(keychain, srcImage)
+	repository, err := name.NewRepository(relocationRepo)
 	if err != nil {
 		return "", err
 	}
@@ -41,3 +43,12 @@ func (r *DefaultRelocatedImageProvider) RelocatedImage(keychain authn.Keychain,
 
 	return fmt.Sprintf("%s@%s", repository, digest), nil
 }
+
+// relocationRepository returns the repository kpConfig assigns to an image of
+// the given kind, reading the buildpack id from buildpackage images.
+func relocationRepository(image v1.Image, kpConfig config.KpConfig, kind config.ImageKind, values config.RepositoryValues) (string, error) {
+	if kind == config.BuildpackageImage {
+		return buildpackage.Repository(image, kpConfig)
+	}
+	return kpConfig.ImageRepository(kind, values)
+}
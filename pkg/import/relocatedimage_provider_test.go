@@ -31,10 +31,28 @@ func testRelocatedImageProvider(t *testing.T, when spec.G, it spec.S) {
 
 			srcImage := "some-registry.com/some-repo/image@sha256:some-digest"
 
-			image, err := relocatedImageProvider.RelocatedImage(keychain, kpConfig, srcImage)
+			image, err := relocatedImageProvider.RelocatedImage(keychain, kpConfig, config.BuildImage, config.RepositoryValues{Name: "some-stack"}, srcImage)
 			require.NoError(t, err)
 
 			assert.Equal(t, "my-registy.com/my-repo@sha256:some-digest", image)
 		})
+
+		it("uses the repository template for the image kind", func() {
+			fetcher := &fakeFetcher{Images: map[string]v1.Image{
+				"some-registry.com/some-repo/image@sha256:some-digest": fakes.NewFakeImage("some-digest"),
+			}}
+
+			relocatedImageProvider := NewDefaultRelocatedImageProvider(fetcher)
+			keychain := &registryfakes.FakeKeychain{Name: "someKeychain"}
+			kpConfig := config.NewKpConfig("my-registy.com/my-repo", corev1.ObjectReference{Name: "service account"}).
+				WithRepositoryTemplate(config.RunImage, "{{.Repo}}/stacks/{{.Name}}/run")
+
+			srcImage := "some-registry.com/some-repo/image@sha256:some-digest"
+
+			image, err := relocatedImageProvider.RelocatedImage(keychain, kpConfig, config.RunImage, config.RepositoryValues{Name: "some-stack"}, srcImage)
+			require.NoError(t, err)
+
+			assert.Equal(t, "my-registy.com/my-repo/stacks/some-stack/run@sha256:some-digest", image)
+		})
 	})
 }
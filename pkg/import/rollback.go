@@ -0,0 +1,229 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package _import
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pivotal/kpack/pkg/apis/build/v1alpha2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
+)
+
+// rollback records how to undo every change made to the cluster during an
+// import so that a failed import leaves the cluster as it was found.
+type rollback struct {
+	steps []rollbackStep
+}
+
+type rollbackStep struct {
+	description string
+	undo        func(ctx context.Context) error
+}
+
+func (r *rollback) add(description string, undo func(ctx context.Context) error) {
+	r.steps = append(r.steps, rollbackStep{description: description, undo: undo})
+}
+
+// rollBack undoes the recorded changes in reverse order and returns importErr
+// annotated with the outcome. A fresh context is used so that an import that
+// failed because its context was cancelled can still be rolled back.
+func (i *Importer) rollBack(r *rollback, importErr error) error {
+	if r == nil || len(r.steps) == 0 {
+		return importErr
+	}
+
+	if err := i.printer.PrintStatus("Import failed, rolling back..."); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	var failed []string
+	for j := len(r.steps) - 1; j >= 0; j-- {
+		step := r.steps[j]
+		if err := step.undo(ctx); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", step.description, err))
+			continue
+		}
+
+		if err := i.printer.Printlnf("\tRolled back: %s", step.description); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("%s\nfailed to roll back:\n\t%s", importErr, strings.Join(failed, "\n\t"))
+	}
+
+	return errors.Wrap(importErr, "import rolled back")
+}
+
+func (i *Importer) recordLifecycleConfigMap(r *rollback, existing *corev1.ConfigMap) {
+	r.add("restored ConfigMap 'kpack/lifecycle-image'", func(ctx context.Context) error {
+		current, err := i.k8sClient.CoreV1().ConfigMaps(existing.Namespace).Get(ctx, existing.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		restored := current.DeepCopy()
+		restored.Data = existing.Data
+		restored.Annotations = existing.Annotations
+		patch, err := k8s.CreatePatch(current, restored)
+		if err != nil || patch == nil {
+			return err
+		}
+
+		_, err = i.k8sClient.CoreV1().ConfigMaps(existing.Namespace).Patch(ctx, existing.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+func (i *Importer) recordClusterStore(r *rollback, name string, existing *v1alpha2.ClusterStore) {
+	if existing == nil {
+		r.add(fmt.Sprintf("deleted ClusterStore '%s'", name), func(ctx context.Context) error {
+			return ignoreNotFound(i.client.KpackV1alpha2().ClusterStores().Delete(ctx, name, metav1.DeleteOptions{}))
+		})
+		return
+	}
+
+	r.add(fmt.Sprintf("restored ClusterStore '%s'", name), func(ctx context.Context) error {
+		current, err := i.client.KpackV1alpha2().ClusterStores().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		restored := current.DeepCopy()
+		restored.Spec = existing.Spec
+		restored.Annotations = existing.Annotations
+		patch, err := k8s.CreatePatch(current, restored)
+		if err != nil || patch == nil {
+			return err
+		}
+
+		_, err = i.client.KpackV1alpha2().ClusterStores().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+func (i *Importer) recordClusterStack(r *rollback, name string, existing *v1alpha2.ClusterStack) {
+	if existing == nil {
+		r.add(fmt.Sprintf("deleted ClusterStack '%s'", name), func(ctx context.Context) error {
+			return ignoreNotFound(i.client.KpackV1alpha2().ClusterStacks().Delete(ctx, name, metav1.DeleteOptions{}))
+		})
+		return
+	}
+
+	r.add(fmt.Sprintf("restored ClusterStack '%s'", name), func(ctx context.Context) error {
+		current, err := i.client.KpackV1alpha2().ClusterStacks().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		restored := current.DeepCopy()
+		restored.Spec = existing.Spec
+		restored.Annotations = existing.Annotations
+		patch, err := k8s.CreatePatch(current, restored)
+		if err != nil || patch == nil {
+			return err
+		}
+
+		_, err = i.client.KpackV1alpha2().ClusterStacks().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+func (i *Importer) recordClusterBuilder(r *rollback, name string, existing *v1alpha2.ClusterBuilder) {
+	if existing == nil {
+		r.add(fmt.Sprintf("deleted ClusterBuilder '%s'", name), func(ctx context.Context) error {
+			return ignoreNotFound(i.client.KpackV1alpha2().ClusterBuilders().Delete(ctx, name, metav1.DeleteOptions{}))
+		})
+		return
+	}
+
+	r.add(fmt.Sprintf("restored ClusterBuilder '%s'", name), func(ctx context.Context) error {
+		current, err := i.client.KpackV1alpha2().ClusterBuilders().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		restored := current.DeepCopy()
+		restored.Spec = existing.Spec
+		restored.Annotations = existing.Annotations
+		patch, err := k8s.CreatePatch(current, restored)
+		if err != nil || patch == nil {
+			return err
+		}
+
+		_, err = i.client.KpackV1alpha2().ClusterBuilders().Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+func (i *Importer) recordBuilder(r *rollback, namespace, name string, existing *v1alpha2.Builder) {
+	if existing == nil {
+		r.add(fmt.Sprintf("deleted Builder '%s/%s'", namespace, name), func(ctx context.Context) error {
+			return ignoreNotFound(i.client.KpackV1alpha2().Builders(namespace).Delete(ctx, name, metav1.DeleteOptions{}))
+		})
+		return
+	}
+
+	r.add(fmt.Sprintf("restored Builder '%s/%s'", namespace, name), func(ctx context.Context) error {
+		current, err := i.client.KpackV1alpha2().Builders(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		restored := current.DeepCopy()
+		restored.Spec = existing.Spec
+		restored.Annotations = existing.Annotations
+		patch, err := k8s.CreatePatch(current, restored)
+		if err != nil || patch == nil {
+			return err
+		}
+
+		_, err = i.client.KpackV1alpha2().Builders(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+func (i *Importer) recordImage(r *rollback, namespace, name string, existing *v1alpha2.Image) {
+	if existing == nil {
+		r.add(fmt.Sprintf("deleted Image '%s/%s'", namespace, name), func(ctx context.Context) error {
+			return ignoreNotFound(i.client.KpackV1alpha2().Images(namespace).Delete(ctx, name, metav1.DeleteOptions{}))
+		})
+		return
+	}
+
+	r.add(fmt.Sprintf("restored Image '%s/%s'", namespace, name), func(ctx context.Context) error {
+		current, err := i.client.KpackV1alpha2().Images(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		restored := current.DeepCopy()
+		restored.Spec = existing.Spec
+		restored.Annotations = existing.Annotations
+		patch, err := k8s.CreatePatch(current, restored)
+		if err != nil || patch == nil {
+			return err
+		}
+
+		_, err = i.client.KpackV1alpha2().Images(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+		return err
+	})
+}
+
+func ignoreNotFound(err error) error {
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,55 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package k8s
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// UpstreamImagesAnnotation records the reference each relocated image was
+// copied from, keyed by the relocated image.
+const UpstreamImagesAnnotation = "kpack.io/upstream-images"
+
+// GetUpstreamImages returns the upstream references recorded on obj, keyed by
+// the relocated image they were copied to.
+func GetUpstreamImages(obj Annotatable) (map[string]string, error) {
+	images := map[string]string{}
+
+	value, ok := obj.GetAnnotations()[UpstreamImagesAnnotation]
+	if !ok {
+		return images, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &images); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s annotation", UpstreamImagesAnnotation)
+	}
+	return images, nil
+}
+
+// SetUpstreamImages records images on obj, removing the annotation when there
+// are none.
+func SetUpstreamImages(obj Annotatable, images map[string]string) error {
+	a := obj.GetAnnotations()
+	if len(images) == 0 {
+		if _, ok := a[UpstreamImagesAnnotation]; ok {
+			delete(a, UpstreamImagesAnnotation)
+			obj.SetAnnotations(a)
+		}
+		return nil
+	}
+
+	value, err := json.Marshal(images)
+	if err != nil {
+		return err
+	}
+
+	if a == nil {
+		a = map[string]string{}
+	}
+	a[UpstreamImagesAnnotation] = string(value)
+	obj.SetAnnotations(a)
+	return nil
+}
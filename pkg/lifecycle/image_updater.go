@@ -82,11 +82,18 @@ func validateImage(img ggcrv1.Image) error {
 func relocateImageToDefaultRepo(ctx context.Context, keychain authn.Keychain, img ggcrv1.Image, cfg ImageUpdaterConfig) (string, error) {
 	kpConfig := config.NewKpConfigProvider(cfg.ClientSet.K8sClient).GetKpConfig(ctx)
 
-	defaultRepo, err := kpConfig.DefaultRepository()
+	if !kpConfig.HasRepositoryTemplate(config.LifecycleImage) {
+		defaultRepo, err := kpConfig.DefaultRepository()
+		if err != nil {
+			return "", err
+		}
+		return cfg.ImgRelocator.Relocate(keychain, img, path.Join(defaultRepo, lifecycleImageName))
+	}
+
+	dstImgLocation, err := kpConfig.ImageRepository(config.LifecycleImage, config.RepositoryValues{})
 	if err != nil {
 		return "", err
 	}
 
-	dstImgLocation := path.Join(defaultRepo, lifecycleImageName)
 	return cfg.ImgRelocator.Relocate(keychain, img, dstImgLocation)
 }
@@ -0,0 +1,190 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/archive"
+)
+
+const (
+	bundleDescriptorFile = "descriptor.yaml"
+	bundleRefAnnotation  = "org.opencontainers.image.ref.name"
+)
+
+type BundleImage struct {
+	Ref   string
+	Image v1.Image
+}
+
+// WriteBundle writes the images and descriptor to a single tarball at path.
+// The tarball is an OCI image layout where every image is annotated with the
+// reference it was fetched from, so it can later be served by a BundleFetcher.
+func WriteBundle(path string, descriptor []byte, images []BundleImage) error {
+	layoutDir, err := ioutil.TempDir("", "kp-bundle")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(layoutDir)
+
+	layoutPath, err := layout.Write(layoutDir, empty.Index)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range images {
+		annotations := layout.WithAnnotations(map[string]string{bundleRefAnnotation: i.Ref})
+
+		var err error
+		if index, ok := ImageIndex(i.Image); ok {
+			err = layoutPath.AppendIndex(index, annotations)
+		} else {
+			err = layoutPath.AppendImage(i.Image, annotations)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "writing image '%s' to bundle", i.Ref)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(layoutDir, bundleDescriptorFile), descriptor, 0644); err != nil {
+		return err
+	}
+
+	tarPath, err := archive.CreateTar(layoutDir)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tarPath)
+
+	return copyFile(tarPath, path)
+}
+
+type BundleFetcher struct {
+	dir        string
+	descriptor []byte
+	images     map[string]v1.Image
+}
+
+// OpenBundle extracts a bundle written by WriteBundle. The caller must Close
+// the returned BundleFetcher to remove the extracted contents.
+func OpenBundle(path string) (*BundleFetcher, error) {
+	dir, err := ioutil.TempDir("", "kp-bundle")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &BundleFetcher{dir: dir, images: map[string]v1.Image{}}
+	if err := b.read(path); err != nil {
+		b.Close()
+		return nil, errors.Wrapf(err, "invalid bundle %s", path)
+	}
+	return b, nil
+}
+
+func (b *BundleFetcher) Fetch(_ authn.Keychain, src string) (v1.Image, error) {
+	image, ok := b.images[src]
+	if !ok {
+		return nil, errors.Errorf("image '%s' not found in bundle", src)
+	}
+	return image, nil
+}
+
+func (b *BundleFetcher) Descriptor() []byte {
+	return b.descriptor
+}
+
+func (b *BundleFetcher) Close() error {
+	return os.RemoveAll(b.dir)
+}
+
+func (b *BundleFetcher) read(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// bundles are uncompressed and routinely larger than any fixed limit, so
+	// the extracted contents are only bounded by the size of the bundle itself
+	limits := archive.ExtractLimits{
+		MaxBytes:   fi.Size(),
+		MaxEntries: archive.DefaultExtractLimits.MaxEntries,
+	}
+	if err := archive.ReadTarWithLimits(file, b.dir, limits); err != nil {
+		return err
+	}
+
+	b.descriptor, err = ioutil.ReadFile(filepath.Join(b.dir, bundleDescriptorFile))
+	if err != nil {
+		return err
+	}
+
+	index, err := layout.ImageIndexFromPath(b.dir)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range manifest.Manifests {
+		ref, ok := m.Annotations[bundleRefAnnotation]
+		if !ok {
+			continue
+		}
+
+		image, err := bundleImage(index, m)
+		if err != nil {
+			return err
+		}
+		b.images[ref] = image
+	}
+	return nil
+}
+
+func bundleImage(index v1.ImageIndex, desc v1.Descriptor) (v1.Image, error) {
+	if !desc.MediaType.IsIndex() {
+		return index.Image(desc.Digest)
+	}
+
+	child, err := index.ImageIndex(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return NewIndexImage(child)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
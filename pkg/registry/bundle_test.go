@@ -0,0 +1,134 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/pivotal/kpack/pkg/registry/registryfakes"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/archive"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func TestBundle(t *testing.T) {
+	spec.Run(t, "Test Bundle", testBundle)
+}
+
+func testBundle(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tempDir      string
+		fakeKeychain = &registryfakes.FakeKeychain{}
+	)
+
+	it.Before(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "bundle-test")
+		require.NoError(t, err)
+	})
+
+	it.After(func() {
+		require.NoError(t, os.RemoveAll(tempDir))
+	})
+
+	it("round trips images and the descriptor through a bundle", func() {
+		firstImage, err := random.Image(100, 2)
+		require.NoError(t, err)
+		secondImage, err := random.Image(100, 1)
+		require.NoError(t, err)
+
+		bundlePath := filepath.Join(tempDir, "bundle.tar")
+		err = registry.WriteBundle(bundlePath, []byte("some-descriptor"), []registry.BundleImage{
+			{Ref: "some-registry.io/first", Image: firstImage},
+			{Ref: "some-registry.io/second:tag", Image: secondImage},
+		})
+		require.NoError(t, err)
+
+		bundle, err := registry.OpenBundle(bundlePath)
+		require.NoError(t, err)
+		defer bundle.Close()
+
+		require.Equal(t, "some-descriptor", string(bundle.Descriptor()))
+
+		fetched, err := bundle.Fetch(fakeKeychain, "some-registry.io/first")
+		require.NoError(t, err)
+		requireSameDigest(t, firstImage, fetched)
+
+		fetched, err = bundle.Fetch(fakeKeychain, "some-registry.io/second:tag")
+		require.NoError(t, err)
+		requireSameDigest(t, secondImage, fetched)
+
+		_, err = bundle.Fetch(fakeKeychain, "some-registry.io/missing")
+		require.EqualError(t, err, "image 'some-registry.io/missing' not found in bundle")
+	})
+
+	it("keeps image indexes intact", func() {
+		index, err := random.Index(100, 1, 2)
+		require.NoError(t, err)
+		image, err := registry.NewIndexImage(index)
+		require.NoError(t, err)
+
+		bundlePath := filepath.Join(tempDir, "bundle.tar")
+		err = registry.WriteBundle(bundlePath, []byte("some-descriptor"), []registry.BundleImage{
+			{Ref: "some-registry.io/index", Image: image},
+		})
+		require.NoError(t, err)
+
+		bundle, err := registry.OpenBundle(bundlePath)
+		require.NoError(t, err)
+		defer bundle.Close()
+
+		fetched, err := bundle.Fetch(fakeKeychain, "some-registry.io/index")
+		require.NoError(t, err)
+		requireSameDigest(t, image, fetched)
+
+		platforms, err := registry.PlatformImages(fetched)
+		require.NoError(t, err)
+		require.Len(t, platforms, 2)
+	})
+
+	it("extracts bundles larger than the default extract limits", func() {
+		defaultLimits := archive.DefaultExtractLimits
+		archive.DefaultExtractLimits.MaxBytes = 1024
+		defer func() { archive.DefaultExtractLimits = defaultLimits }()
+
+		image, err := random.Image(4096, 1)
+		require.NoError(t, err)
+
+		bundlePath := filepath.Join(tempDir, "bundle.tar")
+		err = registry.WriteBundle(bundlePath, []byte("some-descriptor"), []registry.BundleImage{
+			{Ref: "some-registry.io/large", Image: image},
+		})
+		require.NoError(t, err)
+
+		bundle, err := registry.OpenBundle(bundlePath)
+		require.NoError(t, err)
+		defer bundle.Close()
+
+		fetched, err := bundle.Fetch(fakeKeychain, "some-registry.io/large")
+		require.NoError(t, err)
+		requireSameDigest(t, image, fetched)
+	})
+
+	it("errors when the bundle does not exist", func() {
+		_, err := registry.OpenBundle(filepath.Join(tempDir, "missing.tar"))
+		require.Error(t, err)
+	})
+}
+
+func requireSameDigest(t *testing.T, expected, actual v1.Image) {
+	t.Helper()
+	expectedDigest, err := expected.Digest()
+	require.NoError(t, err)
+	actualDigest, err := actual.Digest()
+	require.NoError(t, err)
+	require.Equal(t, expectedDigest, actualDigest)
+}
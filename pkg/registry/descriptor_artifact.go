@@ -0,0 +1,60 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"io/ioutil"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pkg/errors"
+)
+
+// DescriptorMediaType is the media type of the layer holding the dependency
+// descriptor in an artifact written by NewDescriptorArtifact.
+const DescriptorMediaType types.MediaType = "application/vnd.kpack.dependency-descriptor.v1+yaml"
+
+// DescriptorConfigMediaType is the config media type of an artifact written by
+// NewDescriptorArtifact, marking it as an artifact rather than a runnable image.
+const DescriptorConfigMediaType types.MediaType = "application/vnd.kpack.dependency-descriptor.config.v1+json"
+
+// NewDescriptorArtifact returns an OCI artifact with the descriptor as its only
+// layer.
+func NewDescriptorArtifact(descriptor []byte) (v1.Image, error) {
+	image := mutate.MediaType(empty.Image, types.OCIManifestSchema1)
+	image = mutate.ConfigMediaType(image, DescriptorConfigMediaType)
+	return mutate.AppendLayers(image, static.NewLayer(descriptor, DescriptorMediaType))
+}
+
+// ReadDescriptorArtifact returns the descriptor stored in an image written by
+// NewDescriptorArtifact.
+func ReadDescriptorArtifact(image v1.Image) ([]byte, error) {
+	layers, err := image.Layers()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, layer := range layers {
+		mediaType, err := layer.MediaType()
+		if err != nil {
+			return nil, err
+		}
+		if mediaType != DescriptorMediaType {
+			continue
+		}
+
+		rc, err := layer.Compressed()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		return ioutil.ReadAll(rc)
+	}
+
+	return nil, errors.Errorf("image does not contain a layer of type '%s'", DescriptorMediaType)
+}
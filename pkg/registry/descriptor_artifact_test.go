@@ -0,0 +1,76 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/pivotal/kpack/pkg/registry/registryfakes"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func TestDescriptorArtifact(t *testing.T) {
+	spec.Run(t, "Test Descriptor Artifact", testDescriptorArtifact)
+}
+
+func testDescriptorArtifact(t *testing.T, when spec.G, it spec.S) {
+	const descriptor = "apiVersion: kp.kpack.io/v1alpha3\nkind: DependencyDescriptor\n"
+
+	var (
+		fakeKeychain = &registryfakes.FakeKeychain{}
+		host         string
+	)
+
+	it.Before(func() {
+		server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(ioutil.Discard, "", 0))))
+		t.Cleanup(server.Close)
+
+		uri, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		host = uri.Host
+	})
+
+	it("writes a descriptor artifact to its tag and reads it back", func() {
+		artifact, err := registry.NewDescriptorArtifact([]byte(descriptor))
+		require.NoError(t, err)
+		digest, err := artifact.Digest()
+		require.NoError(t, err)
+
+		ref := fmt.Sprintf("%s/platform/dependencies:1.0.0", host)
+		written, err := registry.NewDefaultRepositoryClient(registry.DefaultTLSConfig(), registry.DefaultRetryConfig()).Write(fakeKeychain, artifact, ref)
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("%s/platform/dependencies@%s", host, digest), written)
+
+		fetched, err := registry.NewDefaultFetcher(registry.DefaultTLSConfig(), registry.DefaultRetryConfig()).Fetch(fakeKeychain, ref)
+		require.NoError(t, err)
+
+		manifest, err := fetched.Manifest()
+		require.NoError(t, err)
+		require.Equal(t, types.OCIManifestSchema1, manifest.MediaType)
+		require.Equal(t, registry.DescriptorConfigMediaType, manifest.Config.MediaType)
+
+		contents, err := registry.ReadDescriptorArtifact(fetched)
+		require.NoError(t, err)
+		require.Equal(t, descriptor, string(contents))
+	})
+
+	it("errors when the image has no descriptor layer", func() {
+		image, err := random.Image(10, 1)
+		require.NoError(t, err)
+
+		_, err = registry.ReadDescriptorArtifact(image)
+		require.EqualError(t, err, "image does not contain a layer of type 'application/vnd.kpack.dependency-descriptor.v1+yaml'")
+	})
+}
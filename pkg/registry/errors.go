@@ -4,15 +4,15 @@
 package registry
 
 import (
-	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/pkg/errors"
 )
 
 func newImageAccessError(ref string, err error) error {
-	if transportError, ok := err.(*transport.Error); ok {
-		if transportError.StatusCode == 401 {
-			return errors.Errorf("invalid credentials, ensure registry credentials for '%s' are available", ref)
-		}
+	switch ClassifyError(err) {
+	case UnauthorizedError:
+		return errors.Errorf("invalid credentials, ensure registry credentials for '%s' are available", ref)
+	case NotFoundError:
+		return errors.Wrapf(err, "'%s' not found", ref)
 	}
 	return errors.WithStack(err)
 }
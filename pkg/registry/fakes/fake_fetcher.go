@@ -5,6 +5,7 @@ package fakes
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
@@ -18,6 +19,7 @@ const (
 )
 
 type Fetcher struct {
+	mu        sync.Mutex
 	images    map[string]v1.Image
 	callCount int
 	err       error
@@ -63,6 +65,8 @@ func NewLifecycleImageFetcher(i ...LifecycleInfo) *Fetcher {
 }
 
 func (f *Fetcher) Fetch(_ authn.Keychain, src string) (v1.Image, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	f.callCount++
 	if f.err != nil {
 		return nil, f.err
@@ -75,6 +79,8 @@ func (f *Fetcher) Fetch(_ authn.Keychain, src string) (v1.Image, error) {
 }
 
 func (f *Fetcher) CallCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
 	return f.callCount
 }
 
@@ -11,6 +11,8 @@ import (
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 )
 
 type Relocator struct {
@@ -24,6 +26,11 @@ type Relocator struct {
 }
 
 func (r *Relocator) Relocate(keychain authn.Keychain, image v1.Image, dest string) (string, error) {
+	outcome, err := r.RelocateWithOutcome(keychain, image, dest)
+	return outcome.Ref, err
+}
+
+func (r *Relocator) RelocateWithOutcome(keychain authn.Keychain, image v1.Image, dest string) (registry.RelocationOutcome, error) {
 	r.calls = append(r.calls, struct {
 		Keychain authn.Keychain
 		Image    v1.Image
@@ -32,13 +39,18 @@ func (r *Relocator) Relocate(keychain authn.Keychain, image v1.Image, dest strin
 
 	digest, err := image.Digest()
 	if err != nil {
-		return "", err
+		return registry.RelocationOutcome{}, err
 	}
 	sha := digest.String()
 
+	size, err := image.Size()
+	if err != nil {
+		return registry.RelocationOutcome{}, err
+	}
+
 	destRef, err := name.ParseReference(dest)
 	if err != nil {
-		return "", err
+		return registry.RelocationOutcome{}, err
 	}
 
 	refDigestStr := fmt.Sprintf("%s/%s@%s", destRef.Context().RegistryStr(), destRef.Context().RepositoryStr(), sha)
@@ -53,7 +65,7 @@ func (r *Relocator) Relocate(keychain authn.Keychain, image v1.Image, dest strin
 		r.writer = ioutil.Discard
 	}
 	_, err = r.writer.Write([]byte(message))
-	return refDigestStr, err
+	return registry.RelocationOutcome{Ref: refDigestStr, Uploaded: !r.skip, Size: size}, err
 }
 
 func (r *Relocator) CallCount() int {
@@ -0,0 +1,112 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package fakes
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+)
+
+// RepositoryClient is an in-memory registry of tags keyed by repository.
+type RepositoryClient struct {
+	Tags           map[string]map[string]string
+	CatalogEnabled bool
+	Deleted        []string
+	Written        []v1.Image
+}
+
+func NewRepositoryClient() *RepositoryClient {
+	return &RepositoryClient{Tags: map[string]map[string]string{}}
+}
+
+// AddTag points repository:tag at digest.
+func (r *RepositoryClient) AddTag(repository, tag, digest string) {
+	if r.Tags[repository] == nil {
+		r.Tags[repository] = map[string]string{}
+	}
+	r.Tags[repository][tag] = digest
+}
+
+func (r *RepositoryClient) Catalog(_ authn.Keychain, registry string) ([]string, error) {
+	if !r.CatalogEnabled {
+		return nil, fmt.Errorf("catalog not supported by '%s'", registry)
+	}
+
+	var repositories []string
+	for repository := range r.Tags {
+		if strings.HasPrefix(repository, registry+"/") {
+			repositories = append(repositories, repository)
+		}
+	}
+	sort.Strings(repositories)
+	return repositories, nil
+}
+
+func (r *RepositoryClient) ListTags(_ authn.Keychain, repository string) ([]string, error) {
+	tags, ok := r.Tags[repository]
+	if !ok {
+		return nil, &transport.Error{StatusCode: http.StatusNotFound}
+	}
+
+	var result []string
+	for tag := range tags {
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func (r *RepositoryClient) Digest(_ authn.Keychain, ref string) (string, error) {
+	tag, err := name.NewTag(ref, name.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+
+	digest, ok := r.Tags[tag.Context().Name()][tag.TagStr()]
+	if !ok {
+		return "", &transport.Error{StatusCode: http.StatusNotFound}
+	}
+	return digest, nil
+}
+
+func (r *RepositoryClient) Write(_ authn.Keychain, image v1.Image, ref string) (string, error) {
+	imageRef, err := name.ParseReference(ref, name.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := image.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	if tag, ok := imageRef.(name.Tag); ok {
+		r.AddTag(tag.Context().Name(), tag.TagStr(), digest.String())
+	}
+	r.Written = append(r.Written, image)
+	return imageRef.Context().Digest(digest.String()).String(), nil
+}
+
+func (r *RepositoryClient) Delete(_ authn.Keychain, ref string) error {
+	digest, err := name.NewDigest(ref, name.WeakValidation)
+	if err != nil {
+		return err
+	}
+
+	tags := r.Tags[digest.Context().Name()]
+	for tag, d := range tags {
+		if d == digest.DigestStr() {
+			delete(tags, tag)
+		}
+	}
+	r.Deleted = append(r.Deleted, ref)
+	return nil
+}
@@ -10,20 +10,25 @@ import (
 )
 
 type UtilProvider struct {
-	FakeFetcher registry.Fetcher
+	FakeFetcher          registry.Fetcher
+	FakeRepositoryClient registry.RepositoryClient
 }
 
-func (u UtilProvider) Relocator(writer io.Writer, _ registry.TLSConfig, changeState bool) registry.Relocator {
+func (u UtilProvider) Relocator(writer io.Writer, _ registry.TLSConfig, _ registry.RetryConfig, changeState, _ bool) registry.Relocator {
 	return &Relocator{
 		skip:   !changeState,
 		writer: writer,
 	}
 }
 
-func (u UtilProvider) Fetcher(_ registry.TLSConfig) registry.Fetcher {
+func (u UtilProvider) Fetcher(_ registry.TLSConfig, _ registry.RetryConfig) registry.Fetcher {
 	return u.FakeFetcher
 }
 
-func (u UtilProvider) SourceUploader(writer io.Writer, tlsConfig registry.TLSConfig, changeState bool) registry.SourceUploader {
+func (u UtilProvider) SourceUploader(writer io.Writer, _ registry.TLSConfig, _ registry.RetryConfig, changeState, _ bool) registry.SourceUploader {
 	return NewFakeSourceUploader(writer, changeState)
 }
+
+func (u UtilProvider) RepositoryClient(_ registry.TLSConfig, _ registry.RetryConfig) registry.RepositoryClient {
+	return u.FakeRepositoryClient
+}
@@ -6,6 +6,7 @@ package registry
 import (
 	"os"
 	"runtime"
+	"sync"
 
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
@@ -19,11 +20,12 @@ type Fetcher interface {
 }
 
 type DefaultFetcher struct {
-	tlsCfg TLSConfig
+	tlsCfg   TLSConfig
+	retryCfg RetryConfig
 }
 
-func NewDefaultFetcher(tlsCfg TLSConfig) DefaultFetcher {
-	return DefaultFetcher{tlsCfg: tlsCfg}
+func NewDefaultFetcher(tlsCfg TLSConfig, retryCfg RetryConfig) DefaultFetcher {
+	return DefaultFetcher{tlsCfg: tlsCfg, retryCfg: retryCfg}
 }
 
 func (d DefaultFetcher) Fetch(keychain authn.Keychain, src string) (v1.Image, error) {
@@ -46,11 +48,26 @@ func (d DefaultFetcher) Fetch(keychain authn.Keychain, src string) (v1.Image, er
 			return nil, err
 		}
 
-		img, err := remote.Image(imageRef, remote.WithAuthFromKeychain(keychain), remote.WithTransport(t))
+		options := append([]remote.Option{remote.WithAuthFromKeychain(keychain), remote.WithTransport(t)}, d.retryCfg.remoteOptions()...)
+
+		var desc *remote.Descriptor
+		err = d.retryCfg.retry(d.retryCfg.Writer, imageRef.String(), func() error {
+			desc, err = remote.Get(imageRef, options...)
+			return err
+		})
 		if err != nil {
 			return nil, newImageAccessError(imageRef.String(), err)
 		}
-		return img, nil
+
+		if desc.MediaType.IsIndex() {
+			index, err := desc.ImageIndex()
+			if err != nil {
+				return nil, err
+			}
+			return NewIndexImage(index)
+		}
+
+		return desc.Image()
 	}
 }
 
@@ -58,3 +75,55 @@ func (d DefaultFetcher) isLocal(src string) bool {
 	_, err := os.Stat(src)
 	return err == nil
 }
+
+// CachingFetcher remembers the images it fetched, so that an image fetched
+// while prefetching is not fetched again when it is relocated.
+type CachingFetcher struct {
+	fetcher Fetcher
+
+	mu     sync.Mutex
+	images map[string]v1.Image
+}
+
+// NewCachingFetcher wraps fetcher, unless it already caches.
+func NewCachingFetcher(fetcher Fetcher) *CachingFetcher {
+	if caching, ok := fetcher.(*CachingFetcher); ok {
+		return caching
+	}
+	return &CachingFetcher{fetcher: fetcher, images: map[string]v1.Image{}}
+}
+
+func (c *CachingFetcher) Fetch(keychain authn.Keychain, src string) (v1.Image, error) {
+	c.mu.Lock()
+	image, ok := c.images[src]
+	c.mu.Unlock()
+	if ok {
+		return image, nil
+	}
+
+	image, err := c.fetcher.Fetch(keychain, src)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.images[src] = image
+	c.mu.Unlock()
+	return image, nil
+}
+
+// IsRemoteTag reports whether src is a tag in a registry, rather than a digest
+// or a local file, and so may point to a different image over time.
+func IsRemoteTag(src string) bool {
+	if _, err := os.Stat(src); err == nil {
+		return false
+	}
+
+	ref, err := name.ParseReference(src, name.WeakValidation)
+	if err != nil {
+		return false
+	}
+
+	_, ok := ref.(name.Tag)
+	return ok
+}
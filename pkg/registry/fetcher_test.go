@@ -0,0 +1,56 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"testing"
+
+	"github.com/pivotal/kpack/pkg/registry/registryfakes"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
+)
+
+func TestCachingFetcher(t *testing.T) {
+	spec.Run(t, "Test Caching Fetcher", testCachingFetcher)
+}
+
+func testCachingFetcher(t *testing.T, when spec.G, it spec.S) {
+	var (
+		fakeKeychain = &registryfakes.FakeKeychain{}
+		fetcher      *fakes.Fetcher
+		caching      *registry.CachingFetcher
+	)
+
+	it.Before(func() {
+		fetcher = &fakes.Fetcher{}
+		fetcher.AddImage("some-registry.io/image", fakes.NewFakeImage("some-digest"))
+		caching = registry.NewCachingFetcher(fetcher)
+	})
+
+	it("fetches each image once", func() {
+		first, err := caching.Fetch(fakeKeychain, "some-registry.io/image")
+		require.NoError(t, err)
+
+		second, err := caching.Fetch(fakeKeychain, "some-registry.io/image")
+		require.NoError(t, err)
+		require.Equal(t, first, second)
+		require.Equal(t, 1, fetcher.CallCount())
+	})
+
+	it("fetches again after an error", func() {
+		_, err := caching.Fetch(fakeKeychain, "some-registry.io/missing")
+		require.EqualError(t, err, `image not found: "some-registry.io/missing"`)
+
+		_, err = caching.Fetch(fakeKeychain, "some-registry.io/missing")
+		require.Error(t, err)
+		require.Equal(t, 2, fetcher.CallCount())
+	})
+
+	it("does not wrap a caching fetcher again", func() {
+		require.Same(t, caching, registry.NewCachingFetcher(caching))
+	})
+}
@@ -6,6 +6,23 @@ package registry
 import v1 "github.com/google/go-containerregistry/pkg/v1"
 
 func imageSize(image v1.Image) (int64, error) {
+	images, err := PlatformImages(image)
+	if err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, i := range images {
+		s, err := platformImageSize(i)
+		if err != nil {
+			return 0, err
+		}
+		size += s
+	}
+	return size, nil
+}
+
+func platformImageSize(image v1.Image) (int64, error) {
 	size, err := image.Size()
 	if err != nil {
 		return 0, err
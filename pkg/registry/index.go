@@ -0,0 +1,112 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"runtime"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/pkg/errors"
+)
+
+// indexImage stands in for a multi-arch image index wherever a v1.Image is
+// expected. Its manifest, config, and layers are those of a single platform so
+// that labels can be read as usual, but Digest reports the index digest and the
+// relocator copies the whole index.
+type indexImage struct {
+	v1.Image
+	index v1.ImageIndex
+}
+
+// NewIndexImage wraps index so it can travel through Fetcher and Relocator
+// without losing its other platforms.
+func NewIndexImage(index v1.ImageIndex) (v1.Image, error) {
+	images, err := indexImages(index)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexImage{Image: defaultPlatformImage(images), index: index}, nil
+}
+
+func (i indexImage) Digest() (v1.Hash, error) {
+	return i.index.Digest()
+}
+
+// ImageIndex returns the index an image fetched from one stands in for.
+func ImageIndex(image v1.Image) (v1.ImageIndex, bool) {
+	i, ok := image.(indexImage)
+	if !ok {
+		return nil, false
+	}
+	return i.index, true
+}
+
+// PlatformImages returns the image for every platform of an index, or the
+// image itself when it is not an index.
+func PlatformImages(image v1.Image) ([]v1.Image, error) {
+	index, ok := ImageIndex(image)
+	if !ok {
+		return []v1.Image{image}, nil
+	}
+
+	images, err := indexImages(index)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]v1.Image, 0, len(images))
+	for _, i := range images {
+		result = append(result, i.image)
+	}
+	return result, nil
+}
+
+type platformImage struct {
+	platform *v1.Platform
+	image    v1.Image
+}
+
+func indexImages(index v1.ImageIndex) ([]platformImage, error) {
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, err
+	}
+
+	var images []platformImage
+	for _, m := range manifest.Manifests {
+		if !m.MediaType.IsImage() {
+			continue
+		}
+
+		image, err := index.Image(m.Digest)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, platformImage{platform: m.Platform, image: image})
+	}
+
+	if len(images) == 0 {
+		return nil, errors.New("image index does not contain any images")
+	}
+	return images, nil
+}
+
+// defaultPlatformImage prefers linux on the current architecture, then any
+// linux image, then the first image in the index.
+func defaultPlatformImage(images []platformImage) v1.Image {
+	for _, i := range images {
+		if i.platform != nil && i.platform.OS == "linux" && i.platform.Architecture == runtime.GOARCH {
+			return i.image
+		}
+	}
+
+	for _, i := range images {
+		if i.platform != nil && i.platform.OS == "linux" {
+			return i.image
+		}
+	}
+
+	return images[0].image
+}
@@ -0,0 +1,95 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pivotal/kpack/pkg/registry/registryfakes"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func TestImageIndex(t *testing.T) {
+	spec.Run(t, "Test Image Index", testImageIndex)
+}
+
+func testImageIndex(t *testing.T, when spec.G, it spec.S) {
+	var (
+		fakeKeychain = &registryfakes.FakeKeychain{}
+		host         string
+	)
+
+	it.Before(func() {
+		server := httptest.NewServer(ggcrregistry.New(ggcrregistry.Logger(log.New(ioutil.Discard, "", 0))))
+		t.Cleanup(server.Close)
+
+		uri, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		host = uri.Host
+	})
+
+	it("fetches and relocates every platform of an index", func() {
+		index, err := random.Index(100, 1, 3)
+		require.NoError(t, err)
+		indexDigest, err := index.Digest()
+		require.NoError(t, err)
+
+		src, err := name.ParseReference(fmt.Sprintf("%s/src/index:latest", host))
+		require.NoError(t, err)
+		require.NoError(t, remote.WriteIndex(src, index))
+
+		fetched, err := registry.NewDefaultFetcher(registry.DefaultTLSConfig(), registry.DefaultRetryConfig()).Fetch(fakeKeychain, src.String())
+		require.NoError(t, err)
+
+		fetchedDigest, err := fetched.Digest()
+		require.NoError(t, err)
+		require.Equal(t, indexDigest, fetchedDigest)
+
+		platforms, err := registry.PlatformImages(fetched)
+		require.NoError(t, err)
+		require.Len(t, platforms, 3)
+
+		relocator := registry.NewDefaultRelocator(ioutil.Discard, registry.DefaultTLSConfig(), registry.DefaultRetryConfig(), false)
+		relocatedRef, err := relocator.Relocate(fakeKeychain, fetched, fmt.Sprintf("%s/dest/repo", host))
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("%s/dest/repo@%s", host, indexDigest), relocatedRef)
+
+		dst, err := name.ParseReference(relocatedRef)
+		require.NoError(t, err)
+		relocated, err := remote.Index(dst)
+		require.NoError(t, err)
+
+		manifest, err := relocated.IndexManifest()
+		require.NoError(t, err)
+		require.Len(t, manifest.Manifests, 3)
+		for _, m := range manifest.Manifests {
+			_, err := remote.Image(dst.Context().Digest(m.Digest.String()))
+			require.NoError(t, err)
+		}
+	})
+
+	it("treats single images as their only platform", func() {
+		image, err := random.Image(100, 1)
+		require.NoError(t, err)
+
+		_, ok := registry.ImageIndex(image)
+		require.False(t, ok)
+
+		platforms, err := registry.PlatformImages(image)
+		require.NoError(t, err)
+		require.Len(t, platforms, 1)
+	})
+}
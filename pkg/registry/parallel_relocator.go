@@ -0,0 +1,226 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+)
+
+// RelocationJob is an image to be copied to the destination repository.
+type RelocationJob struct {
+	Image       v1.Image
+	Destination string
+
+	// Fetch, when set, is called on a worker to fetch the image and choose its
+	// destination, so that images are fetched as concurrently as they are
+	// relocated. Image and Destination are ignored.
+	Fetch func() (v1.Image, string, error)
+}
+
+// Prefetcher is implemented by relocators that can copy a set of images ahead of
+// the Relocate calls that will ask for them.
+type Prefetcher interface {
+	Prefetch(keychain authn.Keychain, jobs []RelocationJob) error
+}
+
+type relocationKey struct {
+	digest      string
+	destination string
+}
+
+type relocationResult struct {
+	outcome RelocationOutcome
+	output  []byte
+	err     error
+}
+
+// ParallelRelocator relocates prefetched images on a bounded pool of workers.
+// The output of every relocation is buffered and replayed when Relocate is
+// called for that image, so the output reads the same as a sequential run no
+// matter which upload finishes first.
+type ParallelRelocator struct {
+	writer        io.Writer
+	parallelism   int
+	makeRelocator func(writer io.Writer) Relocator
+
+	mu      sync.Mutex
+	results map[relocationKey]relocationResult
+}
+
+func NewParallelRelocator(writer io.Writer, parallelism int, makeRelocator func(writer io.Writer) Relocator) *ParallelRelocator {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	return &ParallelRelocator{
+		writer:        writer,
+		parallelism:   parallelism,
+		makeRelocator: makeRelocator,
+		results:       map[relocationKey]relocationResult{},
+	}
+}
+
+// NewParallelRelocatorFromProvider wraps the relocator provided by rup so that
+// prefetched images are relocated on up to parallelism workers.
+func NewParallelRelocatorFromProvider(rup UtilProvider, writer io.Writer, tlsCfg TLSConfig, retryCfg RetryConfig, changeState, alwaysTag bool, parallelism int) *ParallelRelocator {
+	return NewParallelRelocator(writer, parallelism, func(w io.Writer) Relocator {
+		return rup.Relocator(w, tlsCfg, retryCfg, changeState, alwaysTag)
+	})
+}
+
+// Prefetch relocates the jobs that have not been relocated yet. It returns the
+// first error fetching a job, in the order of jobs, once every job is done.
+// Relocation failures are not returned here; they are reported by the Relocate
+// call for the image.
+func (p *ParallelRelocator) Prefetch(keychain authn.Keychain, jobs []RelocationJob) error {
+	pending := map[relocationKey]struct{}{}
+	var queue []RelocationJob
+	for _, job := range jobs {
+		if job.Fetch == nil {
+			key, err := newRelocationKey(job.Image, job.Destination)
+			if err != nil {
+				return err
+			}
+
+			if _, ok := p.result(key); ok {
+				continue
+			}
+			if _, ok := pending[key]; ok {
+				continue
+			}
+			pending[key] = struct{}{}
+		}
+		queue = append(queue, job)
+	}
+
+	if len(queue) == 0 {
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		indexes  = make(chan int)
+		errs     = make([]error, len(queue))
+		started  = map[relocationKey]struct{}{}
+		doneMu   sync.Mutex
+		done     int
+		progress = func() string {
+			doneMu.Lock()
+			defer doneMu.Unlock()
+			return fmt.Sprintf("%d/%d images uploaded", done, len(queue))
+		}
+	)
+
+	spinner := newUploadSpinner(p.writer, progress)
+	go spinner.Write()
+
+	for w := 0; w < p.parallelism && w < len(queue); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				errs[i] = p.prefetch(keychain, queue[i], started)
+
+				doneMu.Lock()
+				done++
+				doneMu.Unlock()
+			}
+		}()
+	}
+
+	for i := range queue {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	spinner.Stop()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prefetch fetches the image of a job when needed and relocates it, unless
+// another worker has already started relocating the same image to the same
+// destination.
+func (p *ParallelRelocator) prefetch(keychain authn.Keychain, job RelocationJob, started map[relocationKey]struct{}) error {
+	image, destination := job.Image, job.Destination
+	if job.Fetch != nil {
+		var err error
+		image, destination, err = job.Fetch()
+		if err != nil {
+			return err
+		}
+	}
+
+	key, err := newRelocationKey(image, destination)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	_, relocated := p.results[key]
+	_, ok := started[key]
+	started[key] = struct{}{}
+	p.mu.Unlock()
+	if relocated || ok {
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	outcome, err := RelocateWithOutcome(p.makeRelocator(buf), keychain, image, destination)
+
+	p.mu.Lock()
+	p.results[key] = relocationResult{outcome: outcome, output: buf.Bytes(), err: err}
+	p.mu.Unlock()
+	return nil
+}
+
+// Relocate returns the result of a prefetched relocation, writing its buffered
+// output, or relocates the image directly when it was not prefetched.
+func (p *ParallelRelocator) Relocate(keychain authn.Keychain, image v1.Image, destination string) (string, error) {
+	outcome, err := p.RelocateWithOutcome(keychain, image, destination)
+	return outcome.Ref, err
+}
+
+func (p *ParallelRelocator) RelocateWithOutcome(keychain authn.Keychain, image v1.Image, destination string) (RelocationOutcome, error) {
+	key, err := newRelocationKey(image, destination)
+	if err != nil {
+		return RelocationOutcome{}, err
+	}
+
+	result, ok := p.result(key)
+	if !ok {
+		return RelocateWithOutcome(p.makeRelocator(p.writer), keychain, image, destination)
+	}
+
+	if _, err := p.writer.Write(result.output); err != nil {
+		return RelocationOutcome{}, err
+	}
+	return result.outcome, result.err
+}
+
+func (p *ParallelRelocator) result(key relocationKey) (relocationResult, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.results[key]
+	return result, ok
+}
+
+func newRelocationKey(image v1.Image, destination string) (relocationKey, error) {
+	digest, err := image.Digest()
+	if err != nil {
+		return relocationKey{}, err
+	}
+	return relocationKey{digest: digest.String(), destination: destination}, nil
+}
@@ -0,0 +1,174 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/pivotal/kpack/pkg/registry/registryfakes"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func TestParallelRelocator(t *testing.T) {
+	spec.Run(t, "Test Parallel Relocator", testParallelRelocator)
+}
+
+func testParallelRelocator(t *testing.T, when spec.G, it spec.S) {
+	var (
+		fakeKeychain = &registryfakes.FakeKeychain{}
+		output       *bytes.Buffer
+		counter      *countingRelocator
+		relocator    *registry.ParallelRelocator
+	)
+
+	it.Before(func() {
+		output = &bytes.Buffer{}
+		counter = &countingRelocator{failures: map[string]error{}}
+		relocator = registry.NewParallelRelocator(output, 3, counter.withWriter)
+	})
+
+	randomImage := func() v1.Image {
+		image, err := random.Image(10, 1)
+		require.NoError(t, err)
+		return image
+	}
+
+	it("replays prefetched relocations in the order they are requested", func() {
+		images := []v1.Image{randomImage(), randomImage(), randomImage()}
+
+		var jobs []registry.RelocationJob
+		for _, image := range images {
+			jobs = append(jobs, registry.RelocationJob{Image: image, Destination: "some-registry.io/repo"})
+		}
+		require.NoError(t, relocator.Prefetch(fakeKeychain, jobs))
+		require.Equal(t, 3, counter.count())
+		require.Empty(t, output.String())
+
+		var expected string
+		for i := len(images) - 1; i >= 0; i-- {
+			ref, err := relocator.Relocate(fakeKeychain, images[i], "some-registry.io/repo")
+			require.NoError(t, err)
+			expected += fmt.Sprintf("\tUploading '%s'\n", ref)
+		}
+		require.Equal(t, expected, output.String())
+		require.Equal(t, 3, counter.count())
+	})
+
+	it("relocates each image and destination only once", func() {
+		image := randomImage()
+
+		require.NoError(t, relocator.Prefetch(fakeKeychain, []registry.RelocationJob{
+			{Image: image, Destination: "some-registry.io/repo"},
+			{Image: image, Destination: "some-registry.io/repo"},
+			{Image: image, Destination: "other-registry.io/repo"},
+		}))
+		require.Equal(t, 2, counter.count())
+
+		require.NoError(t, relocator.Prefetch(fakeKeychain, []registry.RelocationJob{
+			{Image: image, Destination: "some-registry.io/repo"},
+		}))
+		require.Equal(t, 2, counter.count())
+	})
+
+	it("relocates images that were not prefetched directly", func() {
+		image := randomImage()
+
+		ref, err := relocator.Relocate(fakeKeychain, image, "some-registry.io/repo")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("\tUploading '%s'\n", ref), output.String())
+		require.Equal(t, 1, counter.count())
+	})
+
+	it("reports prefetch failures when the image is relocated", func() {
+		image := randomImage()
+		digest, err := image.Digest()
+		require.NoError(t, err)
+		counter.failures[digest.String()] = errors.New("some-upload-error")
+
+		require.NoError(t, relocator.Prefetch(fakeKeychain, []registry.RelocationJob{
+			{Image: image, Destination: "some-registry.io/repo"},
+		}))
+
+		_, err = relocator.Relocate(fakeKeychain, image, "some-registry.io/repo")
+		require.EqualError(t, err, "some-upload-error")
+	})
+
+	it("fetches images on the workers and returns the first fetch error", func() {
+		image := randomImage()
+		// computes the digest up front, as mutated images compute it lazily
+		// without locking
+		_, err := image.Digest()
+		require.NoError(t, err)
+
+		fetch := func(image v1.Image, err error) func() (v1.Image, string, error) {
+			return func() (v1.Image, string, error) {
+				return image, "some-registry.io/repo", err
+			}
+		}
+
+		err = relocator.Prefetch(fakeKeychain, []registry.RelocationJob{
+			{Fetch: fetch(image, nil)},
+			{Fetch: fetch(nil, errors.New("some-fetch-error"))},
+			{Fetch: fetch(image, nil)},
+			{Fetch: fetch(nil, errors.New("other-fetch-error"))},
+		})
+		require.EqualError(t, err, "some-fetch-error")
+		require.Equal(t, 1, counter.count())
+		require.Empty(t, output.String())
+
+		ref, err := relocator.Relocate(fakeKeychain, image, "some-registry.io/repo")
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("\tUploading '%s'\n", ref), output.String())
+		require.Equal(t, 1, counter.count())
+	})
+}
+
+type countingRelocator struct {
+	mu       sync.Mutex
+	calls    int
+	failures map[string]error
+}
+
+func (c *countingRelocator) withWriter(writer io.Writer) registry.Relocator {
+	return relocatorFunc(func(keychain authn.Keychain, image v1.Image, dest string) (string, error) {
+		c.mu.Lock()
+		c.calls++
+		c.mu.Unlock()
+
+		digest, err := image.Digest()
+		if err != nil {
+			return "", err
+		}
+		if err := c.failures[digest.String()]; err != nil {
+			return "", err
+		}
+
+		ref := fmt.Sprintf("%s@%s", dest, digest)
+		_, err = fmt.Fprintf(writer, "\tUploading '%s'\n", ref)
+		return ref, err
+	})
+}
+
+func (c *countingRelocator) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+type relocatorFunc func(keychain authn.Keychain, image v1.Image, dest string) (string, error)
+
+func (f relocatorFunc) Relocate(keychain authn.Keychain, image v1.Image, dest string) (string, error) {
+	return f(keychain, image, dest)
+}
@@ -18,6 +18,39 @@ type Relocator interface {
 	Relocate(keychain authn.Keychain, src v1.Image, destination string) (string, error)
 }
 
+// RelocationOutcome is the result of relocating an image: the relocated
+// reference, whether the image was uploaded or already present, and its size.
+type RelocationOutcome struct {
+	Ref      string
+	Uploaded bool
+	Size     int64
+}
+
+// OutcomeRelocator is implemented by relocators that report whether an image
+// was uploaded.
+type OutcomeRelocator interface {
+	RelocateWithOutcome(keychain authn.Keychain, src v1.Image, destination string) (RelocationOutcome, error)
+}
+
+// RelocateWithOutcome relocates src with r. Relocators that do not report
+// their outcome are assumed to have uploaded the image.
+func RelocateWithOutcome(r Relocator, keychain authn.Keychain, src v1.Image, destination string) (RelocationOutcome, error) {
+	if outcomeRelocator, ok := r.(OutcomeRelocator); ok {
+		return outcomeRelocator.RelocateWithOutcome(keychain, src, destination)
+	}
+
+	ref, err := r.Relocate(keychain, src, destination)
+	if err != nil {
+		return RelocationOutcome{Ref: ref}, err
+	}
+
+	size, err := imageSize(src)
+	if err != nil {
+		return RelocationOutcome{Ref: ref}, err
+	}
+	return RelocationOutcome{Ref: ref, Uploaded: true, Size: size}, nil
+}
+
 type DiscardRelocator struct {
 	writer io.Writer
 }
@@ -27,53 +60,97 @@ func NewDiscardRelocator(writer io.Writer) DiscardRelocator {
 }
 
 func (d DiscardRelocator) Relocate(keychain authn.Keychain, src v1.Image, destination string) (string, error) {
+	outcome, err := d.RelocateWithOutcome(keychain, src, destination)
+	return outcome.Ref, err
+}
+
+func (d DiscardRelocator) RelocateWithOutcome(keychain authn.Keychain, src v1.Image, destination string) (RelocationOutcome, error) {
 	cfg, err := getDstImageInfo(src, destination)
 	if err != nil {
-		return "", err
+		return RelocationOutcome{}, err
 	}
 
 	_, err = d.writer.Write([]byte(fmt.Sprintf("\tSkipping '%s'\n", cfg.refDigestStr)))
-	return cfg.refDigestStr, err
+	return RelocationOutcome{Ref: cfg.refDigestStr, Size: cfg.size}, err
 }
 
 type DefaultRelocator struct {
-	tlsCfg TLSConfig
-	writer io.Writer
+	tlsCfg    TLSConfig
+	retryCfg  RetryConfig
+	writer    io.Writer
+	alwaysTag bool
 }
 
-func NewDefaultRelocator(writer io.Writer, tlsCfg TLSConfig) DefaultRelocator {
-	return DefaultRelocator{writer: writer, tlsCfg: tlsCfg}
+// NewDefaultRelocator returns a relocator that skips images whose digest is
+// already present in the destination repository unless alwaysTag is set, in
+// which case every image is written and tagged with a timestamp.
+func NewDefaultRelocator(writer io.Writer, tlsCfg TLSConfig, retryCfg RetryConfig, alwaysTag bool) DefaultRelocator {
+	return DefaultRelocator{writer: writer, tlsCfg: tlsCfg, retryCfg: retryCfg, alwaysTag: alwaysTag}
 }
 
 func (d DefaultRelocator) Relocate(keychain authn.Keychain, src v1.Image, destination string) (string, error) {
+	outcome, err := d.RelocateWithOutcome(keychain, src, destination)
+	return outcome.Ref, err
+}
+
+func (d DefaultRelocator) RelocateWithOutcome(keychain authn.Keychain, src v1.Image, destination string) (RelocationOutcome, error) {
 	cfg, err := getDstImageInfo(src, destination)
 	if err != nil {
-		return "", err
+		return RelocationOutcome{}, err
 	}
 
-	if _, err := d.writer.Write([]byte(fmt.Sprintf("\tUploading '%s'", cfg.refDigestStr))); err != nil {
-		return cfg.refDigestStr, err
+	outcome := RelocationOutcome{Ref: cfg.refDigestStr, Size: cfg.size}
+	transport, err := d.tlsCfg.Transport()
+	if err != nil {
+		return outcome, err
+	}
+	imgWriteOptions := append([]remote.Option{
+		remote.WithAuthFromKeychain(keychain),
+		remote.WithTransport(transport),
+	}, d.retryCfg.remoteOptions()...)
+
+	if !d.alwaysTag && isPresent(cfg.refDigestStr, imgWriteOptions) {
+		_, err := d.writer.Write([]byte(fmt.Sprintf("\tAlready present '%s'\n", cfg.refDigestStr)))
+		return outcome, err
+	}
+
+	outcome.Uploaded = true
+	if _, err := d.writer.Write([]byte(fmt.Sprintf("\tUploading '%s'\n", cfg.refDigestStr))); err != nil {
+		return outcome, err
 	}
 
-	spinner := newUploadSpinner(d.writer, cfg.size)
+	spinner := newUploadSpinner(d.writer, sizeStatus(cfg.size))
 	defer spinner.Stop()
 	go spinner.Write()
 
-	transport, err := d.tlsCfg.Transport()
+	var taggable remote.Taggable = src
+	err = d.retryCfg.retry(d.writer, cfg.refDigestStr, func() error {
+		if index, ok := ImageIndex(src); ok {
+			taggable = index
+			return remote.WriteIndex(cfg.refRepo, index, imgWriteOptions...)
+		}
+		return remote.Write(cfg.refRepo, src, imgWriteOptions...)
+	})
 	if err != nil {
-		return cfg.refDigestStr, err
-	}
-	imgWriteOptions := []remote.Option{
-		remote.WithAuthFromKeychain(keychain),
-		remote.WithTransport(transport),
+		return outcome, newImageAccessError(cfg.refRepo.Context().RegistryStr(), err)
 	}
 
-	err = remote.Write(cfg.refRepo, src, imgWriteOptions...)
+	err = d.retryCfg.retry(d.writer, cfg.tag.String(), func() error {
+		return remote.Tag(cfg.tag, taggable, imgWriteOptions...)
+	})
+	return outcome, err
+}
+
+// isPresent reports whether the destination already has a manifest for the
+// digest. Any error is treated as absent so that the upload surfaces it.
+func isPresent(refDigestStr string, options []remote.Option) bool {
+	ref, err := name.NewDigest(refDigestStr, name.WeakValidation)
 	if err != nil {
-		return cfg.refDigestStr, newImageAccessError(cfg.refRepo.Context().RegistryStr(), err)
+		return false
 	}
 
-	return cfg.refDigestStr, remote.Tag(cfg.tag, src, imgWriteOptions...)
+	_, err = remote.Head(ref, options...)
+	return err == nil
 }
 
 type relocateImageInfo struct {
@@ -118,7 +195,23 @@ func getDstImageInfo(srcImage v1.Image, dstRepoStr string) (relocateImageInfo, e
 	return imgInfo, err
 }
 
+const timestampTagLayout = "20060102150405"
+
 func timestampTag() string {
 	now := time.Now()
 	return fmt.Sprintf("%s%02d%02d%02d", now.Format("20060102"), now.Hour(), now.Minute(), now.Second())
 }
+
+// ParseTimestampTag returns when the relocator wrote an image from the
+// timestamp tag it gave it, or false for any other tag.
+func ParseTimestampTag(tag string) (time.Time, bool) {
+	if len(tag) != len(timestampTagLayout) {
+		return time.Time{}, false
+	}
+
+	t, err := time.ParseInLocation(timestampTagLayout, tag, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
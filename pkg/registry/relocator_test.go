@@ -14,6 +14,7 @@ import (
 	"strings"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/pivotal/kpack/pkg/registry/registryfakes"
 	"github.com/sclevine/spec"
@@ -79,7 +80,7 @@ func testRelocateStackImages(t *testing.T, when spec.G, it spec.S) {
 			require.NoError(t, err)
 
 			output := &bytes.Buffer{}
-			relocator := registry.NewDefaultRelocator(output, registry.DefaultTLSConfig())
+			relocator := registry.NewDefaultRelocator(output, registry.DefaultTLSConfig(), registry.DefaultRetryConfig(), false)
 			relocatedRef, err := relocator.Relocate(fakeKeychain, srcImage, dst)
 			require.NoError(t, err)
 
@@ -88,14 +89,81 @@ func testRelocateStackImages(t *testing.T, when spec.G, it spec.S) {
 			require.Equal(t, srcImageDigest.Hex, relocatedHex)
 			require.Equal(t, 1, additionalTags)
 
-			require.Equal(t, output.String(), fmt.Sprintf("\tUploading '%s'", relocatedRef))
+			require.Equal(t, fmt.Sprintf("\tUploading '%s'\n", relocatedRef), output.String())
+		})
+
+		when("the digest is already present in the dest registry", func() {
+			var (
+				dstImageName = "dest-repo/an-image"
+				srcImage     v1.Image
+				uploads      int
+				dst          string
+			)
+
+			it.Before(func() {
+				var err error
+				srcImage, err = random.Image(int64(100), int64(5))
+				require.NoError(t, err)
+				srcImageDigest, err := srcImage.Digest()
+				require.NoError(t, err)
+				manifest, err := srcImage.RawManifest()
+				require.NoError(t, err)
+				mediaType, err := srcImage.MediaType()
+				require.NoError(t, err)
+
+				uploads = 0
+				dstRegistryServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch path := r.URL.Path; {
+					case path == "/v2/":
+						w.WriteHeader(http.StatusOK)
+					case r.Method == http.MethodHead && path == "/v2/"+dstImageName+"/manifests/"+srcImageDigest.String():
+						w.Header().Set("Content-Type", string(mediaType))
+						w.Header().Set("Docker-Content-Digest", srcImageDigest.String())
+						w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+						w.WriteHeader(http.StatusOK)
+					case r.Method == http.MethodHead:
+						w.Header().Set("Docker-Content-Digest", srcImageDigest.String())
+						w.WriteHeader(http.StatusOK)
+					case strings.Contains(path, "/manifests/"):
+						uploads++
+						http.Error(w, "Created", http.StatusCreated)
+					default:
+						http.Error(w, "Created", http.StatusCreated)
+					}
+				}))
+				t.Cleanup(dstRegistryServer.Close)
+
+				uri, err := url.Parse(dstRegistryServer.URL)
+				require.NoError(t, err)
+				dst = fmt.Sprintf("%s/%s", uri.Host, dstImageName)
+			})
+
+			it("reuses the digest without uploading or tagging", func() {
+				output := &bytes.Buffer{}
+				relocator := registry.NewDefaultRelocator(output, registry.DefaultTLSConfig(), registry.DefaultRetryConfig(), false)
+				relocatedRef, err := relocator.Relocate(fakeKeychain, srcImage, dst)
+				require.NoError(t, err)
+
+				require.Equal(t, 0, uploads)
+				require.Equal(t, fmt.Sprintf("\tAlready present '%s'\n", relocatedRef), output.String())
+			})
+
+			it("uploads and tags the image with always tag", func() {
+				output := &bytes.Buffer{}
+				relocator := registry.NewDefaultRelocator(output, registry.DefaultTLSConfig(), registry.DefaultRetryConfig(), true)
+				relocatedRef, err := relocator.Relocate(fakeKeychain, srcImage, dst)
+				require.NoError(t, err)
+
+				require.Equal(t, 2, uploads)
+				require.Equal(t, fmt.Sprintf("\tUploading '%s'\n", relocatedRef), output.String())
+			})
 		})
 
 		it("should error on invalid destination", func() {
 			srcImage, err := random.Image(int64(100), int64(5))
 			require.NoError(t, err)
 
-			relocator := registry.NewDefaultRelocator(ioutil.Discard, registry.DefaultTLSConfig())
+			relocator := registry.NewDefaultRelocator(ioutil.Discard, registry.DefaultTLSConfig(), registry.DefaultRetryConfig(), false)
 			_, err = relocator.Relocate(fakeKeychain, srcImage, "notuser/notimage:tag")
 			require.Error(t, err)
 		})
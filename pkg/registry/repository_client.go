@@ -0,0 +1,167 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"context"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// RepositoryClient lists, writes, and deletes the images in registry repositories.
+type RepositoryClient interface {
+	Catalog(keychain authn.Keychain, registry string) ([]string, error)
+	ListTags(keychain authn.Keychain, repository string) ([]string, error)
+	Digest(keychain authn.Keychain, ref string) (string, error)
+	Write(keychain authn.Keychain, image v1.Image, ref string) (string, error)
+	Delete(keychain authn.Keychain, ref string) error
+}
+
+type DefaultRepositoryClient struct {
+	tlsCfg   TLSConfig
+	retryCfg RetryConfig
+}
+
+func NewDefaultRepositoryClient(tlsCfg TLSConfig, retryCfg RetryConfig) DefaultRepositoryClient {
+	return DefaultRepositoryClient{tlsCfg: tlsCfg, retryCfg: retryCfg}
+}
+
+// Catalog returns the repositories of a registry. Many registries do not
+// support listing their repositories and return an error.
+func (d DefaultRepositoryClient) Catalog(keychain authn.Keychain, registry string) ([]string, error) {
+	reg, err := name.NewRegistry(registry, name.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := d.options(keychain)
+	if err != nil {
+		return nil, err
+	}
+
+	var repositories []string
+	err = d.retryCfg.retry(d.retryCfg.Writer, registry, func() error {
+		repositories, err = remote.Catalog(context.Background(), reg, options...)
+		return err
+	})
+	if err != nil {
+		return nil, newImageAccessError(registry, err)
+	}
+
+	for i, repository := range repositories {
+		repositories[i] = reg.RegistryStr() + "/" + repository
+	}
+	return repositories, nil
+}
+
+func (d DefaultRepositoryClient) ListTags(keychain authn.Keychain, repository string) ([]string, error) {
+	repo, err := name.NewRepository(repository, name.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := d.options(keychain)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = d.retryCfg.retry(d.retryCfg.Writer, repository, func() error {
+		tags, err = remote.List(repo, options...)
+		return err
+	})
+	if err != nil {
+		return nil, newImageAccessError(repository, err)
+	}
+	return tags, nil
+}
+
+func (d DefaultRepositoryClient) Digest(keychain authn.Keychain, ref string) (string, error) {
+	imageRef, err := name.ParseReference(ref, name.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+
+	options, err := d.options(keychain)
+	if err != nil {
+		return "", err
+	}
+
+	var digest string
+	err = d.retryCfg.retry(d.retryCfg.Writer, ref, func() error {
+		desc, err := remote.Head(imageRef, options...)
+		if err != nil {
+			return err
+		}
+		digest = desc.Digest.String()
+		return nil
+	})
+	if err != nil {
+		return "", newImageAccessError(ref, err)
+	}
+	return digest, nil
+}
+
+// Write writes image to ref and returns the reference to its digest. Unlike a
+// Relocator, Write keeps the tag of ref instead of adding a timestamp tag.
+func (d DefaultRepositoryClient) Write(keychain authn.Keychain, image v1.Image, ref string) (string, error) {
+	imageRef, err := name.ParseReference(ref, name.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+
+	digest, err := image.Digest()
+	if err != nil {
+		return "", err
+	}
+
+	options, err := d.options(keychain)
+	if err != nil {
+		return "", err
+	}
+
+	err = d.retryCfg.retry(d.retryCfg.Writer, ref, func() error {
+		return remote.Write(imageRef, image, options...)
+	})
+	if err != nil {
+		return "", newImageAccessError(ref, err)
+	}
+	return imageRef.Context().Digest(digest.String()).String(), nil
+}
+
+// Delete removes the manifest ref points to, along with every tag of it.
+func (d DefaultRepositoryClient) Delete(keychain authn.Keychain, ref string) error {
+	imageRef, err := name.ParseReference(ref, name.WeakValidation)
+	if err != nil {
+		return err
+	}
+
+	options, err := d.options(keychain)
+	if err != nil {
+		return err
+	}
+
+	err = d.retryCfg.retry(d.retryCfg.Writer, ref, func() error {
+		return remote.Delete(imageRef, options...)
+	})
+	if err != nil {
+		return newImageAccessError(ref, err)
+	}
+	return nil
+}
+
+func (d DefaultRepositoryClient) options(keychain authn.Keychain) ([]remote.Option, error) {
+	transport, err := d.tlsCfg.Transport()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]remote.Option{
+		remote.WithAuthFromKeychain(keychain),
+		remote.WithTransport(transport),
+	}, d.retryCfg.remoteOptions()...), nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultRetryAttempts = 5
+	DefaultRetryMaxDelay = 30 * time.Second
+
+	retryBaseDelay = 500 * time.Millisecond
+)
+
+// RetryConfig is the policy for retrying registry operations that fail with a
+// retryable error. Delays grow exponentially from half a second up to MaxDelay
+// with random jitter. An Attempts value below two disables retries.
+type RetryConfig struct {
+	Attempts int
+	MaxDelay time.Duration
+
+	// Writer receives a line for every retry of a fetch. Relocations report
+	// retries to the relocator's own writer.
+	Writer io.Writer
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		Attempts: DefaultRetryAttempts,
+		MaxDelay: DefaultRetryMaxDelay,
+	}
+}
+
+// ErrorClass groups registry errors by how a command should react to them.
+type ErrorClass int
+
+const (
+	OtherError ErrorClass = iota
+	RetryableError
+	UnauthorizedError
+	NotFoundError
+)
+
+// ClassifyError tells apart errors worth retrying, such as 5xx and 429
+// responses and dropped connections, from auth failures and missing images.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return OtherError
+	}
+
+	var transportErr *transport.Error
+	if errors.As(err, &transportErr) {
+		return classifyTransportError(transportErr)
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) {
+		return RetryableError
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return RetryableError
+	}
+
+	return OtherError
+}
+
+func classifyTransportError(err *transport.Error) ErrorClass {
+	switch err.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return UnauthorizedError
+	case http.StatusNotFound:
+		return NotFoundError
+	case http.StatusTooManyRequests,
+		http.StatusRequestTimeout,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return RetryableError
+	}
+
+	for _, d := range err.Errors {
+		switch d.Code {
+		case transport.UnauthorizedErrorCode, transport.DeniedErrorCode:
+			return UnauthorizedError
+		case transport.ManifestUnknownErrorCode, transport.NameUnknownErrorCode, transport.BlobUnknownErrorCode:
+			return NotFoundError
+		case transport.TooManyRequestsErrorCode, transport.UnavailableErrorCode:
+			return RetryableError
+		}
+	}
+
+	return OtherError
+}
+
+// retry runs op until it succeeds, fails with an error that is not retryable,
+// or runs out of attempts. Every retry is reported to writer.
+func (r RetryConfig) retry(writer io.Writer, ref string, op func() error) error {
+	attempts := r.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || ClassifyError(err) != RetryableError {
+			return err
+		}
+
+		if attempt >= attempts {
+			if attempts > 1 {
+				return errors.Wrapf(err, "giving up on '%s' after %d attempts", ref, attempts)
+			}
+			return err
+		}
+
+		delay := r.delay(attempt)
+		if writer != nil {
+			if _, werr := fmt.Fprintf(writer, "\tRetrying '%s' in %s (attempt %d of %d): %s\n", ref, delay.Round(time.Millisecond), attempt+1, attempts, err); werr != nil {
+				return werr
+			}
+		}
+		time.Sleep(delay)
+	}
+}
+
+// delay doubles the base delay for every attempt, caps it at MaxDelay, and
+// picks a random delay between half and all of it.
+func (r RetryConfig) delay(attempt int) time.Duration {
+	maxDelay := r.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultRetryMaxDelay
+	}
+
+	d := retryBaseDelay
+	for i := 1; i < attempt && d < maxDelay; i++ {
+		d *= 2
+	}
+	if d > maxDelay {
+		d = maxDelay
+	}
+
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// remoteOptions turns off the retries built into go-containerregistry when this
+// policy retries, so that the configured attempts are the only ones made.
+func (r RetryConfig) remoteOptions() []remote.Option {
+	if r.Attempts < 2 {
+		return nil
+	}
+	return []remote.Option{remote.WithRetryBackoff(remote.Backoff{Steps: 1})}
+}
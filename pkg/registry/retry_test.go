@@ -0,0 +1,198 @@
+// Copyright 2020-Present VMware, Inc.
+// SPDX-License-Identifier: Apache-2.0
+
+package registry_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrregistry "github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pivotal/kpack/pkg/registry/registryfakes"
+	"github.com/pkg/errors"
+	"github.com/sclevine/spec"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
+)
+
+func TestRetry(t *testing.T) {
+	spec.Run(t, "Test Registry Retry", testRetry)
+}
+
+// flakyHandler fails the first failures manifest requests with status before
+// handing requests to the registry.
+type flakyHandler struct {
+	next     http.Handler
+	status   int
+	failures int
+
+	mu       sync.Mutex
+	requests int
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.URL.Path, "/manifests/") {
+		h.mu.Lock()
+		h.requests++
+		fail := h.requests <= h.failures
+		h.mu.Unlock()
+
+		if fail {
+			w.WriteHeader(h.status)
+			return
+		}
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func (h *flakyHandler) manifestRequests() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests
+}
+
+func testRetry(t *testing.T, when spec.G, it spec.S) {
+	var (
+		fakeKeychain = &registryfakes.FakeKeychain{}
+		handler      *flakyHandler
+		host         string
+		retryOutput  *bytes.Buffer
+		tlsCfg       registry.TLSConfig
+		retryCfg     registry.RetryConfig
+	)
+
+	it.Before(func() {
+		handler = &flakyHandler{next: ggcrregistry.New(ggcrregistry.Logger(log.New(ioutil.Discard, "", 0)))}
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+
+		uri, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		host = uri.Host
+
+		retryOutput = &bytes.Buffer{}
+		tlsCfg = registry.DefaultTLSConfig()
+		retryCfg = registry.RetryConfig{Attempts: 3, MaxDelay: time.Millisecond, Writer: retryOutput}
+	})
+
+	pushImage := func() string {
+		image, err := random.Image(100, 1)
+		require.NoError(t, err)
+
+		ref, err := name.ParseReference(fmt.Sprintf("%s/src/image:latest", host))
+		require.NoError(t, err)
+		require.NoError(t, remote.Write(ref, image))
+
+		handler.mu.Lock()
+		handler.requests = 0
+		handler.mu.Unlock()
+		return ref.String()
+	}
+
+	when("fetching", func() {
+		it("retries transient failures and reports each retry", func() {
+			src := pushImage()
+			handler.status = http.StatusServiceUnavailable
+			handler.failures = 2
+
+			_, err := registry.NewDefaultFetcher(tlsCfg, retryCfg).Fetch(fakeKeychain, src)
+			require.NoError(t, err)
+
+			require.Equal(t, 3, handler.manifestRequests())
+			require.Equal(t, 2, strings.Count(retryOutput.String(), fmt.Sprintf("\tRetrying '%s' in ", src)))
+			require.Contains(t, retryOutput.String(), "(attempt 3 of 3)")
+		})
+
+		it("gives up after the configured attempts", func() {
+			src := pushImage()
+			handler.status = http.StatusTooManyRequests
+			handler.failures = 10
+
+			_, err := registry.NewDefaultFetcher(tlsCfg, retryCfg).Fetch(fakeKeychain, src)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), fmt.Sprintf("giving up on '%s' after 3 attempts", src))
+			require.Equal(t, 3, handler.manifestRequests())
+		})
+
+		it("does not retry auth failures", func() {
+			src := pushImage()
+			handler.status = http.StatusForbidden
+			handler.failures = 10
+
+			_, err := registry.NewDefaultFetcher(tlsCfg, retryCfg).Fetch(fakeKeychain, src)
+			require.EqualError(t, err, fmt.Sprintf("invalid credentials, ensure registry credentials for '%s' are available", src))
+			require.Equal(t, 1, handler.manifestRequests())
+			require.Empty(t, retryOutput.String())
+		})
+
+		it("reports missing images as not found", func() {
+			src := fmt.Sprintf("%s/src/missing:latest", host)
+
+			_, err := registry.NewDefaultFetcher(tlsCfg, retryCfg).Fetch(fakeKeychain, src)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), fmt.Sprintf("'%s' not found", src))
+			require.Empty(t, retryOutput.String())
+		})
+	})
+
+	when("relocating", func() {
+		it("retries transient failures and reports them to the relocator output", func() {
+			image, err := random.Image(100, 1)
+			require.NoError(t, err)
+
+			handler.status = http.StatusBadGateway
+			handler.failures = 2
+
+			out := &bytes.Buffer{}
+			relocator := registry.NewDefaultRelocator(out, tlsCfg, retryCfg, true)
+			ref, err := relocator.Relocate(fakeKeychain, image, fmt.Sprintf("%s/dest/repo", host))
+			require.NoError(t, err)
+
+			require.Contains(t, out.String(), fmt.Sprintf("\tRetrying '%s' in ", ref))
+			require.Empty(t, retryOutput.String())
+
+			dst, err := name.ParseReference(ref)
+			require.NoError(t, err)
+			_, err = remote.Image(dst)
+			require.NoError(t, err)
+		})
+	})
+
+	when("classifying errors", func() {
+		it("tells apart retryable, auth, and not found errors", func() {
+			for _, tc := range []struct {
+				err   error
+				class registry.ErrorClass
+			}{
+				{&transport.Error{StatusCode: http.StatusServiceUnavailable}, registry.RetryableError},
+				{&transport.Error{StatusCode: http.StatusTooManyRequests}, registry.RetryableError},
+				{&transport.Error{StatusCode: http.StatusUnauthorized}, registry.UnauthorizedError},
+				{&transport.Error{StatusCode: http.StatusForbidden}, registry.UnauthorizedError},
+				{&transport.Error{StatusCode: http.StatusNotFound}, registry.NotFoundError},
+				{&transport.Error{StatusCode: http.StatusBadRequest, Errors: []transport.Diagnostic{{Code: transport.ManifestUnknownErrorCode}}}, registry.NotFoundError},
+				{&transport.Error{StatusCode: http.StatusBadRequest, Errors: []transport.Diagnostic{{Code: transport.DeniedErrorCode}}}, registry.UnauthorizedError},
+				{errors.Wrap(syscall.ECONNRESET, "read"), registry.RetryableError},
+				{io.ErrUnexpectedEOF, registry.RetryableError},
+				{errors.New("some error"), registry.OtherError},
+			} {
+				require.Equal(t, tc.class, registry.ClassifyError(tc.err), tc.err.Error())
+			}
+		})
+	})
+}
@@ -1,5 +1,5 @@
 // Copyright 2020-Present VMware, Inc.
-// SPDX-License-Identifier: Apache-2.0	// SPDX-License-Identifier: Apache-2.0
+// SPDX-License-Identifier: Apache-2.0
 
 package registry
 
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh/terminal"
@@ -17,28 +18,37 @@ const framerate = time.Millisecond * 150
 
 var spinners = []string{"|", "/", "-", "\\"}
 
+// uploadSpinner renders a single progress line below the upload output and
+// clears it when stopped, leaving only complete lines behind. The status is
+// re-read on every frame so one spinner can report on several concurrent
+// uploads whose own output is buffered elsewhere.
 type uploadSpinner struct {
-	size     string
+	status   func() string
 	stopChan chan struct{}
 	doneChan chan struct{}
+	once     sync.Once
 	Output   io.Writer
 	NotTty   bool
 }
 
-func newUploadSpinner(writer io.Writer, size int64) *uploadSpinner {
-	isTerminal := terminal.IsTerminal(int(os.Stdout.Fd())) || terminal.IsTerminal(int(os.Stderr.Fd()))
+func newUploadSpinner(writer io.Writer, status func() string) *uploadSpinner {
 	sp := &uploadSpinner{
-		size:     readableSize(size),
+		status:   status,
 		stopChan: make(chan struct{}),
 		doneChan: make(chan struct{}),
 		Output:   writer,
-		NotTty:   !isTerminal,
+		NotTty:   !isTerminal(writer),
 	}
 	return sp
 }
 
+func sizeStatus(size int64) func() string {
+	readable := ReadableSize(size)
+	return func() string { return readable }
+}
+
 func (s *uploadSpinner) Stop() {
-	close(s.stopChan)
+	s.once.Do(func() { close(s.stopChan) })
 	<-s.doneChan
 }
 
@@ -50,7 +60,6 @@ func (s *uploadSpinner) Write() {
 	}
 
 	index := 0
-	fmt.Fprint(s.Output, "\n")
 	for {
 		select {
 		case <-s.stopChan:
@@ -59,7 +68,7 @@ func (s *uploadSpinner) Write() {
 		case <-time.After(framerate):
 			s.clear()
 
-			fmt.Fprintf(s.Output, "\t %s %s", spinners[index], s.size)
+			fmt.Fprintf(s.Output, "\t %s %s", spinners[index], s.status())
 
 			index++
 			if index == len(spinners) {
@@ -75,7 +84,15 @@ func (s *uploadSpinner) clear() {
 	fmt.Fprint(s.Output, "\033[1A")
 }
 
-func readableSize(length int64) string {
+// isTerminal only reports true for writers that are themselves a terminal, so
+// output captured in a buffer never receives spinner escape sequences.
+func isTerminal(writer io.Writer) bool {
+	f, ok := writer.(*os.File)
+	return ok && terminal.IsTerminal(int(f.Fd()))
+}
+
+// ReadableSize formats a size in bytes with a decimal unit, such as 1.50 MB.
+func ReadableSize(length int64) string {
 	const (
 		gb = 1000000000
 		mb = 1000000
@@ -10,13 +10,35 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"path/filepath"
 	"runtime"
 	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
 )
 
 type TLSConfig struct {
 	CaCertPath  string
 	VerifyCerts bool
+
+	// ConfigPath is a file with TLS settings for individual registry hosts.
+	// Requests to hosts it does not list use CaCertPath and VerifyCerts.
+	ConfigPath string
+}
+
+// RegistryTLSConfig holds the TLS settings for a single registry host.
+// VerifyCerts defaults to the command's --registry-verify-certs value.
+type RegistryTLSConfig struct {
+	CaCertPath     string `json:"caCertPath,omitempty"`
+	ClientCertPath string `json:"clientCertPath,omitempty"`
+	ClientKeyPath  string `json:"clientKeyPath,omitempty"`
+	VerifyCerts    *bool  `json:"verifyCerts,omitempty"`
+}
+
+type tlsConfigFile struct {
+	Registries map[string]RegistryTLSConfig `json:"registries"`
 }
 
 func DefaultTLSConfig() TLSConfig {
@@ -32,18 +54,120 @@ func NewTLSConfig(caCertPath string, verifyCerts bool) TLSConfig {
 	}
 }
 
-func (t *TLSConfig) Transport() (*http.Transport, error) {
+// Transport returns the round tripper for registry requests. When ConfigPath
+// is set, each request uses the settings of the host it is sent to.
+func (t *TLSConfig) Transport() (http.RoundTripper, error) {
+	defaultTransport, err := newTransport(t.CaCertPath, "", "", t.VerifyCerts)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.ConfigPath == "" {
+		return defaultTransport, nil
+	}
+
+	registries, err := readTLSConfigFile(t.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := map[string]http.RoundTripper{}
+	for host, cfg := range registries {
+		verifyCerts := t.VerifyCerts
+		if cfg.VerifyCerts != nil {
+			verifyCerts = *cfg.VerifyCerts
+		}
+
+		hostTransport, err := newTransport(cfg.CaCertPath, cfg.ClientCertPath, cfg.ClientKeyPath, verifyCerts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "registry '%s' in '%s'", host, t.ConfigPath)
+		}
+		hosts[host] = hostTransport
+	}
+
+	return &perHostTransport{hosts: hosts, fallback: defaultTransport}, nil
+}
+
+// perHostTransport sends every request with the transport configured for its
+// host, falling back to the default transport for unlisted hosts.
+type perHostTransport struct {
+	hosts    map[string]http.RoundTripper
+	fallback http.RoundTripper
+}
+
+func (p *perHostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t, ok := p.hosts[req.URL.Host]; ok {
+		return t.RoundTrip(req)
+	}
+	if t, ok := p.hosts[req.URL.Hostname()]; ok {
+		return t.RoundTrip(req)
+	}
+	return p.fallback.RoundTrip(req)
+}
+
+// readTLSConfigFile reads the registries of a TLS config file, keyed by the
+// host requests to them are sent to. Relative paths are resolved against the
+// directory of the file.
+func readTLSConfigFile(path string) (map[string]RegistryTLSConfig, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading registry TLS config")
+	}
+
+	var file tlsConfigFile
+	if err := yaml.UnmarshalStrict(buf, &file); err != nil {
+		return nil, errors.Wrapf(err, "parsing registry TLS config '%s'", path)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	registries := map[string]RegistryTLSConfig{}
+	for host, cfg := range file.Registries {
+		reg, err := name.NewRegistry(host, name.WeakValidation)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing registry TLS config '%s'", path)
+		}
+
+		if (cfg.ClientCertPath == "") != (cfg.ClientKeyPath == "") {
+			return nil, errors.Errorf("registry '%s' in '%s' must set both clientCertPath and clientKeyPath", host, path)
+		}
+
+		cfg.CaCertPath = resolve(cfg.CaCertPath)
+		cfg.ClientCertPath = resolve(cfg.ClientCertPath)
+		cfg.ClientKeyPath = resolve(cfg.ClientKeyPath)
+		registries[reg.RegistryStr()] = cfg
+	}
+
+	return registries, nil
+}
+
+func newTransport(caCertPath, clientCertPath, clientKeyPath string, verifyCerts bool) (*http.Transport, error) {
 	pool, err := x509.SystemCertPool()
 	if err != nil {
 		pool = x509.NewCertPool()
 	}
 
-	if t.CaCertPath != "" {
-		if cert, err := ioutil.ReadFile(t.CaCertPath); err != nil {
-			return nil, fmt.Errorf("reading CA certificate from '%s': %s", t.CaCertPath, err)
+	if caCertPath != "" {
+		if cert, err := ioutil.ReadFile(caCertPath); err != nil {
+			return nil, fmt.Errorf("reading CA certificate from '%s': %s", caCertPath, err)
 		} else if ok := pool.AppendCertsFromPEM(cert); !ok {
-			return nil, fmt.Errorf("adding CA certificate from '%s': failed", t.CaCertPath)
+			return nil, fmt.Errorf("adding CA certificate from '%s': failed", caCertPath)
+		}
+	}
+
+	var certificates []tls.Certificate
+	if clientCertPath != "" {
+		cert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading client certificate from '%s': %s", clientCertPath, err)
 		}
+		certificates = append(certificates, cert)
 	}
 
 	transport := &http.Transport{
@@ -60,13 +184,14 @@ func (t *TLSConfig) Transport() (*http.Transport, error) {
 		ExpectContinueTimeout: 1 * time.Second,
 		TLSClientConfig: &tls.Config{
 			RootCAs:            pool,
-			InsecureSkipVerify: t.VerifyCerts == false,
+			Certificates:       certificates,
+			InsecureSkipVerify: verifyCerts == false,
 		},
 	}
 
 	// Do not set RootCAs when custom CA is not set on windows
 	// https://github.com/golang/go/issues/16736
-	if runtime.GOOS == "windows" && t.CaCertPath == "" {
+	if runtime.GOOS == "windows" && caCertPath == "" {
 		transport.TLSClientConfig.RootCAs = nil
 	}
 
@@ -4,11 +4,23 @@
 package registry_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
+	"fmt"
 	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/sclevine/spec"
 	"github.com/stretchr/testify/require"
@@ -35,8 +47,10 @@ func testTLSConfig(t *testing.T, when spec.G, it spec.S) {
 
 		cfg := registry.NewTLSConfig(certPath, false)
 
-		transport, err := cfg.Transport()
+		roundTripper, err := cfg.Transport()
 		require.NoError(t, err)
+		transport, ok := roundTripper.(*http.Transport)
+		require.True(t, ok)
 		subjects := transport.TLSClientConfig.RootCAs.Subjects()
 
 		found := false
@@ -52,8 +66,174 @@ func testTLSConfig(t *testing.T, when spec.G, it spec.S) {
 	it("sets skip verify to false when verify certs is true", func() {
 		cfg := registry.NewTLSConfig("", true)
 
-		transport, err := cfg.Transport()
+		roundTripper, err := cfg.Transport()
 		require.NoError(t, err)
+		transport, ok := roundTripper.(*http.Transport)
+		require.True(t, ok)
 		require.False(t, transport.TLSClientConfig.InsecureSkipVerify)
 	})
+
+	when("a registry TLS config file is provided", func() {
+		var (
+			tempDir string
+			server  *httptest.Server
+			host    string
+		)
+
+		it.Before(func() {
+			tempDir = t.TempDir()
+
+			ca, caKey := newCertificate(t, nil, nil, &x509.Certificate{
+				Subject:               pkix.Name{CommonName: "test-ca"},
+				IsCA:                  true,
+				KeyUsage:              x509.KeyUsageCertSign,
+				BasicConstraintsValid: true,
+			})
+			writeCertificate(t, filepath.Join(tempDir, "ca.crt"), ca, nil)
+
+			serverCert, serverKey := newCertificate(t, ca, caKey, &x509.Certificate{
+				Subject:     pkix.Name{CommonName: "127.0.0.1"},
+				IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			})
+
+			clientCert, clientKey := newCertificate(t, ca, caKey, &x509.Certificate{
+				Subject:     pkix.Name{CommonName: "kp"},
+				ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+			})
+			writeCertificate(t, filepath.Join(tempDir, "client.crt"), clientCert, nil)
+			writeCertificate(t, filepath.Join(tempDir, "client.key"), nil, clientKey)
+
+			caPool := x509.NewCertPool()
+			caPool.AddCert(ca)
+
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			server.TLS = &tls.Config{
+				Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				ClientCAs:    caPool,
+			}
+			server.StartTLS()
+			t.Cleanup(server.Close)
+
+			uri, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			host = uri.Host
+		})
+
+		get := func(cfg registry.TLSConfig) error {
+			roundTripper, err := cfg.Transport()
+			if err != nil {
+				return err
+			}
+
+			resp, err := (&http.Client{Transport: roundTripper}).Get(server.URL + "/v2/")
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			return nil
+		}
+
+		writeConfig := func(content string) string {
+			path := filepath.Join(tempDir, "tls.yaml")
+			require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+			return path
+		}
+
+		it("uses the CA and client certificate configured for the host", func() {
+			cfg := registry.DefaultTLSConfig()
+			cfg.ConfigPath = writeConfig(fmt.Sprintf(`registries:
+  %s:
+    caCertPath: ca.crt
+    clientCertPath: client.crt
+    clientKeyPath: client.key
+`, host))
+
+			require.NoError(t, get(cfg))
+		})
+
+		it("uses the default settings for hosts that are not listed", func() {
+			cfg := registry.DefaultTLSConfig()
+			cfg.ConfigPath = writeConfig(`registries:
+  some-other-registry.io:
+    verifyCerts: false
+`)
+
+			err := get(cfg)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "certificate")
+		})
+
+		it("does not send a client certificate when none is configured", func() {
+			cfg := registry.DefaultTLSConfig()
+			cfg.ConfigPath = writeConfig(fmt.Sprintf(`registries:
+  %s:
+    caCertPath: ca.crt
+`, host))
+
+			require.Error(t, get(cfg))
+		})
+
+		it("errors when a client certificate has no key", func() {
+			cfg := registry.DefaultTLSConfig()
+			cfg.ConfigPath = writeConfig(fmt.Sprintf(`registries:
+  %s:
+    clientCertPath: client.crt
+`, host))
+
+			_, err := cfg.Transport()
+			require.EqualError(t, err, fmt.Sprintf("registry '%s' in '%s' must set both clientCertPath and clientKeyPath", host, cfg.ConfigPath))
+		})
+
+		it("errors on unknown fields", func() {
+			cfg := registry.DefaultTLSConfig()
+			cfg.ConfigPath = writeConfig(fmt.Sprintf(`registries:
+  %s:
+    caCert: ca.crt
+`, host))
+
+			_, err := cfg.Transport()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "parsing registry TLS config")
+		})
+	})
+}
+
+func newCertificate(t *testing.T, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, template *x509.Certificate) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writeCertificate(t *testing.T, path string, cert *x509.Certificate, key *ecdsa.PrivateKey) {
+	var block *pem.Block
+	if cert != nil {
+		block = &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}
+	} else {
+		der, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	}
+	require.NoError(t, ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600))
 }
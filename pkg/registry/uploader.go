@@ -4,11 +4,13 @@
 package registry
 
 import (
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
-	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
 	"github.com/pkg/errors"
 
@@ -19,47 +21,96 @@ type SourceUploader interface {
 	Upload(keychain authn.Keychain, dstImgRefStr, srcPath string) (string, error)
 }
 
+// DefaultSourceUploader uploads local source code as a single layer image.
+// The source can be a directory, a zip, jar or war, or a tar optionally
+// compressed with gzip or zstd. Files ignored by the .kpignore of the source, or by its .gitignore when
+// UseGitignore is set and there is no .kpignore, are left out. When
+// FileListWriter is set, the files that are included are listed to it.
+//
+// Source images are built reproducibly, so uploading an unchanged tree yields
+// the digest that is already in the registry and the upload is skipped.
 type DefaultSourceUploader struct {
-	Relocator Relocator
+	Relocator      Relocator
+	UseGitignore   bool
+	FileListWriter io.Writer
 }
 
 func (d DefaultSourceUploader) Upload(keychain authn.Keychain, dstImgRefStr, srcPath string) (string, error) {
-	srcTarPath, err := readPathToTar(srcPath)
+	if d.FileListWriter != nil {
+		if err := d.listSourceFiles(srcPath); err != nil {
+			return "", err
+		}
+	}
+
+	srcTarPath, err := readPathToTar(srcPath, d.UseGitignore)
 	if err != nil {
 		return "", err
 	}
 
 	defer os.Remove(srcTarPath)
 
-	image, err := random.Image(0, 0)
+	layer, err := tarball.LayerFromFile(srcTarPath)
 	if err != nil {
 		return "", err
 	}
 
-	layer, err := tarball.LayerFromFile(srcTarPath)
+	image, err := mutate.AppendLayers(empty.Image, layer)
 	if err != nil {
 		return "", err
 	}
 
-	image, err = mutate.AppendLayers(image, layer)
+	return d.Relocator.Relocate(keychain, image, dstImgRefStr)
+}
+
+func (d DefaultSourceUploader) listSourceFiles(path string) error {
+	var (
+		files []string
+		err   error
+	)
+	switch {
+	case isZip(path):
+		files, err = archive.ZipSourceFiles(path, d.UseGitignore)
+	case isTar(path):
+		files, err = archive.TarSourceFiles(path, d.UseGitignore)
+	default:
+		files, err = archive.SourceFiles(path, d.UseGitignore)
+	}
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	return d.Relocator.Relocate(keychain, image, dstImgRefStr)
+	for _, file := range files {
+		if _, err := fmt.Fprintf(d.FileListWriter, "\tIncluding '%s'\n", file); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func readPathToTar(path string) (string, error) {
+func readPathToTar(path string, useGitignore bool) (string, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
 		return "", err
 	}
 
-	if !fi.IsDir() && archive.IsZip(path) {
-		return archive.ZipToTar(path)
-	} else if !fi.IsDir() {
-		return "", errors.New("local path must be a directory or zip")
+	switch {
+	case fi.IsDir():
+		return archive.CreateSourceTar(path, useGitignore)
+	case isZip(path):
+		return archive.ZipToTar(path, useGitignore)
+	case isTar(path):
+		return archive.NormalizeTar(path, useGitignore)
+	default:
+		return "", errors.New("local path must be a directory, zip, jar, war or tar")
 	}
+}
 
-	return archive.CreateTar(path)
+func isZip(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir() && (archive.IsZip(path) || archive.IsJavaArchive(path))
+}
+
+func isTar(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && !fi.IsDir() && archive.IsTar(path)
 }
@@ -4,6 +4,12 @@
 package registry_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/pivotal/kpack/pkg/registry/registryfakes"
@@ -20,8 +26,8 @@ func TestUploader(t *testing.T) {
 
 func testUploader(t *testing.T, when spec.G, it spec.S) {
 	const (
-		testdataDigest = "sha256:f261a0e333140b47e51e1cf5a045e704a47842d56ee33962770001582ac6656a"
-		testZipDigest  = "sha256:0a35946b7420d1f6bf0d1397c86cef1f00b08a8575d6045f20914a6845c1376c"
+		testdataDigest = "sha256:2ccc6a29d97f741953ecc081169ed1ffafcd71a8718e9ac6e8ad1595cedf6f8d"
+		testZipDigest  = "sha256:121b21dbf4640e003e430c30b6618e7817d2a9a6ebe50b54ece756e6a6c7f542"
 	)
 
 	when("Upload", func() {
@@ -56,9 +62,76 @@ func testUploader(t *testing.T, when spec.G, it spec.S) {
 			require.Equal(t, testZipDigest, digest.String())
 		})
 
+		it("produces the same image for identical trees", func() {
+			var digests []string
+			for _, perm := range []os.FileMode{0600, 0664} {
+				dir, err := ioutil.TempDir("", "uploader-test")
+				require.NoError(t, err)
+				defer os.RemoveAll(dir)
+
+				require.NoError(t, os.Mkdir(filepath.Join(dir, "src"), perm|0100))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "src", "main.go"), []byte("package main"), perm))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh"), perm|0100))
+
+				_, err = uploader.Upload(&registryfakes.FakeKeychain{}, "myregistry.com/blah", dir)
+				require.NoError(t, err)
+
+				_, image, _ := fakeRelocator.RelocateCall(len(digests))
+				digest, err := image.Digest()
+				require.NoError(t, err)
+				digests = append(digests, digest.String())
+			}
+
+			require.Equal(t, digests[0], digests[1])
+		})
+
+		it("lists the files it includes", func() {
+			dir, err := ioutil.TempDir("", "uploader-test")
+			require.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, ".kpignore"), []byte(".kpignore\n*.log\n"), 0644))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "app.log"), []byte("log"), 0644))
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644))
+
+			out := &bytes.Buffer{}
+			uploader.FileListWriter = out
+			_, err = uploader.Upload(&registryfakes.FakeKeychain{}, "myregistry.com/blah", dir)
+			require.NoError(t, err)
+			require.Equal(t, "\tIncluding 'main.go'\n", out.String())
+			require.Equal(t, 1, fakeRelocator.CallCount())
+		})
+
+		it("relocates local tar.gz to registry", func() {
+			file, err := ioutil.TempFile("", "sample.tgz")
+			require.NoError(t, err)
+			defer os.Remove(file.Name())
+
+			gw := gzip.NewWriter(file)
+			tw := tar.NewWriter(gw)
+			contents, err := ioutil.ReadFile("testdata/sample/app")
+			require.NoError(t, err)
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: "app", Typeflag: tar.TypeReg, Mode: 0664, Size: int64(len(contents))}))
+			_, err = tw.Write(contents)
+			require.NoError(t, err)
+			require.NoError(t, tw.Close())
+			require.NoError(t, gw.Close())
+			require.NoError(t, file.Close())
+
+			_, err = uploader.Upload(&registryfakes.FakeKeychain{}, "myregistry.com/blah", file.Name())
+			require.NoError(t, err)
+
+			require.Equal(t, 1, fakeRelocator.CallCount())
+
+			_, image, _ := fakeRelocator.RelocateCall(0)
+			layers, err := image.Layers()
+			require.NoError(t, err)
+			require.Len(t, layers, 1)
+		})
+
 		it("returns err on path to invalid zip", func() {
 			_, err := uploader.Upload(&registryfakes.FakeKeychain{}, "myregistry.com/blah", "testdata/sample/app")
-			require.EqualError(t, err, "local path must be a directory or zip")
+			require.EqualError(t, err, "local path must be a directory, zip, jar, war or tar")
 
 			require.Equal(t, 0, fakeRelocator.CallCount())
 		})
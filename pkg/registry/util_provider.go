@@ -6,25 +6,39 @@ package registry
 import "io"
 
 type UtilProvider interface {
-	Relocator(writer io.Writer, tlsCfg TLSConfig, changeState bool) Relocator
-	SourceUploader(writer io.Writer, tlsCfg TLSConfig, changeState bool) SourceUploader
-	Fetcher(config TLSConfig) Fetcher
+	Relocator(writer io.Writer, tlsCfg TLSConfig, retryCfg RetryConfig, changeState, alwaysTag bool) Relocator
+	SourceUploader(writer io.Writer, tlsCfg TLSConfig, retryCfg RetryConfig, changeState, useGitignore bool) SourceUploader
+	Fetcher(config TLSConfig, retryCfg RetryConfig) Fetcher
+	RepositoryClient(config TLSConfig, retryCfg RetryConfig) RepositoryClient
 }
 
 type DefaultUtilProvider struct{}
 
-func (d DefaultUtilProvider) Relocator(writer io.Writer, tlsCfg TLSConfig, changeState bool) Relocator {
+func (d DefaultUtilProvider) Relocator(writer io.Writer, tlsCfg TLSConfig, retryCfg RetryConfig, changeState, alwaysTag bool) Relocator {
 	if changeState {
-		return NewDefaultRelocator(writer, tlsCfg)
+		return NewDefaultRelocator(writer, tlsCfg, retryCfg, alwaysTag)
 	} else {
 		return NewDiscardRelocator(writer)
 	}
 }
 
-func (d DefaultUtilProvider) SourceUploader(writer io.Writer, tlsCfg TLSConfig, changeState bool) SourceUploader {
-	return &DefaultSourceUploader{Relocator: d.Relocator(writer, tlsCfg, changeState)}
+// SourceUploader returns an uploader that lists the files it would upload
+// instead of uploading them when changeState is not set.
+func (d DefaultUtilProvider) SourceUploader(writer io.Writer, tlsCfg TLSConfig, retryCfg RetryConfig, changeState, useGitignore bool) SourceUploader {
+	uploader := &DefaultSourceUploader{
+		Relocator:    d.Relocator(writer, tlsCfg, retryCfg, changeState, false),
+		UseGitignore: useGitignore,
+	}
+	if !changeState {
+		uploader.FileListWriter = writer
+	}
+	return uploader
+}
+
+func (d DefaultUtilProvider) Fetcher(config TLSConfig, retryCfg RetryConfig) Fetcher {
+	return NewDefaultFetcher(config, retryCfg)
 }
 
-func (d DefaultUtilProvider) Fetcher(config TLSConfig) Fetcher {
-	return NewDefaultFetcher(config)
+func (d DefaultUtilProvider) RepositoryClient(config TLSConfig, retryCfg RetryConfig) RepositoryClient {
+	return NewDefaultRepositoryClient(config, retryCfg)
 }
@@ -15,9 +15,11 @@ import (
 	clusterstackcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/clusterstack"
 	clusterstorecmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/clusterstore"
 	configcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/config"
+	exportcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/export"
 	imgcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/image"
 	importcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/import"
 	"github.com/vmware-tanzu/kpack-cli/pkg/commands/lifecycle"
+	registrycmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/registry"
 	secretcmds "github.com/vmware-tanzu/kpack-cli/pkg/commands/secret"
 	importpkg "github.com/vmware-tanzu/kpack-cli/pkg/import"
 	"github.com/vmware-tanzu/kpack-cli/pkg/k8s"
@@ -52,7 +54,9 @@ Learn more about kpack @ https://github.com/pivotal/kpack`,
 		getStoreCommand(clientSetProvider),
 		getLifecycleCommand(clientSetProvider),
 		getImportCommand(clientSetProvider),
+		getExportCommand(clientSetProvider),
 		getConfigCommand(clientSetProvider),
+		getRegistryCommand(clientSetProvider),
 		getCompletionCommand(),
 	)
 
@@ -169,6 +173,7 @@ func getStackCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
 		clusterstackcmds.NewCreateCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
 		clusterstackcmds.NewPatchCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
 		clusterstackcmds.NewSaveCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
+		clusterstackcmds.NewRefreshCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
 		clusterstackcmds.NewListCommand(clientSetProvider),
 		clusterstackcmds.NewStatusCommand(clientSetProvider),
 		clusterstackcmds.NewDeleteCommand(clientSetProvider),
@@ -186,6 +191,7 @@ func getStoreCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
 		clusterstorecmds.NewCreateCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
 		clusterstorecmds.NewAddCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
 		clusterstorecmds.NewSaveCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
+		clusterstorecmds.NewRefreshCommand(clientSetProvider, registry.DefaultUtilProvider{}, commands.NewResourceWaiter),
 		clusterstorecmds.NewDeleteCommand(clientSetProvider, commands.NewConfirmationProvider()),
 		clusterstorecmds.NewStatusCommand(clientSetProvider),
 		clusterstorecmds.NewRemoveCommand(clientSetProvider, commands.NewResourceWaiter),
@@ -207,7 +213,7 @@ func getLifecycleCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command
 }
 
 func getImportCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
-	return importcmds.NewImportCommand(
+	importCmd := importcmds.NewImportCommand(
 		commands.Differ{},
 		clientSetProvider,
 		registry.DefaultUtilProvider{},
@@ -215,6 +221,16 @@ func getImportCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
 		commands.NewConfirmationProvider(),
 		commands.NewResourceWaiter,
 	)
+	importCmd.AddCommand(
+		importcmds.NewPushCommand(registry.DefaultUtilProvider{}),
+		importcmds.NewConvertCommand(registry.DefaultUtilProvider{}),
+		importcmds.NewValidateCommand(registry.DefaultUtilProvider{}),
+	)
+	return importCmd
+}
+
+func getExportCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
+	return exportcmds.NewExportCommand(clientSetProvider)
 }
 
 func getConfigCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
@@ -226,11 +242,23 @@ func getConfigCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
 	configRootCmd.AddCommand(
 		configcmds.NewDefaultRepositoryCommand(clientSetProvider),
 		configcmds.NewDefaultServiceAccountCommand(clientSetProvider),
+		configcmds.NewRepositoryTemplateCommand(clientSetProvider),
 	)
 
 	return configRootCmd
 }
 
+func getRegistryCommand(clientSetProvider k8s.ClientSetProvider) *cobra.Command {
+	registryRootCmd := &cobra.Command{
+		Use:   "registry",
+		Short: "Registry Commands",
+	}
+	registryRootCmd.AddCommand(
+		registrycmds.NewGCCommand(clientSetProvider, registry.DefaultUtilProvider{}),
+	)
+	return registryRootCmd
+}
+
 func getCompletionCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:   "completion [bash|zsh|fish|powershell]",
@@ -7,6 +7,8 @@ import (
 	"github.com/google/go-containerregistry/pkg/authn"
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/pkg/errors"
+
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 )
 
 const (
@@ -26,7 +28,7 @@ type Uploader struct {
 	Fetcher   Fetcher
 }
 
-func (u *Uploader) UploadStackImages(keychain authn.Keychain, buildImageTag, runImageTag, dest string) (string, string, error) {
+func (u *Uploader) UploadStackImages(keychain authn.Keychain, buildImageTag, runImageTag, buildDest, runDest string) (string, string, error) {
 	buildImage, err := u.Fetcher.Fetch(keychain, buildImageTag)
 	if err != nil {
 		return "", "", err
@@ -37,12 +39,12 @@ func (u *Uploader) UploadStackImages(keychain authn.Keychain, buildImageTag, run
 		return "", "", err
 	}
 
-	relocatedBuildImageRef, err := u.Relocator.Relocate(keychain, buildImage, dest)
+	relocatedBuildImageRef, err := u.Relocator.Relocate(keychain, buildImage, buildDest)
 	if err != nil {
 		return "", "", err
 	}
 
-	relocatedRunImageRef, err := u.Relocator.Relocate(keychain, runImage, dest)
+	relocatedRunImageRef, err := u.Relocator.Relocate(keychain, runImage, runDest)
 	if err != nil {
 		return "", "", err
 	}
@@ -78,7 +80,30 @@ func (u *Uploader) ValidateStackIDs(keychain authn.Keychain, buildImageTag, runI
 	return buildStackId, nil
 }
 
+// getStackId returns the stack id shared by every platform of img.
 func getStackId(img v1.Image) (string, error) {
+	images, err := registry.PlatformImages(img)
+	if err != nil {
+		return "", err
+	}
+
+	var stackId string
+	for _, i := range images {
+		id, err := getPlatformStackId(i)
+		if err != nil {
+			return "", err
+		}
+
+		if stackId != "" && id != stackId {
+			return "", errors.Errorf("image platforms have different stack ids '%s' and '%s'", stackId, id)
+		}
+		stackId = id
+	}
+
+	return stackId, nil
+}
+
+func getPlatformStackId(img v1.Image) (string, error) {
 	config, err := img.ConfigFile()
 	if err != nil {
 		return "", err
@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/pivotal/kpack/pkg/registry/imagehelpers"
 	kpackregistryfakes "github.com/pivotal/kpack/pkg/registry/registryfakes"
 	"github.com/sclevine/spec"
 	"github.com/stretchr/testify/require"
 
+	"github.com/vmware-tanzu/kpack-cli/pkg/registry"
 	registryfakes "github.com/vmware-tanzu/kpack-cli/pkg/registry/fakes"
 )
 
@@ -44,11 +48,11 @@ func testBuildpackageUploader(t *testing.T, when spec.G, it spec.S) {
 			runDigest, err := testRunImage.Digest()
 			require.NoError(t, err)
 
-			bldImage, runImage, err := uploader.UploadStackImages(fakeKeychain, "some/remote-build", "some/remote-run", "kpackcr.org/somepath")
+			bldImage, runImage, err := uploader.UploadStackImages(fakeKeychain, "some/remote-build", "some/remote-run", "kpackcr.org/somepath", "kpackcr.org/somepath/run")
 			require.NoError(t, err)
 
 			expectedBldImage := fmt.Sprintf("kpackcr.org/somepath@%s", bldDigest)
-			expectedRunImage := fmt.Sprintf("kpackcr.org/somepath@%s", runDigest)
+			expectedRunImage := fmt.Sprintf("kpackcr.org/somepath/run@%s", runDigest)
 			require.Equal(t, expectedBldImage, bldImage)
 			require.Equal(t, expectedRunImage, runImage)
 			require.Equal(t, 2, relocator.CallCount())
@@ -93,5 +97,34 @@ func testBuildpackageUploader(t *testing.T, when spec.G, it spec.S) {
 			_, err = uploader.ValidateStackIDs(fakeKeychain, "some/remote-build", "some/remote-run")
 			require.EqualError(t, err, "build stack 'some-id' does not match run stack 'some-other-id'")
 		})
+
+		it("validates every platform of a multi-arch run image", func() {
+			testBuildImage, err := random.Image(10, 10)
+			require.NoError(t, err)
+			testBuildImage, err = imagehelpers.SetStringLabel(testBuildImage, "io.buildpacks.stack.id", "some-id")
+			require.NoError(t, err)
+
+			amd64Image, err := random.Image(10, 10)
+			require.NoError(t, err)
+			amd64Image, err = imagehelpers.SetStringLabel(amd64Image, "io.buildpacks.stack.id", "some-id")
+			require.NoError(t, err)
+			arm64Image, err := random.Image(10, 10)
+			require.NoError(t, err)
+			arm64Image, err = imagehelpers.SetStringLabel(arm64Image, "io.buildpacks.stack.id", "some-other-id")
+			require.NoError(t, err)
+
+			index := mutate.AppendManifests(empty.Index,
+				mutate.IndexAddendum{Add: amd64Image, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "amd64"}}},
+				mutate.IndexAddendum{Add: arm64Image, Descriptor: v1.Descriptor{Platform: &v1.Platform{OS: "linux", Architecture: "arm64"}}},
+			)
+			testRunImage, err := registry.NewIndexImage(index)
+			require.NoError(t, err)
+
+			fetcher.AddImage("some/remote-build", testBuildImage)
+			fetcher.AddImage("some/remote-run", testRunImage)
+
+			_, err = uploader.ValidateStackIDs(fakeKeychain, "some/remote-build", "some/remote-run")
+			require.EqualError(t, err, "image platforms have different stack ids 'some-id' and 'some-other-id'")
+		})
 	})
 }